@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/daemon"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/spf13/cobra"
+)
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "View the audit log",
+		Long: `View the audit log, a record of who started and stopped the daemon,
+tunnels, and groups, and who reloaded the config.
+
+Each entry has the UID of the user whose request caused it, or the signal
+that did. --since takes the same values as bore logs --since.`,
+		RunE: runAudit,
+	}
+
+	cmd.Flags().IntP("lines", "n", 50, "Number of entries to show")
+	cmd.Flags().String("since", "", "Only show entries newer than a duration ago or a time")
+	cmd.Flags().Bool("json", false, "Output entries as JSON lines")
+
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	auditPath, err := ipc.AuditPath()
+	if err != nil {
+		return err
+	}
+
+	lines, _ := cmd.Flags().GetInt("lines")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	var since time.Time
+	if value, _ := cmd.Flags().GetString("since"); value != "" {
+		if since, err = parseSince(value, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(auditPath)
+	if os.IsNotExist(err) {
+		fmt.Println("No audit log found. Start the daemon with 'bore start' first.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := readAudit(file, since)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if len(entries) > lines {
+		entries = entries[len(entries)-lines:]
+	}
+
+	for _, line := range entries {
+		if asJSON {
+			fmt.Println(line)
+		} else if entry, ok := daemon.ParseAuditEntry(line); ok {
+			fmt.Println(entry.Text())
+		} else {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// readAudit returns the audit log lines at or after since. Lines that don't
+// parse are kept, since an audit trail shouldn't hide anything.
+func readAudit(r io.Reader, since time.Time) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if entry, ok := daemon.ParseAuditEntry(line); ok && entry.Time.Before(since) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
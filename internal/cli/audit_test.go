@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAudit(t *testing.T) {
+	log := `{"time":"2026-01-02T10:00:00Z","event":"daemon_start","uid":501}
+{"time":"2026-01-02T11:00:00Z","event":"tunnel_up","tunnel":"web","host":"bastion","uid":501}
+
+garbage
+{"time":"2026-01-02T12:00:00Z","event":"tunnel_down","tunnel":"web","uid":501}
+`
+
+	all, err := readAudit(strings.NewReader(log), time.Time{})
+	if err != nil {
+		t.Fatalf("readAudit failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 lines including the unparsable one, got %d: %q", len(all), all)
+	}
+
+	since := time.Date(2026, 1, 2, 11, 0, 0, 0, time.UTC)
+	recent, err := readAudit(strings.NewReader(log), since)
+	if err != nil {
+		t.Fatalf("readAudit failed: %v", err)
+	}
+	if len(recent) != 3 || !strings.Contains(recent[0], "tunnel_up") || recent[1] != "garbage" {
+		t.Errorf("unexpected lines since %s: %q", since, recent)
+	}
+}
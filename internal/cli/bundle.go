@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// Names of the files in an export bundle
+const (
+	bundleConfig = "config.yaml"
+	bundleState  = "state.json"
+)
+
+// bundle is the contents of an export bundle. State is nil if it wasn't
+// exported.
+type bundle struct {
+	Config []byte
+	State  []byte
+}
+
+// bundleStateFile is the part of the daemon state worth moving to another
+// machine: which tunnels and groups were up, and via which hosts
+type bundleStateFile struct {
+	ActiveTunnels []state.TunnelState `json:"active_tunnels"`
+	ActiveGroups  []state.GroupState  `json:"active_groups"`
+}
+
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the config as a tar bundle",
+		Long: `Write the bore config to a tar archive on stdout, for bore import to
+restore on another machine or as a backup.
+
+With --state, the tunnels and groups that are up are included too, so the
+daemon brings them back after an import. Host definitions are exported as
+written, including identity file paths, but key material is never read or
+included; copy keys separately.`,
+		Example: "  bore export --state > bore.tar",
+		Args:    cobra.NoArgs,
+		RunE:    runExport,
+	}
+	cmd.Flags().Bool("state", false, "Include the tunnels and groups that are up")
+	cmd.Flags().StringP("output", "o", "", "Write the bundle to a file instead of stdout")
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Restore the config from an export bundle",
+		Long: `Restore the config, and state if the bundle has it, from a bundle written
+by bore export. Use - to read the bundle from stdin.
+
+The config is validated before anything is written, and an existing config
+or state file is only replaced with --force. State can't be imported while
+the daemon is running, since the daemon would overwrite it; stop it first.`,
+		Example: "  bore import bore.tar",
+		Args:    cobra.ExactArgs(1),
+		RunE:    runImport,
+	}
+	cmd.Flags().Bool("force", false, "Replace an existing config or state file")
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no config to export at %s", configPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	b := bundle{Config: data}
+
+	if withState, _ := cmd.Flags().GetBool("state"); withState {
+		statePath, err := ipc.StatePath()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(statePath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read state file: %w", err)
+		}
+		if b.State, err = sanitizeState(data); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundle(&buf, b, time.Now()); err != nil {
+		return err
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		out := cmd.OutOrStdout()
+		if f, ok := out.(*os.File); ok && isTerminal(f) {
+			return fmt.Errorf("refusing to write a tar archive to a terminal (redirect stdout or use --output)")
+		}
+		_, err := out.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	what := "config"
+	if b.State != nil {
+		what = "config and state"
+	}
+	fmt.Fprintf(progress(cmd), "Exported %s to %s\n", what, output)
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	var r io.Reader = cmd.InOrStdin()
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open bundle: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	b, err := readBundle(r)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Parse(b.Config)
+	if err != nil {
+		return fmt.Errorf("bundle has an invalid config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("bundle has an invalid config:\n%w", err)
+	}
+
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	statePath, err := ipc.StatePath()
+	if err != nil {
+		return err
+	}
+	if b.State != nil && ipc.IsDaemonRunning() {
+		return fmt.Errorf("can't import state while the daemon is running (stop it with 'bore stop' first)")
+	}
+
+	// Check every target before writing any, so a refusal leaves both alone
+	force, _ := cmd.Flags().GetBool("force")
+	targets := []string{configPath}
+	if b.State != nil {
+		targets = append(targets, statePath)
+	}
+	for _, path := range targets {
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("%s already exists (use --force to replace it)", path)
+		}
+	}
+
+	if err := writeFileAtomic(configPath, b.Config); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	out := progress(cmd)
+	fmt.Fprintf(out, "Imported config to %s\n", configPath)
+	if b.State != nil {
+		if err := writeFileAtomic(statePath, b.State); err != nil {
+			return fmt.Errorf("failed to write state file: %w", err)
+		}
+		fmt.Fprintf(out, "Imported state to %s\n", statePath)
+	}
+
+	// Paths from the old machine often don't exist here yet
+	if sshReader, err := config.NewSSHConfigReader(); err == nil {
+		if warnings := append(cfg.Warnings(sshReader), keyPermissionWarnings(cfg, sshReader)...); len(warnings) > 0 {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Configuration warnings:")
+			fmt.Fprintln(cmd.ErrOrStderr(), warnings)
+		}
+	}
+	if ipc.IsDaemonRunning() {
+		fmt.Fprintln(out, "Run 'bore config reload' to apply it to the running daemon")
+	}
+	return nil
+}
+
+// sanitizeState reduces a state file to the tunnels and groups that were up,
+// dropping anything specific to the daemon that wrote it
+func sanitizeState(data []byte) ([]byte, error) {
+	s := bundleStateFile{ActiveTunnels: []state.TunnelState{}, ActiveGroups: []state.GroupState{}}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse state file: %w", err)
+		}
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// writeBundle writes b to w as a tar archive
+func writeBundle(w io.Writer, b bundle, modTime time.Time) error {
+	tw := tar.NewWriter(w)
+	files := []struct {
+		name string
+		data []byte
+	}{{bundleConfig, b.Config}, {bundleState, b.State}}
+
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		hdr := &tar.Header{
+			Name:    f.name,
+			Mode:    0600,
+			Size:    int64(len(f.data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// readBundle reads a bundle written by writeBundle. Files other than the
+// config and state are rejected, so a bundle can't write anywhere else.
+func readBundle(r io.Reader) (*bundle, error) {
+	var b bundle
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry '%s' in bundle", hdr.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		switch hdr.Name {
+		case bundleConfig:
+			b.Config = data
+		case bundleState:
+			var s bundleStateFile
+			if err := json.Unmarshal(data, &s); err != nil {
+				return nil, fmt.Errorf("bundle has an invalid state file: %w", err)
+			}
+			b.State = data
+		default:
+			return nil, fmt.Errorf("unexpected file '%s' in bundle", hdr.Name)
+		}
+	}
+	if b.Config == nil {
+		return nil, fmt.Errorf("bundle has no %s", bundleConfig)
+	}
+	return &b, nil
+}
+
+// writeFileAtomic replaces path with data, creating its directory if needed,
+// so a failed write never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const cfg = `# my tunnels
+tunnels:
+  web:
+    type: local
+    host: bastion
+    local_port: 8080
+    remote_port: 80
+`
+	const st = `{"start_time": "2026-01-02T03:04:05Z", "active_tunnels": [{"name": "web", "host": "bastion"}]}`
+
+	oldHome := t.TempDir()
+	writeFile := func(path, data string) {
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(oldHome, "config.yaml"), cfg)
+	writeFile(filepath.Join(oldHome, "state.json"), st)
+
+	run := func(home string, args ...string) error {
+		t.Setenv(config.HomeEnvVar, home)
+		root := NewRootCmd()
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(args)
+		return root.Execute()
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "bore.tar")
+	if err := run(oldHome, "export", "--state", "-o", bundlePath); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	newHome := filepath.Join(t.TempDir(), "bore")
+	if err := run(newHome, "import", bundlePath); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(newHome, "config.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read imported config: %v", err)
+	}
+	if string(got) != cfg {
+		t.Errorf("expected the config byte for byte, got %q", got)
+	}
+	got, err = os.ReadFile(filepath.Join(newHome, "state.json"))
+	if err != nil {
+		t.Fatalf("failed to read imported state: %v", err)
+	}
+	if strings.Contains(string(got), "start_time") || !strings.Contains(string(got), `"bastion"`) {
+		t.Errorf("expected only the active tunnels in the state, got %s", got)
+	}
+
+	if err := run(newHome, "import", bundlePath); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected import over an existing config to need --force, got %v", err)
+	}
+	if err := run(newHome, "import", "--force", bundlePath); err != nil {
+		t.Errorf("expected --force to replace the config, got %v", err)
+	}
+}
+
+func TestExportChecksCommandOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	home := t.TempDir()
+	t.Setenv(config.HomeEnvVar, home)
+	if err := os.WriteFile(filepath.Join(home, "config.yaml"), []byte("tunnels: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(out io.Writer) error {
+		root := NewRootCmd()
+		root.SetOut(out)
+		root.SetErr(io.Discard)
+		root.SetArgs([]string{"export"})
+		return root.Execute()
+	}
+
+	var buf bytes.Buffer
+	if err := export(&buf); err != nil || buf.Len() == 0 {
+		t.Errorf("expected the bundle written to the command's output, got %d bytes, err %v", buf.Len(), err)
+	}
+
+	// A character device stands in for a terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("can't open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	if err := export(devNull); err == nil || !strings.Contains(err.Error(), "terminal") {
+		t.Errorf("expected export to a terminal to be refused, got %v", err)
+	}
+}
+
+func TestReadBundle(t *testing.T) {
+	tarOf := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, data := range files {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: time.Now()})
+			tw.Write([]byte(data))
+		}
+		tw.Close()
+		return &buf
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"config only", map[string]string{"config.yaml": "tunnels: {}\n"}, ""},
+		{"config and state", map[string]string{"config.yaml": "", "state.json": "{}"}, ""},
+		{"no config", map[string]string{"state.json": "{}"}, "no config.yaml"},
+		{"bad state", map[string]string{"config.yaml": "", "state.json": "nope"}, "invalid state"},
+		{"other file", map[string]string{"config.yaml": "", "../.ssh/authorized_keys": "key"}, "unexpected file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readBundle(tarOf(tt.files))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
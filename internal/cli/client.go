@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/spf13/cobra"
+)
+
+// groupEnableTimeout is the default deadline for group enable, which starts
+// every tunnel in the group sequentially
+const groupEnableTimeout = 2 * time.Minute
+
+// newClient creates an IPC client using the --timeout flag if it was set,
+// otherwise the given default
+func newClient(cmd *cobra.Command, defaultTimeout time.Duration) (*ipc.Client, error) {
+	timeout := defaultTimeout
+	if cmd != nil {
+		if flag := cmd.Flags().Lookup("timeout"); flag != nil && flag.Changed {
+			timeout, _ = cmd.Flags().GetDuration("timeout")
+		}
+	}
+	return ipc.NewClient(ipc.WithTimeout(timeout))
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate shell completions",
+		Long: `Write a completion script for the given shell to stdout.
+
+To load completions in the current shell:
+
+  bash:        source <(bore completion bash)
+  zsh:         source <(bore completion zsh)
+  fish:        bore completion fish | source
+  powershell:  bore completion powershell | Out-String | Invoke-Expression
+
+To load them in every new shell, write the script where your shell looks for
+completions instead:
+
+  bash:        bore completion bash > /etc/bash_completion.d/bore
+  zsh:         bore completion zsh > "${fpath[1]}/_bore"
+  fish:        bore completion fish > ~/.config/fish/completions/bore.fish
+  powershell:  bore completion powershell >> $PROFILE
+
+Bash completions need the bash-completion package, and zsh needs
+'autoload -U compinit; compinit' in ~/.zshrc.`,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeCompletion(cmd.Root(), args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+// writeCompletion writes root's completion script for shell to w
+func writeCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unsupported shell '%s' (use bash, zsh, fish, or powershell)", shell)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	root := NewRootCmd()
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(root, shell, &buf); err != nil {
+				t.Fatalf("writeCompletion failed: %v", err)
+			}
+			if !strings.Contains(buf.String(), "bore") {
+				t.Errorf("expected a %s script for bore, got %d bytes", shell, buf.Len())
+			}
+		})
+	}
+
+	if err := writeCompletion(root, "tcsh", &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestHostFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	writeFile := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	writeFile(filepath.Join(home, ".ssh", "config"), "Host bastion\n  HostName bastion.example.com\n")
+	writeFile(filepath.Join(home, ".bore", "config.yaml"), "hosts:\n  prod:\n    hostname: prod.example.com\n")
+
+	hosts, directive := completeHosts(nil, nil, "b")
+	if len(hosts) != 1 || hosts[0] != "bastion" || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected to complete 'bastion', got %v (%d)", hosts, directive)
+	}
+
+	for _, host := range []string{"bastion", "prod", "admin@1.2.3.4:2222"} {
+		if err := checkHostFlag(host); err != nil {
+			t.Errorf("expected '%s' to be known, got %v", host, err)
+		}
+	}
+	err := checkHostFlag("bastoin")
+	if err == nil || err.Error() != "unknown host 'bastoin' (not in config or ~/.ssh/config)" {
+		t.Errorf("expected an unknown host error, got %v", err)
+	}
+	if err := checkHostFlag("admin@1.2.3.4:99999"); err == nil {
+		t.Error("expected an error for an inline host with a bad port")
+	}
+}
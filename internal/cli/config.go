@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/ssh"
 	"github.com/spf13/cobra"
 )
 
@@ -13,23 +19,35 @@ func newConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Manage configuration",
-		Long:  "Validate or edit the bore configuration file.",
+		Long:  "Validate, edit, or reload the bore configuration file.",
 	}
 
 	cmd.AddCommand(newConfigValidateCmd())
 	cmd.AddCommand(newConfigEditCmd())
 	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigReloadCmd())
 
 	return cmd
 }
 
 func newConfigValidateCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate configuration",
-		Long:  "Check the configuration file for errors.",
-		RunE:  runConfigValidate,
+		Long: `Check the configuration file for errors.
+
+Missing identity files and hosts that aren't defined in bore or ~/.ssh/config
+are reported as warnings, since they may be intentional (agent-only auth,
+plain hostnames). Use --strict to treat warnings as errors.
+
+Use --file to validate a candidate file instead of the installed config, or
+--file - to read it from stdin. Errors and warnings are printed to stderr.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigValidate,
 	}
+	cmd.Flags().Bool("strict", false, "Treat warnings as errors")
+	cmd.Flags().StringP("file", "f", "", "Validate this file instead of the installed config (- for stdin)")
+	return cmd
 }
 
 func newConfigEditCmd() *cobra.Command {
@@ -50,27 +68,117 @@ func newConfigPathCmd() *cobra.Command {
 	}
 }
 
+func newConfigReloadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reload",
+		Short: "Apply config changes to running tunnels",
+		Long: `Tell the daemon to re-read the configuration file. Running tunnels whose
+definition changed are restarted via the same host, tunnels removed from the
+config are stopped, and the rest keep running. Sending SIGHUP to the daemon
+does the same.
+
+The tunnels and groups added, removed, or changed since the daemon last
+applied the config are listed, with the fields that changed.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigReload,
+	}
+	cmd.Flags().Bool("json", false, "Output the reload result as JSON")
+	return cmd
+}
+
 func runConfigValidate(cmd *cobra.Command, args []string) error {
-	cfg, err := config.Load()
+	// Flags parsed fine; a usage dump would only bury the validation errors
+	cmd.SilenceUsage = true
+
+	file, _ := cmd.Flags().GetString("file")
+	cfg, err := loadConfigToValidate(file, cmd.InOrStdin())
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	stderr := cmd.ErrOrStderr()
 	if err := cfg.Validate(); err != nil {
-		fmt.Println("Configuration errors:")
-		fmt.Println(err)
+		fmt.Fprintln(stderr, "Configuration errors:")
+		fmt.Fprintln(stderr, err)
 		return fmt.Errorf("configuration is invalid")
 	}
 
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	warnings := append(cfg.Warnings(sshReader), keyPermissionWarnings(cfg, sshReader)...)
+	if len(warnings) > 0 {
+		fmt.Fprintln(stderr, "Configuration warnings:")
+		fmt.Fprintln(stderr, warnings)
+		if strict, _ := cmd.Flags().GetBool("strict"); strict {
+			return fmt.Errorf("configuration has warnings (--strict)")
+		}
+	}
+
+	if notes := cfg.Notes(); len(notes) > 0 {
+		fmt.Fprintln(stderr, "Notes:")
+		fmt.Fprintln(stderr, notes)
+	}
+
 	// Print summary
-	fmt.Println("Configuration is valid")
-	fmt.Printf("  Hosts: %d\n", len(cfg.Hosts))
-	fmt.Printf("  Tunnels: %d\n", len(cfg.Tunnels))
-	fmt.Printf("  Groups: %d\n", len(cfg.Groups))
+	out := progress(cmd)
+	fmt.Fprintln(out, "Configuration is valid")
+	fmt.Fprintf(out, "  Hosts: %d\n", len(cfg.Hosts))
+	fmt.Fprintf(out, "  Tunnels: %d\n", len(cfg.Tunnels))
+	fmt.Fprintf(out, "  Groups: %d\n", len(cfg.Groups))
 
 	return nil
 }
 
+// keyPermissionWarnings reports hosts whose identity file other users can
+// read, which OpenSSH refuses to use
+func keyPermissionWarnings(cfg *config.Config, sshReader *config.SSHConfigReader) config.ValidationErrors {
+	names := make([]string, 0, len(cfg.Hosts))
+	for name := range cfg.Hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings config.ValidationErrors
+	for _, name := range names {
+		resolved := config.ResolveHost(name, cfg.Hosts[name], sshReader)
+		if resolved.IdentityFile == "" {
+			continue
+		}
+		if err := ssh.CheckKeyPermissions(resolved.IdentityFile); err != nil {
+			warnings = append(warnings, config.ValidationError{
+				Field:   fmt.Sprintf("hosts.%s.identity_file", name),
+				Message: err.Error(),
+			})
+		}
+	}
+	return warnings
+}
+
+// loadConfigToValidate loads the config from file, stdin when file is "-", or
+// the installed config when file is empty. Unlike the installed config, an
+// explicitly named file must exist.
+func loadConfigToValidate(file string, stdin io.Reader) (*config.Config, error) {
+	switch file {
+	case "":
+		return config.Load()
+	case "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return config.Parse(data)
+	default:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return config.Parse(data)
+	}
+}
+
 func runConfigEdit(cmd *cobra.Command, args []string) error {
 	configPath, err := config.ConfigPath()
 	if err != nil {
@@ -92,7 +200,7 @@ func runConfigEdit(cmd *cobra.Command, args []string) error {
 		if err := defaultCfg.SaveTo(configPath); err != nil {
 			return fmt.Errorf("failed to create default config: %w", err)
 		}
-		fmt.Printf("Created default config at %s\n", configPath)
+		fmt.Fprintf(progress(cmd), "Created default config at %s\n", configPath)
 	}
 
 	// Get editor
@@ -115,10 +223,102 @@ func runConfigEdit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate after editing
-	fmt.Println()
+	fmt.Fprintln(progress(cmd))
 	return runConfigValidate(cmd, args)
 }
 
+func runConfigReload(cmd *cobra.Command, args []string) error {
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.ReloadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d tunnel(s) failed to reload", len(result.Failed))
+		}
+		return nil
+	}
+
+	out := progress(cmd)
+	if len(result.Restarted) == 0 && len(result.Stopped) == 0 && len(result.Failed) == 0 {
+		fmt.Fprintln(out, "Reloaded config (no running tunnels changed)")
+		printConfigDiff(out, result.Diff)
+		return nil
+	}
+
+	fmt.Fprintln(out, "Reloaded config")
+	if len(result.Restarted) > 0 {
+		fmt.Fprintf(out, "  Restarted: %s\n", strings.Join(result.Restarted, ", "))
+	}
+	if len(result.Stopped) > 0 {
+		fmt.Fprintf(out, "  Stopped:   %s\n", strings.Join(result.Stopped, ", "))
+	}
+	printConfigDiff(out, result.Diff)
+	if len(result.Failed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(result.Failed))
+	for name := range result.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Failures are errors, so they're shown even with --quiet
+	for _, name := range names {
+		fmt.Fprintf(cmd.ErrOrStderr(), "  Failed:    %s: %s\n", name, result.Failed[name])
+	}
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d tunnel(s) failed to reload", len(result.Failed))
+}
+
+// printConfigDiff lists what changed in the config since the daemon last
+// applied it. An older daemon sends no diff.
+func printConfigDiff(w io.Writer, diff *config.ConfigDiff) {
+	if diff == nil || diff.Empty() {
+		return
+	}
+
+	fmt.Fprintln(w, "Config changes:")
+	list := func(label string, names []string) {
+		if len(names) > 0 {
+			fmt.Fprintf(w, "  %s %s\n", label, strings.Join(names, ", "))
+		}
+	}
+	changed := func(kind string, fields map[string][]string) {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  ~ %s %s: %s\n", kind, name, strings.Join(fields[name], ", "))
+		}
+	}
+
+	list("+ tunnels:", diff.TunnelsAdded)
+	list("- tunnels:", diff.TunnelsRemoved)
+	changed("tunnel", diff.TunnelsChanged)
+	list("+ groups:", diff.GroupsAdded)
+	list("- groups:", diff.GroupsRemoved)
+	changed("group", diff.GroupsChanged)
+}
+
 func runConfigPath(cmd *cobra.Command, args []string) error {
 	configPath, err := config.ConfigPath()
 	if err != nil {
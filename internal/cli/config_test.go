@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+func TestLoadConfigToValidate(t *testing.T) {
+	const yaml = `
+tunnels:
+  web:
+    type: local
+    local_port: 8080
+    remote_port: 80
+`
+
+	cfg, err := loadConfigToValidate("-", strings.NewReader(yaml))
+	if err != nil {
+		t.Fatalf("failed to load from stdin: %v", err)
+	}
+	if _, ok := cfg.Tunnels["web"]; !ok {
+		t.Error("expected tunnel 'web' from stdin")
+	}
+
+	path := filepath.Join(t.TempDir(), "candidate.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	cfg, err = loadConfigToValidate(path, nil)
+	if err != nil {
+		t.Fatalf("failed to load from file: %v", err)
+	}
+	if cfg.Tunnels["web"].RemoteHost != "localhost" {
+		t.Errorf("expected defaults to be applied, got remote_host %q", cfg.Tunnels["web"].RemoteHost)
+	}
+
+	if _, err := loadConfigToValidate(filepath.Join(t.TempDir(), "missing.yaml"), nil); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestPrintConfigDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printConfigDiff(&buf, &config.ConfigDiff{
+		TunnelsAdded:   []string{"metrics"},
+		TunnelsRemoved: []string{"cache"},
+		TunnelsChanged: map[string][]string{"web": {"local_port", "remote_host"}, "db": {"reconnect"}},
+		GroupsChanged:  map[string][]string{"dev": {"tunnels"}},
+	})
+
+	want := `Config changes:
+  + tunnels: metrics
+  - tunnels: cache
+  ~ tunnel db: reconnect
+  ~ tunnel web: local_port, remote_host
+  ~ group dev: tunnels
+`
+	if got := buf.String(); got != want {
+		t.Errorf("printConfigDiff output:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	printConfigDiff(&buf, &config.ConfigDiff{})
+	printConfigDiff(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing printed without changes, got %q", buf.String())
+	}
+}
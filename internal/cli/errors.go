@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+// Exit codes returned by bore for failed commands
+const (
+	ExitFailure          = 1
+	ExitNotFound         = 3
+	ExitPortConflict     = 4
+	ExitHostUnreachable  = 5
+	ExitDaemonNotRunning = 6
+	ExitTunnelsUnhealthy = 7
+)
+
+// exitStatus ends a command with a specific exit code after it has already
+// reported what it found, like bore status --exit-code
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// Quiet reports whether err only carries an exit code and shouldn't be printed
+func Quiet(err error) bool {
+	var status exitStatus
+	return errors.As(err, &status)
+}
+
+// ExitCode returns the process exit status for an error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var status exitStatus
+	if errors.As(err, &status) {
+		return int(status)
+	}
+	switch ipc.ErrorCodeOf(err) {
+	case ipc.ErrCodeTunnelNotFound, ipc.ErrCodeGroupNotFound, ipc.ErrCodeNotRunning:
+		return ExitNotFound
+	case ipc.ErrCodePortConflict:
+		return ExitPortConflict
+	case ipc.ErrCodeHostUnreachable:
+		return ExitHostUnreachable
+	case ipc.ErrCodeDaemonNotRunning:
+		return ExitDaemonNotRunning
+	default:
+		return ExitFailure
+	}
+}
+
+// Hint returns a suggestion for resolving an error, or "" if there isn't one
+func Hint(err error) string {
+	switch ipc.ErrorCodeOf(err) {
+	case ipc.ErrCodeTunnelNotFound, ipc.ErrCodeGroupNotFound:
+		return "run 'bore' to choose from the tunnels and groups in your config"
+	case ipc.ErrCodeNotRunning:
+		return "run 'bore status' to see which tunnels are running"
+	case ipc.ErrCodePortConflict:
+		return "run 'bore status' to see which tunnel is using the port"
+	case ipc.ErrCodeHostUnreachable:
+		return "check that the host is reachable with plain 'ssh'"
+	default:
+		return ""
+	}
+}
@@ -2,8 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"text/tabwriter"
 
+	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/tunnel"
 	"github.com/spf13/cobra"
 )
 
@@ -24,12 +29,14 @@ func newGroupEnableCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "enable <name>",
 		Short: "Enable a tunnel group",
-		Long:  "Start all tunnels in a group, connecting through the specified host.",
+		Long:  "Start all tunnels in a group, connecting through the specified host or the group's default host.",
 		Args:  cobra.ExactArgs(1),
 		RunE:  runGroupEnable,
 	}
-	cmd.Flags().String("host", "", "SSH host to connect through (required)")
-	cmd.MarkFlagRequired("host")
+	cmd.Flags().String("host", "", "SSH host to connect through (defaults to the group's configured host)")
+	cmd.Flags().Bool("dry-run", false, "Show which tunnels would start and any port conflicts without starting them")
+	cmd.Flags().Bool("force", false, "Accept a --host that isn't in bore's config or ~/.ssh/config")
+	cmd.RegisterFlagCompletionFunc("host", completeHosts)
 	return cmd
 }
 
@@ -47,11 +54,36 @@ func runGroupEnable(cmd *cobra.Command, args []string) error {
 	groupName := args[0]
 	host, _ := cmd.Flags().GetString("host")
 
-	if !ipc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running (start with 'bore start')")
+	if force, _ := cmd.Flags().GetBool("force"); host != "" && !force {
+		if err := checkHostFlag(host); err != nil {
+			return err
+		}
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return runGroupEnableDryRun(cmd, groupName, host)
 	}
 
-	client, err := ipc.NewClient()
+	if host == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		group, ok := cfg.GetGroup(groupName)
+		if !ok {
+			return &ipc.Error{Code: ipc.ErrCodeGroupNotFound, Message: fmt.Sprintf("group '%s' not found in config", groupName)}
+		}
+		if group.Host == "" && group.NeedsHost() {
+			return fmt.Errorf("no host specified for group '%s' (use --host or set host in config)", groupName)
+		}
+		host = group.Host
+	}
+
+	if err := ensureDaemon(cmd); err != nil {
+		return err
+	}
+
+	client, err := newClient(cmd, groupEnableTimeout)
 	if err != nil {
 		return err
 	}
@@ -60,7 +92,11 @@ func runGroupEnable(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to enable group '%s': %w", groupName, err)
 	}
 
-	fmt.Printf("Enabled group '%s' via host '%s'\n", groupName, host)
+	if host == "" {
+		fmt.Fprintf(progress(cmd), "Enabled group '%s'\n", groupName)
+	} else {
+		fmt.Fprintf(progress(cmd), "Enabled group '%s' via host '%s'\n", groupName, host)
+	}
 	return nil
 }
 
@@ -68,10 +104,10 @@ func runGroupDisable(cmd *cobra.Command, args []string) error {
 	groupName := args[0]
 
 	if !ipc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running (start with 'bore start')")
+		return ipc.ErrDaemonNotRunning
 	}
 
-	client, err := ipc.NewClient()
+	client, err := newClient(cmd, ipc.DefaultTimeout)
 	if err != nil {
 		return err
 	}
@@ -80,6 +116,142 @@ func runGroupDisable(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to disable group '%s': %w", groupName, err)
 	}
 
-	fmt.Printf("Disabled group '%s'\n", groupName)
+	fmt.Fprintf(progress(cmd), "Disabled group '%s'\n", groupName)
 	return nil
 }
+
+// groupPlanEntry describes what enabling a group would do with one tunnel
+type groupPlanEntry struct {
+	Tunnel  string
+	Type    config.TunnelType
+	Local   string
+	Remote  string
+	Host    string
+	Running string // host the tunnel is already running via, if any
+}
+
+// planGroupEnable works out which host each tunnel in a group would use and
+// what would conflict, given the running tunnels (nil if the daemon is down)
+func planGroupEnable(cfg *config.Config, groupName, host string, running []ipc.TunnelStatus) ([]groupPlanEntry, []error, error) {
+	group, ok := cfg.GetGroup(groupName)
+	if !ok {
+		return nil, nil, &ipc.Error{Code: ipc.ErrCodeGroupNotFound, Message: fmt.Sprintf("group '%s' not found in config", groupName)}
+	}
+	if host == "" {
+		host = group.Host
+	}
+
+	runningCfgs := make(map[string]config.Tunnel, len(running))
+	runningHosts := make(map[string]string, len(running))
+	for _, t := range running {
+		runningCfgs[t.Name] = config.Tunnel{LocalHost: t.LocalHost, LocalPort: t.LocalPort, LocalPortRange: t.LocalPortRange}
+		runningHosts[t.Name] = t.Host
+	}
+
+	problems := tunnel.GroupPortConflicts(group.TunnelNames(), cfg, runningCfgs)
+
+	var plan []groupPlanEntry
+	for _, member := range group.Members() {
+		t, ok := cfg.GetTunnel(member.Tunnel)
+		if !ok {
+			continue // already reported by GroupPortConflicts
+		}
+		entry := groupPlanEntry{
+			Tunnel:  member.Tunnel,
+			Type:    t.Type,
+			Local:   net.JoinHostPort(t.LocalHost, t.LocalPorts().String()),
+			Remote:  t.RemoteTarget(),
+			Host:    host,
+			Running: runningHosts[member.Tunnel],
+		}
+		if member.Host != "" {
+			entry.Host = member.Host
+		}
+		if entry.Host == "" {
+			problems = append(problems, fmt.Errorf("no host for tunnel '%s' (use --host, set host on the group, or list it as '%s@<host>')", member.Tunnel, member.Tunnel))
+		}
+		plan = append(plan, entry)
+	}
+
+	return plan, problems, nil
+}
+
+func runGroupEnableDryRun(cmd *cobra.Command, groupName, host string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Conflicts against running tunnels need the daemon; without it, only
+	// the group itself and ports held by other processes are checked
+	var running []ipc.TunnelStatus
+	if ipc.IsDaemonRunning() {
+		client, err := newClient(cmd, ipc.DefaultTimeout)
+		if err != nil {
+			return err
+		}
+		status, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+		running = status.Tunnels
+	}
+
+	plan, problems, err := planGroupEnable(cfg, groupName, host, running)
+	if err != nil {
+		return err
+	}
+
+	// Probe local ports that bore isn't already holding
+	boundByBore := make(map[int]bool)
+	for _, t := range running {
+		ports := config.Tunnel{LocalPort: t.LocalPort, LocalPortRange: t.LocalPortRange}.LocalPorts()
+		for port := ports.First; port <= ports.Last; port++ {
+			boundByBore[port] = true
+		}
+	}
+	for _, entry := range plan {
+		t, _ := cfg.GetTunnel(entry.Tunnel)
+		if entry.Running != "" || t.Type != config.TunnelTypeLocal {
+			continue
+		}
+		for _, port := range t.Expand() {
+			if boundByBore[port.LocalPort] {
+				continue
+			}
+			if err := tunnel.CheckPortAvailable(port.LocalHost, port.LocalPort); err != nil {
+				problems = append(problems, fmt.Errorf("tunnel '%s': %w", entry.Tunnel, err))
+				break
+			}
+		}
+	}
+
+	fmt.Printf("Enabling group '%s' would start:\n", groupName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TUNNEL\tTYPE\tLOCAL\tREMOTE\tHOST\tNOTE")
+	for _, entry := range plan {
+		via := entry.Host
+		if via == "" {
+			via = "-"
+		}
+		note := ""
+		if entry.Running != "" {
+			note = fmt.Sprintf("already running via '%s'", entry.Running)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", entry.Tunnel, entry.Type, entry.Local, entry.Remote, via, note)
+	}
+	w.Flush()
+
+	if len(problems) == 0 {
+		fmt.Println("\nNo conflicts found (dry run, nothing was started)")
+		return nil
+	}
+
+	fmt.Println("\nConflicts:")
+	for _, p := range problems {
+		fmt.Printf("  %v\n", p)
+	}
+	return fmt.Errorf("group '%s' has %d conflict(s) (dry run, nothing was started)", groupName, len(problems))
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestPlanGroupEnable(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tunnels["web"] = config.Tunnel{Type: config.TunnelTypeLocal, LocalHost: "localhost", LocalPort: 8080, RemoteHost: "web.internal", RemotePort: 80}
+	cfg.Tunnels["api"] = config.Tunnel{Type: config.TunnelTypeLocal, LocalHost: "localhost", LocalPort: 9000, RemoteHost: "api.internal", RemotePort: 80}
+	cfg.Tunnels["db"] = config.Tunnel{Type: config.TunnelTypeLocal, LocalHost: "localhost", LocalPort: 5432, RemoteHost: "db.internal", RemotePort: 5432}
+	cfg.Groups["dev"] = config.Group{Host: "bastion", Tunnels: []string{"web", "api", "db@db-bastion"}}
+
+	running := []ipc.TunnelStatus{
+		{Name: "other", Host: "bastion", LocalPort: 9000},
+		{Name: "db", Host: "db-bastion", LocalPort: 5432},
+	}
+
+	plan, problems, err := planGroupEnable(cfg, "dev", "", running)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hosts := make(map[string]string)
+	for _, entry := range plan {
+		hosts[entry.Tunnel] = entry.Host
+	}
+	if hosts["web"] != "bastion" || hosts["db"] != "db-bastion" {
+		t.Errorf("expected group host for web and member host for db, got %v", hosts)
+	}
+	if plan[2].Running != "db-bastion" {
+		t.Errorf("expected db to be reported as already running, got %q", plan[2].Running)
+	}
+
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "'other'") {
+		t.Errorf("expected a single conflict with running tunnel 'other', got %v", problems)
+	}
+
+	if _, _, err := planGroupEnable(cfg, "missing", "", nil); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
+
+func TestPlanGroupEnableMissingHost(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tunnels["web"] = config.Tunnel{Type: config.TunnelTypeLocal, LocalPort: 8080, RemotePort: 80}
+	cfg.Groups["dev"] = config.Group{Tunnels: []string{"web"}}
+
+	_, problems, err := planGroupEnable(cfg, "dev", "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "no host") {
+		t.Errorf("expected a missing host problem, got %v", problems)
+	}
+
+	if _, problems, _ := planGroupEnable(cfg, "dev", "bastion", nil); len(problems) != 0 {
+		t.Errorf("expected --host to satisfy the group, got %v", problems)
+	}
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/spf13/cobra"
+)
+
+func newHostsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hosts",
+		Short: "Show SSH host connections",
+		Long:  "Display each SSH host the daemon is connected to and the tunnels sharing that connection.",
+		RunE:  runHosts,
+	}
+
+	cmd.AddCommand(newHostsResolveCmd())
+
+	return cmd
+}
+
+func newHostsResolveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resolve <name>",
+		Short: "Show the resolved settings for a host",
+		Long:  "Print the host settings bore will use after merging bore's config with ~/.ssh/config. The daemon does not need to be running.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runHostsResolve,
+	}
+
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runHosts(cmd *cobra.Command, args []string) error {
+	if !ipc.IsDaemonRunning() {
+		fmt.Println("Daemon is not running")
+		return nil
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.HostStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get host status: %w", err)
+	}
+
+	if len(status.Hosts) == 0 {
+		fmt.Println("No active SSH connections")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tSTATUS\tRTT\tTUNNELS\tLAST CONNECTED")
+
+	for _, h := range status.Hosts {
+		statusStr := "disconnected"
+		if h.Connected {
+			statusStr = "connected"
+		}
+		lastConnected := h.LastConnected
+		if lastConnected == "" {
+			lastConnected = "-"
+		}
+		tunnels := fmt.Sprintf("%d", len(h.Tunnels))
+		if len(h.Tunnels) > 0 {
+			tunnels = fmt.Sprintf("%d (%s)", len(h.Tunnels), strings.Join(h.Tunnels, ", "))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", h.Name, statusStr, formatRTT(h.RTTMillis), tunnels, lastConnected)
+	}
+	w.Flush()
+
+	return nil
+}
+
+// resolvedHost is the JSON form of a resolved host
+type resolvedHost struct {
+	Name              string `json:"name"`
+	Hostname          string `json:"hostname"`
+	User              string `json:"user,omitempty"`
+	Port              int    `json:"port"`
+	IdentityFile      string `json:"identity_file,omitempty"`
+	CertFile          string `json:"cert_file,omitempty"`
+	IdentitiesOnly    bool   `json:"identities_only"`
+	IdentityAgent     string `json:"identity_agent,omitempty"`
+	ProxyJump         string `json:"proxy_jump,omitempty"`
+	ConnectTimeout    string `json:"connect_timeout,omitempty"`
+	KeepAliveInterval string `json:"keep_alive_interval,omitempty"`
+	KeepAliveMethod   string `json:"keep_alive_method"`
+}
+
+func runHostsResolve(cmd *cobra.Command, args []string) error {
+	hostName := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	host := cfg.ResolveHostName(hostName, sshReader)
+
+	resolved := resolvedHost{
+		Name:           hostName,
+		Hostname:       host.Hostname,
+		User:           host.User,
+		Port:           host.Port,
+		IdentityFile:   host.IdentityFile,
+		CertFile:       host.CertFile,
+		IdentitiesOnly: host.IdentitiesOnly,
+		IdentityAgent:  host.IdentityAgent,
+		ProxyJump:      host.ProxyJump,
+
+		KeepAliveMethod: cfg.KeepAliveMethod(host),
+	}
+	if host.ConnectTimeout > 0 {
+		resolved.ConnectTimeout = host.ConnectTimeout.String()
+	}
+	if host.KeepAliveInterval != nil {
+		resolved.KeepAliveInterval = "disabled"
+		if *host.KeepAliveInterval > 0 {
+			resolved.KeepAliveInterval = host.KeepAliveInterval.String()
+		}
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(resolved)
+	}
+
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", resolved.Name)
+	fmt.Fprintf(w, "Hostname:\t%s\n", resolved.Hostname)
+	user := resolved.User
+	if user == "" {
+		// Matches the fallback in ssh.Client.Connect
+		user = "root (default)"
+	}
+	fmt.Fprintf(w, "User:\t%s\n", user)
+	fmt.Fprintf(w, "Port:\t%d\n", resolved.Port)
+	fmt.Fprintf(w, "Identity file:\t%s\n", orDash(resolved.IdentityFile))
+	fmt.Fprintf(w, "Certificate file:\t%s\n", orDash(resolved.CertFile))
+	fmt.Fprintf(w, "Identities only:\t%t\n", resolved.IdentitiesOnly)
+	fmt.Fprintf(w, "Identity agent:\t%s\n", orDash(resolved.IdentityAgent))
+	fmt.Fprintf(w, "Proxy jump:\t%s\n", orDash(resolved.ProxyJump))
+	fmt.Fprintf(w, "Connect timeout:\t%s\n", orDash(resolved.ConnectTimeout))
+	fmt.Fprintf(w, "Keepalive interval:\t%s\n", orDash(resolved.KeepAliveInterval))
+	fmt.Fprintf(w, "Keepalive method:\t%s\n", resolved.KeepAliveMethod)
+	w.Flush()
+
+	return nil
+}
+
+// checkHostFlag rejects a --host that neither bore's config nor
+// ~/.ssh/config defines and that isn't a valid inline user@hostname:port,
+// so a typo fails before the daemon tries to dial it
+func checkHostFlag(host string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+	if cfg.IsKnownHost(host, sshReader) {
+		return nil
+	}
+	// An inline user@hostname:port is deliberate, not a typo
+	if config.IsHostSpec(host) {
+		_, err := config.ParseHostSpec(host)
+		return err
+	}
+	return fmt.Errorf("unknown host '%s' (not in config or ~/.ssh/config)", host)
+}
+
+// completeHosts completes --host with the hosts defined in bore's config and
+// ~/.ssh/config
+func completeHosts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var hosts []string
+	for _, host := range cfg.KnownHosts(sshReader) {
+		if strings.HasPrefix(host, toComplete) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, cobra.ShellCompDirectiveNoFileComp
+}
@@ -1,15 +1,32 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/spf13/cobra"
 )
 
-func runInteractive() error {
+const (
+	// daemonReadyWait bounds how long the interactive flow waits for a daemon
+	// it just started to answer before sending it commands
+	daemonReadyWait = 2 * time.Second
+
+	// refusedRetryDelay is the pause before retrying a refused first request
+	refusedRetryDelay = 250 * time.Millisecond
+)
+
+func runInteractive(cmd *cobra.Command) error {
 	// Load config to get available tunnels and groups
 	cfg, err := config.Load()
 	if err != nil {
@@ -56,8 +73,9 @@ func runInteractive() error {
 
 	for _, name := range tunnelNames {
 		t := cfg.Tunnels[name]
-		label := fmt.Sprintf("%s (%s:%d -> %s:%d)",
-			name, t.LocalHost, t.LocalPort, t.RemoteHost, t.RemotePort)
+		label := fmt.Sprintf("%s (%s -> %s)", name,
+			net.JoinHostPort(t.LocalHost, t.LocalPorts().String()),
+			t.RemoteTarget())
 		if runningTunnels[name] {
 			label = "[*] " + label
 		} else {
@@ -106,25 +124,33 @@ func runInteractive() error {
 
 	switch action {
 	case "start":
-		return runStart(nil, nil)
+		return runStart(cmd, nil)
 
 	case "stop":
-		return runStop(nil, nil)
+		return runStop(cmd, nil)
 
 	case "status":
-		return runStatus(nil, nil)
+		return runStatus(cmd, nil)
 
 	case "tunnels":
-		return manageTunnels(client, tunnelOptions, runningTunnels, daemonRunning)
+		return manageTunnels(cmd, cfg, client, tunnelOptions, runningTunnels, daemonRunning)
 
 	case "groups":
-		return manageGroups(client, groupOptions, daemonRunning)
+		return manageGroups(cmd, cfg, client, groupOptions, daemonRunning)
 	}
 
 	return nil
 }
 
-func manageTunnels(client *ipc.Client, options []huh.Option[string], running map[string]bool, daemonRunning bool) error {
+// interactiveClient is the part of ipc.Client the interactive flow uses
+type interactiveClient interface {
+	TunnelUp(req ipc.TunnelRequest) (*ipc.TunnelUpResponse, error)
+	TunnelDown(name string) error
+	GroupEnable(name, host string) error
+	GroupDisable(name string) error
+}
+
+func manageTunnels(cmd *cobra.Command, cfg *config.Config, client *ipc.Client, options []huh.Option[string], running map[string]bool, daemonRunning bool) error {
 	if len(options) == 0 {
 		fmt.Println("No tunnels configured.")
 		return nil
@@ -146,16 +172,16 @@ func manageTunnels(client *ipc.Client, options []huh.Option[string], running map
 		return nil
 	}
 
-	// Check if any tunnels need to be started (require host selection)
-	var tunnelsToStart []string
+	// Only ask for a host if a tunnel being started has no default of its own
+	needsHost := false
 	for _, name := range selectedTunnels {
-		if !running[name] {
-			tunnelsToStart = append(tunnelsToStart, name)
+		if t, ok := cfg.GetTunnel(name); ok && !running[name] && t.Host == "" {
+			needsHost = true
 		}
 	}
 
 	var host string
-	if len(tunnelsToStart) > 0 {
+	if needsHost {
 		host, err = selectHost()
 		if err != nil {
 			return err
@@ -164,38 +190,55 @@ func manageTunnels(client *ipc.Client, options []huh.Option[string], running map
 
 	if !daemonRunning {
 		fmt.Println("Starting daemon first...")
-		if err := runStart(nil, nil); err != nil {
+		if err := runStart(cmd, nil); err != nil {
 			return err
 		}
-		client, err = ipc.NewClient()
+		client, err = connectAfterStart(cmd, ipc.DefaultTimeout)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Toggle selected tunnels
-	for _, name := range selectedTunnels {
+	toggleTunnels(cmd.OutOrStdout(), client, cfg, selectedTunnels, running, host, !daemonRunning)
+	return nil
+}
+
+// toggleTunnels stops the tunnels that are running and starts the rest, each
+// via its default host if it has one and host otherwise. With retryFirst, the
+// first request is retried if the daemon wasn't accepting connections yet.
+func toggleTunnels(out io.Writer, client interactiveClient, cfg *config.Config, names []string, running map[string]bool, host string, retryFirst bool) {
+	for i, name := range names {
+		req := ipc.TunnelRequest{Name: name, Host: host}
+		if t, ok := cfg.GetTunnel(name); ok && t.Host != "" {
+			req.Host = t.Host
+		}
+		toggle := func() error {
+			_, err := client.TunnelUp(req)
+			return err
+		}
 		if running[name] {
-			fmt.Printf("Stopping tunnel '%s'... ", name)
-			if err := client.TunnelDown(name); err != nil {
-				fmt.Printf("error: %v\n", err)
-			} else {
-				fmt.Println("done")
-			}
+			fmt.Fprintf(out, "Stopping tunnel '%s'... ", name)
+			toggle = func() error { return client.TunnelDown(name) }
 		} else {
-			fmt.Printf("Starting tunnel '%s' via host '%s'... ", name, host)
-			if err := client.TunnelUp(name, host); err != nil {
-				fmt.Printf("error: %v\n", err)
-			} else {
-				fmt.Println("done")
-			}
+			fmt.Fprintf(out, "Starting tunnel '%s' via host '%s'... ", name, req.Host)
 		}
-	}
 
-	return nil
+		// A daemon that was just started may not be accepting connections yet
+		var err error
+		if i == 0 && retryFirst {
+			err = retryIfRefused(toggle)
+		} else {
+			err = toggle()
+		}
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		} else {
+			fmt.Fprintln(out, "done")
+		}
+	}
 }
 
-func manageGroups(client *ipc.Client, options []huh.Option[string], daemonRunning bool) error {
+func manageGroups(cmd *cobra.Command, cfg *config.Config, client *ipc.Client, options []huh.Option[string], daemonRunning bool) error {
 	if len(options) == 0 {
 		fmt.Println("No groups configured.")
 		return nil
@@ -226,9 +269,11 @@ func manageGroups(client *ipc.Client, options []huh.Option[string], daemonRunnin
 		return err
 	}
 
-	// If enabling, ask for host
-	var host string
-	if action == "enable" {
+	// If enabling, ask for a host unless the group has a default or doesn't
+	// need one, as when every member names its own
+	group, _ := cfg.GetGroup(selectedGroup)
+	host := group.Host
+	if action == "enable" && host == "" && group.NeedsHost() {
 		host, err = selectHost()
 		if err != nil {
 			return err
@@ -237,82 +282,140 @@ func manageGroups(client *ipc.Client, options []huh.Option[string], daemonRunnin
 
 	if !daemonRunning {
 		fmt.Println("Starting daemon first...")
-		if err := runStart(nil, nil); err != nil {
+		if err := runStart(cmd, nil); err != nil {
 			return err
 		}
-		client, err = ipc.NewClient()
+		client, err = connectAfterStart(cmd, groupEnableTimeout)
 		if err != nil {
 			return err
 		}
 	}
 
+	applyGroup(cmd.OutOrStdout(), client, selectedGroup, action, host, !daemonRunning)
+	return nil
+}
+
+// applyGroup enables the group via host, or disables it. With retry, the
+// request is retried if the daemon wasn't accepting connections yet.
+func applyGroup(out io.Writer, client interactiveClient, name, action, host string, retry bool) {
+	var apply func() error
 	switch action {
 	case "enable":
-		fmt.Printf("Enabling group '%s' via host '%s'... ", selectedGroup, host)
-		if err := client.GroupEnable(selectedGroup, host); err != nil {
-			fmt.Printf("error: %v\n", err)
+		if host != "" {
+			fmt.Fprintf(out, "Enabling group '%s' via host '%s'... ", name, host)
 		} else {
-			fmt.Println("done")
+			fmt.Fprintf(out, "Enabling group '%s'... ", name)
 		}
+		apply = func() error { return client.GroupEnable(name, host) }
 	case "disable":
-		fmt.Printf("Disabling group '%s'... ", selectedGroup)
-		if err := client.GroupDisable(selectedGroup); err != nil {
-			fmt.Printf("error: %v\n", err)
-		} else {
-			fmt.Println("done")
-		}
+		fmt.Fprintf(out, "Disabling group '%s'... ", name)
+		apply = func() error { return client.GroupDisable(name) }
+	default:
+		return
 	}
 
-	return nil
+	// A daemon that was just started may not be accepting connections yet
+	var err error
+	if retry {
+		err = retryIfRefused(apply)
+	} else {
+		err = apply()
+	}
+	if err != nil {
+		fmt.Fprintf(out, "error: %v\n", err)
+	} else {
+		fmt.Fprintln(out, "done")
+	}
+}
+
+// connectAfterStart returns a client for a daemon that was just started,
+// waiting briefly for it to answer pings first
+func connectAfterStart(cmd *cobra.Command, timeout time.Duration) (*ipc.Client, error) {
+	deadline := time.Now().Add(daemonReadyWait)
+	for !ipc.IsDaemonRunning() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return newClient(cmd, timeout)
+}
+
+// retryIfRefused runs fn, and runs it once more if it couldn't reach the
+// daemon's socket. The request never arrived, so retrying it is safe.
+func retryIfRefused(fn func() error) error {
+	err := fn()
+	if connRefused(err) {
+		time.Sleep(refusedRetryDelay)
+		err = fn()
+	}
+	return err
+}
+
+// connRefused reports whether err means nothing was listening on the socket
+func connRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, fs.ErrNotExist)
 }
 
+// otherHost is the value of the host option that asks for a host to be typed in
+const otherHost = "\x00other"
+
 func selectHost() (string, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return "", fmt.Errorf("failed to load config: %w", err)
 	}
-
-	// Build host options from config
-	var hostOptions []huh.Option[string]
-	hostNames := make([]string, 0, len(cfg.Hosts))
-	for name := range cfg.Hosts {
-		hostNames = append(hostNames, name)
-	}
-	sort.Strings(hostNames)
-
-	for _, name := range hostNames {
-		h := cfg.Hosts[name]
-		label := name
-		if h.Hostname != "" {
-			label = fmt.Sprintf("%s (%s)", name, h.Hostname)
-		}
-		hostOptions = append(hostOptions, huh.NewOption(label, name))
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSH config: %w", err)
 	}
 
-	// If no hosts configured, ask for manual input
-	if len(hostOptions) == 0 {
-		var host string
-		err := huh.NewInput().
-			Title("Enter SSH host").
-			Description("No hosts configured in config file").
-			Value(&host).
+	// With no hosts to pick from, go straight to typing one in
+	options := hostOptions(cfg, sshReader)
+	selectedHost := otherHost
+	if len(options) > 1 {
+		err = huh.NewSelect[string]().
+			Title("Select SSH host to connect through").
+			Options(options...).
+			Value(&selectedHost).
 			Run()
 		if err != nil {
 			return "", err
 		}
-		return host, nil
+	}
+	if selectedHost != otherHost {
+		return selectedHost, nil
 	}
 
-	var selectedHost string
-	err = huh.NewSelect[string]().
-		Title("Select SSH host to connect through").
-		Options(hostOptions...).
-		Value(&selectedHost).
+	var host string
+	err = huh.NewInput().
+		Title("Enter SSH host").
+		Description("A host alias or user@hostname:port").
+		Validate(func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return errors.New("host is required")
+			}
+			return nil
+		}).
+		Value(&host).
 		Run()
-
 	if err != nil {
 		return "", err
 	}
+	return strings.TrimSpace(host), nil
+}
 
-	return selectedHost, nil
+// hostOptions lists the hosts defined in bore's config and ~/.ssh/config,
+// labelled with the hostname each connects to, followed by an option to type
+// in another
+func hostOptions(cfg *config.Config, sshReader *config.SSHConfigReader) []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, name := range cfg.KnownHosts(sshReader) {
+		label := name
+		if h := cfg.ResolveHostName(name, sshReader); h.Hostname != name {
+			label = fmt.Sprintf("%s (%s)", name, h.Hostname)
+		}
+		if _, ok := cfg.Hosts[name]; !ok {
+			label += " [ssh config]"
+		}
+		options = append(options, huh.NewOption(label, name))
+	}
+	return append(options, huh.NewOption("Other...", otherHost))
 }
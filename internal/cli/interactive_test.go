@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestConnRefused(t *testing.T) {
+	_, err := net.Dial("unix", filepath.Join(t.TempDir(), "missing.sock"))
+	if !connRefused(fmt.Errorf("failed to connect to daemon: %w", err)) {
+		t.Errorf("expected a missing socket to count as refused, got %v", err)
+	}
+	if connRefused(errors.New("tunnel 'web' not found")) {
+		t.Error("expected an error from the daemon not to count as refused")
+	}
+	if connRefused(nil) {
+		t.Error("expected nil not to count as refused")
+	}
+}
+
+func TestRetryIfRefused(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", []error{nil}, 1, false},
+		{"refused then succeeds", []error{syscall.ECONNREFUSED, nil}, 2, false},
+		{"refused twice", []error{syscall.ECONNREFUSED, syscall.ECONNREFUSED}, 2, true},
+		{"other errors aren't retried", []error{errors.New("boom")}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryIfRefused(func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHostOptions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sshConfig := `
+Host bastion
+  HostName bastion.example.com
+
+Host db
+  HostName db.example.com
+
+Host *.internal
+  User deploy
+`
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sshReader, err := config.NewSSHConfigReader()
+	if err != nil {
+		t.Fatalf("failed to read SSH config: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Hosts = map[string]config.Host{
+		"db":   {Hostname: "10.0.0.5"},
+		"prod": {},
+	}
+
+	want := []struct{ key, value string }{
+		{"bastion (bastion.example.com) [ssh config]", "bastion"},
+		{"db (10.0.0.5)", "db"},
+		{"prod", "prod"},
+		{"Other...", otherHost},
+	}
+	got := hostOptions(cfg, sshReader)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d options, got %d: %v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		if got[i].Key != w.key || got[i].Value != w.value {
+			t.Errorf("option %d = (%q, %q), want (%q, %q)", i, got[i].Key, got[i].Value, w.key, w.value)
+		}
+	}
+}
+
+// recordingClient records the requests the interactive flow sends
+type recordingClient struct {
+	calls []string
+}
+
+func (c *recordingClient) TunnelUp(req ipc.TunnelRequest) (*ipc.TunnelUpResponse, error) {
+	c.calls = append(c.calls, fmt.Sprintf("up %s@%s", req.Name, req.Host))
+	return &ipc.TunnelUpResponse{}, nil
+}
+
+func (c *recordingClient) TunnelDown(name string) error {
+	c.calls = append(c.calls, "down "+name)
+	return nil
+}
+
+func (c *recordingClient) GroupEnable(name, host string) error {
+	c.calls = append(c.calls, fmt.Sprintf("enable %s@%s", name, host))
+	return nil
+}
+
+func (c *recordingClient) GroupDisable(name string) error {
+	c.calls = append(c.calls, "disable "+name)
+	return nil
+}
+
+func TestToggleTunnels(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tunnels = map[string]config.Tunnel{
+		"web": {Host: "prod"},
+		"db":  {},
+		"api": {},
+	}
+	running := map[string]bool{"api": true}
+
+	client := &recordingClient{}
+	toggleTunnels(io.Discard, client, cfg, []string{"web", "db", "api"}, running, "bastion", false)
+
+	want := []string{"up web@prod", "up db@bastion", "down api"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestApplyGroup(t *testing.T) {
+	tests := []struct {
+		action string
+		host   string
+		want   []string
+	}{
+		{"enable", "prod", []string{"enable dev@prod"}},
+		{"enable", "", []string{"enable dev@"}},
+		{"disable", "prod", []string{"disable dev"}},
+		{"other", "prod", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action+"@"+tt.host, func(t *testing.T) {
+			client := &recordingClient{}
+			applyGroup(io.Discard, client, "dev", tt.action, tt.host, false)
+			if !reflect.DeepEqual(client.calls, tt.want) {
+				t.Errorf("calls = %v, want %v", client.calls, tt.want)
+			}
+		})
+	}
+}
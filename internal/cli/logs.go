@@ -2,10 +2,14 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/pjtatlow/bore/internal/daemon"
 	"github.com/pjtatlow/bore/internal/ipc"
 	"github.com/spf13/cobra"
 )
@@ -14,12 +18,23 @@ func newLogsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "logs",
 		Short: "View daemon logs",
-		Long:  "View or follow the daemon log file.",
-		RunE:  runLogs,
+		Long: `View or follow the daemon log file.
+
+--since accepts a duration (10m, 2h) or a time (15:04, 2006-01-02,
+2006-01-02 15:04:05, or RFC 3339). --level shows entries at or above a
+level, so --level warn shows warnings and errors.
+
+--json prints each entry as a JSON object on its own line, whichever format
+the daemon logs in. Lines that aren't log entries, like a panic trace, are
+printed as {"raw": "..."}.`,
+		RunE: runLogs,
 	}
 
 	cmd.Flags().BoolP("follow", "f", false, "Follow the log file (like tail -f)")
 	cmd.Flags().IntP("lines", "n", 50, "Number of lines to show")
+	cmd.Flags().String("since", "", "Only show entries newer than a duration ago or a time")
+	cmd.Flags().String("level", "", "Only show entries at or above this level (debug, info, warn, error)")
+	cmd.Flags().Bool("json", false, "Output entries as JSON lines")
 
 	return cmd
 }
@@ -32,22 +47,90 @@ func runLogs(cmd *cobra.Command, args []string) error {
 
 	follow, _ := cmd.Flags().GetBool("follow")
 	lines, _ := cmd.Flags().GetInt("lines")
+	render, notices := formatLogLine, os.Stdout
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		// Keep stdout to JSON lines only
+		render, notices = jsonLogLine, os.Stderr
+	}
+
+	var filter logFilter
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		if filter.since, err = parseSince(since, time.Now()); err != nil {
+			return err
+		}
+	}
+	if level, _ := cmd.Flags().GetString("level"); level != "" {
+		if filter.minLevel, err = daemon.ParseLevel(level); err != nil {
+			return err
+		}
+	}
 
 	// Check if log file exists
 	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		fmt.Println("No log file found. Start the daemon with 'bore start' first.")
+		fmt.Fprintln(notices, "No log file found. Start the daemon with 'bore start' first.")
 		return nil
 	}
 
 	if follow {
-		return tailFollow(logPath, lines)
+		return tailFollow(logPath, lines, &filter, render, notices)
+	}
+
+	return tailLines(logPath, lines, &filter, render)
+}
+
+// logFilter selects log lines by time and level. Lines that can't be parsed,
+// like a panic trace, follow the decision for the entry before them.
+type logFilter struct {
+	since    time.Time
+	minLevel daemon.Level
+	last     bool
+}
+
+// match reports whether a line passes the filter
+func (f *logFilter) match(line string) bool {
+	if f.since.IsZero() && f.minLevel == "" {
+		return true
+	}
+
+	entry, ok := daemon.ParseLogLine(line)
+	if !ok {
+		return f.last
 	}
+	f.last = !entry.Time.Before(f.since) && (f.minLevel == "" || entry.Level.AtLeast(f.minLevel))
+	return f.last
+}
 
-	return tailLines(logPath, lines)
+// sinceLayouts are the time formats accepted by --since, besides durations
+var sinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
 }
 
-// tailLines shows the last n lines of a file
-func tailLines(path string, n int) error {
+// parseSince turns a --since value into a cutoff time: a duration before now,
+// a date and time, or a clock time today
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value '%s' (use a duration like 10m or a time like 2006-01-02 15:04)", value)
+}
+
+// tailLines shows the last n matching lines of a file, rendered by render
+func tailLines(path string, n int, filter *logFilter, render func(string) string) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
@@ -58,7 +141,9 @@ func tailLines(path string, n int) error {
 	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		if line := scanner.Text(); filter.match(line) {
+			lines = append(lines, line)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -72,16 +157,16 @@ func tailLines(path string, n int) error {
 	}
 
 	for _, line := range lines[start:] {
-		fmt.Println(line)
+		fmt.Println(render(line))
 	}
 
 	return nil
 }
 
-// tailFollow follows the log file like tail -f
-func tailFollow(path string, initialLines int) error {
+// tailFollow follows the log file like tail -f, writing its banner to notices
+func tailFollow(path string, initialLines int, filter *logFilter, render func(string) string, notices io.Writer) error {
 	// First, show initial lines
-	if err := tailLines(path, initialLines); err != nil {
+	if err := tailLines(path, initialLines, filter, render); err != nil {
 		return err
 	}
 
@@ -97,7 +182,7 @@ func tailFollow(path string, initialLines int) error {
 		return err
 	}
 
-	fmt.Println("--- Following log file (Ctrl+C to stop) ---")
+	fmt.Fprintln(notices, "--- Following log file (Ctrl+C to stop) ---")
 
 	reader := bufio.NewReader(file)
 	for {
@@ -109,6 +194,33 @@ func tailFollow(path string, initialLines int) error {
 			}
 			return err
 		}
-		fmt.Print(line)
+		line = strings.TrimRight(line, "\n")
+		if filter.match(line) {
+			fmt.Println(render(line))
+		}
+	}
+}
+
+// formatLogLine renders json log entries as text so logs read the same in either format
+func formatLogLine(line string) string {
+	if entry, ok := daemon.ParseLogEntry(line); ok {
+		return entry.Text()
+	}
+	return line
+}
+
+// jsonLogLine renders a log line in either format as a json entry, or wraps
+// it as {"raw": ...} if it isn't a log entry
+func jsonLogLine(line string) string {
+	var v any = struct {
+		Raw string `json:"raw"`
+	}{line}
+	if entry, ok := daemon.ParseLogLine(line); ok {
+		v = entry
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return line
 	}
+	return string(data)
 }
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/daemon"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"10m", now.Add(-10 * time.Minute), false},
+		{"2024-03-09", time.Date(2024, 3, 9, 0, 0, 0, 0, time.Local), false},
+		{"2024-03-09 08:15", time.Date(2024, 3, 9, 8, 15, 0, 0, time.Local), false},
+		{"09:00", time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	lines := []string{
+		"2024/03/10 09:00:00 [ERROR] old failure",
+		"2024/03/10 15:00:00 [INFO] Started tunnel 'web'",
+		"2024/03/10 15:01:00 [ERROR] Failed to reconnect tunnel 'web'",
+		"goroutine 1 [running]:",
+		"2024/03/10 15:02:00 [WARN] Network unavailable",
+		"  more context from a plain line",
+	}
+
+	filter := logFilter{
+		since:    time.Date(2024, 3, 10, 14, 0, 0, 0, time.Local),
+		minLevel: daemon.LevelWarn,
+	}
+	var got []string
+	for _, line := range lines {
+		if filter.match(line) {
+			got = append(got, line)
+		}
+	}
+
+	want := []string{lines[2], lines[3], lines[4], lines[5]}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJSONLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want map[string]string
+	}{
+		{
+			"text entry",
+			"2024/03/10 15:01:00 [ERROR] Failed to reconnect tunnel 'web'",
+			map[string]string{"level": "error", "msg": "Failed to reconnect tunnel 'web'"},
+		},
+		{
+			"json entry",
+			`{"time":"2024-03-10T15:01:00Z","level":"warn","tunnel":"web","host":"bastion","msg":"Retrying"}`,
+			map[string]string{"time": "2024-03-10T15:01:00Z", "level": "warn", "tunnel": "web", "host": "bastion", "msg": "Retrying"},
+		},
+		{
+			"not an entry",
+			"goroutine 1 [running]:",
+			map[string]string{"raw": "goroutine 1 [running]:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+			if err := json.Unmarshal([]byte(jsonLogLine(tt.line)), &got); err != nil {
+				t.Fatalf("expected a JSON object, got %q: %v", jsonLogLine(tt.line), err)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %q, want %q", key, got[key], want)
+				}
+			}
+		})
+	}
+}
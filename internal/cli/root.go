@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"io"
+	"os"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
 	"github.com/spf13/cobra"
 )
 
@@ -10,24 +15,64 @@ func NewRootCmd() *cobra.Command {
 		Use:   "bore",
 		Short: "SSH tunnel manager",
 		Long:  "Bore is a self-managed SSH tunnel daemon with automatic reconnection and group-based tunnel management.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return applyHome(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Default action: run interactive selector
-			return runInteractive()
+			return runInteractive(cmd)
 		},
 	}
 
+	rootCmd.PersistentFlags().String("home", "", "Directory for bore's config, socket, PID, log, and state files (default $BORE_HOME or ~/.bore)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print errors and requested output, not progress or confirmations")
+	rootCmd.PersistentFlags().Duration("timeout", ipc.DefaultTimeout, "How long to wait for the daemon to respond (group enable defaults to 2m)")
+
 	// Add subcommands
 	rootCmd.AddCommand(newStartCmd())
 	rootCmd.AddCommand(newStopCmd())
 	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newHostsCmd())
 	rootCmd.AddCommand(newGroupCmd())
 	rootCmd.AddCommand(newTunnelCmd())
+	rootCmd.AddCommand(newKillCmd())
 	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newImportCmd())
 	rootCmd.AddCommand(newLogsCmd())
+	rootCmd.AddCommand(newAuditCmd())
+	rootCmd.AddCommand(newVersionCmd())
+	rootCmd.AddCommand(newCompletionCmd())
 
 	return rootCmd
 }
 
+// applyHome exports --home as BORE_HOME and makes it absolute, so the forked
+// daemon (which runs from /) inherits the same directory
+func applyHome(cmd *cobra.Command) error {
+	if home, _ := cmd.Flags().GetString("home"); home != "" {
+		os.Setenv(config.HomeEnvVar, home)
+	}
+	if os.Getenv(config.HomeEnvVar) == "" {
+		return nil
+	}
+
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return err
+	}
+	return os.Setenv(config.HomeEnvVar, dir)
+}
+
+// progress returns where cmd writes progress and confirmation messages:
+// stdout, or nowhere with --quiet
+func progress(cmd *cobra.Command) io.Writer {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return io.Discard
+	}
+	return cmd.OutOrStdout()
+}
+
 // Execute runs the CLI
 func Execute() error {
 	return NewRootCmd().Execute()
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestQuietSuppressesProgress(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("tunnels: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		root := NewRootCmd()
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("bore %s failed: %v", strings.Join(args, " "), err)
+		}
+		return out.String()
+	}
+
+	if out := run("config", "validate", "--file", path); !strings.Contains(out, "Configuration is valid") {
+		t.Errorf("expected a summary without --quiet, got %q", out)
+	}
+	if out := run("--quiet", "config", "validate", "--file", path); out != "" {
+		t.Errorf("expected no output with --quiet, got %q", out)
+	}
+	if out := run("config", "validate", "-q", "--file", path); out != "" {
+		t.Errorf("expected no output with -q after the subcommand, got %q", out)
+	}
+}
+
+func TestKillMatchesTunnelKill(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.HomeEnvVar, t.TempDir())
+
+	for _, args := range [][]string{{"kill", "web"}, {"tunnel", "kill", "web"}} {
+		root := NewRootCmd()
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		root.SetArgs(args)
+		if err := root.Execute(); !errors.Is(err, ipc.ErrDaemonNotRunning) {
+			t.Errorf("bore %s: expected %v, got %v", strings.Join(args, " "), ipc.ErrDaemonNotRunning, err)
+		}
+	}
+}
@@ -2,20 +2,30 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
+	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/daemon"
 	"github.com/pjtatlow/bore/internal/ipc"
 	"github.com/spf13/cobra"
 )
 
 func newStartCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the bore daemon",
-		Long:  "Start the bore daemon in the background. The daemon manages all SSH tunnels.",
-		RunE:  runStart,
+		Long: `Start the bore daemon in the background. The daemon manages all SSH tunnels.
+
+Use --foreground to run the daemon in the current process with logs on stdout,
+for running under systemd, supervisord, or in a container.`,
+		RunE: runStart,
 	}
+
+	cmd.Flags().BoolP("foreground", "f", false, "Run the daemon in the foreground, logging to stdout")
+
+	return cmd
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
@@ -28,28 +38,63 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return d.Run()
 	}
 
+	out := progress(cmd)
+
 	// Check if already running
 	if ipc.IsDaemonRunning() {
-		fmt.Println("Daemon is already running")
+		fmt.Fprintln(out, "Daemon is already running")
 		return nil
 	}
 
-	// Fork to background
+	// The interactive selector calls this without a command
+	foreground := false
+	if cmd != nil {
+		foreground, _ = cmd.Flags().GetBool("foreground")
+	}
+	if foreground {
+		d, err := daemon.New(daemon.WithLogOutput(os.Stdout))
+		if err != nil {
+			return err
+		}
+		return d.Run()
+	}
+
+	return startDaemon(out)
+}
+
+// startDaemon forks the daemon into the background and waits for it to
+// answer, reporting progress to out
+func startDaemon(out io.Writer) error {
 	if err := daemon.Fork(); err != nil {
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 
 	// Wait for daemon to start
-	fmt.Print("Starting daemon")
+	fmt.Fprint(out, "Starting daemon")
 	for i := 0; i < 50; i++ {
 		time.Sleep(100 * time.Millisecond)
 		if ipc.IsDaemonRunning() {
-			fmt.Println(" done")
+			fmt.Fprintln(out, " done")
 			return nil
 		}
-		fmt.Print(".")
+		fmt.Fprint(out, ".")
 	}
 
-	fmt.Println(" timeout")
+	fmt.Fprintln(out, " timeout")
 	return fmt.Errorf("daemon failed to start (check logs with 'bore logs')")
 }
+
+// ensureDaemon checks that the daemon is running before a command that needs
+// it. With defaults.auto_start_daemon set it starts the daemon instead of
+// failing.
+func ensureDaemon(cmd *cobra.Command) error {
+	if ipc.IsDaemonRunning() {
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil || !cfg.Defaults.AutoStartDaemon {
+		return ipc.ErrDaemonNotRunning
+	}
+	return startDaemon(progress(cmd))
+}
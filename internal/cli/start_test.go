@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestEnsureDaemonWithoutAutoStart(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	// Off by default: report the daemon as down rather than forking it
+	if err := ensureDaemon(NewRootCmd()); !errors.Is(err, ipc.ErrDaemonNotRunning) {
+		t.Errorf("expected ErrDaemonNotRunning, got %v", err)
+	}
+
+	dir := filepath.Join(home, ".bore")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("defaults:\n  auto_start_daemon: false\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := ensureDaemon(NewRootCmd()); !errors.Is(err, ipc.ErrDaemonNotRunning) {
+		t.Errorf("expected ErrDaemonNotRunning with auto_start_daemon off, got %v", err)
+	}
+}
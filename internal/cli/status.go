@@ -1,43 +1,263 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/ipc"
 	"github.com/pjtatlow/bore/internal/tunnel"
+	"github.com/pjtatlow/bore/internal/version"
 	"github.com/spf13/cobra"
 )
 
+const statusWatchInterval = 2 * time.Second
+
 func newStatusCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show daemon and tunnel status",
-		Long:  "Display the status of the daemon, all managed tunnels, and their statistics.",
-		RunE:  runStatus,
+		Long: `Display the status of the daemon, all managed tunnels, and their statistics.
+
+With --exit-code, bore status exits 0 if the daemon is running and every
+shown tunnel is connected, idle, or paused, 6 if the daemon is not running,
+and 7 if any shown tunnel is not connected (error, reconnecting, or still
+connecting), for use in health checks.`,
+		RunE: runStatus,
 	}
+
+	cmd.Flags().BoolP("watch", "w", false, "Refresh the status continuously")
+	cmd.Flags().Bool("json", false, "Output status as JSON")
+	cmd.Flags().Bool("exit-code", false, "Exit non-zero if the daemon is down (6) or a tunnel is not connected (7)")
+	cmd.Flags().StringArrayP("tunnel", "t", nil, "Only show this tunnel (repeatable)")
+	cmd.Flags().StringArrayP("group", "g", nil, "Only show tunnels in this group (repeatable)")
+
+	return cmd
+}
+
+// statusFilter selects which tunnels and groups bore status shows
+type statusFilter struct {
+	tunnels []string
+	groups  []string
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	watch, asJSON, exitCode := false, false, false
+	var filter statusFilter
+	if cmd != nil {
+		watch, _ = cmd.Flags().GetBool("watch")
+		asJSON, _ = cmd.Flags().GetBool("json")
+		exitCode, _ = cmd.Flags().GetBool("exit-code")
+		filter.tunnels, _ = cmd.Flags().GetStringArray("tunnel")
+		filter.groups, _ = cmd.Flags().GetStringArray("group")
+	}
+
+	if watch && exitCode {
+		return fmt.Errorf("--exit-code can't be used with --watch")
+	}
+
 	if !ipc.IsDaemonRunning() {
-		fmt.Println("Daemon is not running")
+		if asJSON {
+			if err := printStatusJSON(&ipc.StatusResponse{Running: false}); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("Daemon is not running")
+		}
+		if exitCode {
+			return quietExit(cmd, ExitDaemonNotRunning)
+		}
 		return nil
 	}
 
-	client, err := ipc.NewClient()
+	client, err := newClient(cmd, ipc.DefaultTimeout)
 	if err != nil {
 		return err
 	}
-
-	status, err := client.Status()
+	configPath, err := config.ConfigPath()
 	if err != nil {
-		return fmt.Errorf("failed to get status: %w", err)
+		return err
+	}
+
+	var healthy bool
+	render := func() error {
+		status, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+		status, err = filterStatus(status, filter)
+		if err != nil {
+			return err
+		}
+		status.ConfigChanged = configChanged(status.ConfigHash, configPath)
+		healthy = allConnected(status.Tunnels)
+		if asJSON {
+			return printStatusJSON(status)
+		}
+		if watch {
+			// Clear the screen and redraw
+			fmt.Print("\033[H\033[2J")
+		}
+		printStatus(status)
+		if watch {
+			fmt.Printf("\nRefreshing every %s (Ctrl+C to stop)\n", statusWatchInterval)
+		}
+		return nil
+	}
+
+	if !watch {
+		if err := render(); err != nil {
+			return err
+		}
+		if exitCode && !healthy {
+			return quietExit(cmd, ExitTunnelsUnhealthy)
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(statusWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := render(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// configChanged reports whether the config file at path differs from the one
+// the daemon last applied. Daemons that don't report a hash never differ.
+func configChanged(appliedHash, path string) bool {
+	if appliedHash == "" {
+		return false
+	}
+	hash, err := config.HashFile(path)
+	return err == nil && hash != appliedHash
+}
+
+// allConnected reports whether every tunnel is connected, counting idle lazy
+// tunnels and paused ones as healthy
+func allConnected(tunnels []ipc.TunnelStatus) bool {
+	for _, t := range tunnels {
+		if !t.Status.Healthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// quietExit ends the command with code without printing an error, since the
+// status has already been shown
+func quietExit(cmd *cobra.Command, code int) error {
+	if cmd != nil {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+	return exitStatus(code)
+}
+
+// filterStatus sorts tunnels and groups by name and keeps only those selected
+// by the filter. Tunnels match if named directly or via a selected group.
+func filterStatus(status *ipc.StatusResponse, filter statusFilter) (*ipc.StatusResponse, error) {
+	filtered := *status
+	filtered.Tunnels = append([]ipc.TunnelStatus(nil), status.Tunnels...)
+	filtered.Groups = append([]ipc.GroupStatus(nil), status.Groups...)
+	sort.Slice(filtered.Tunnels, func(i, j int) bool { return filtered.Tunnels[i].Name < filtered.Tunnels[j].Name })
+	sort.Slice(filtered.Groups, func(i, j int) bool { return filtered.Groups[i].Name < filtered.Groups[j].Name })
+
+	if len(filter.tunnels) == 0 && len(filter.groups) == 0 {
+		return &filtered, nil
+	}
+
+	wantTunnels := make(map[string]bool)
+	for _, name := range filter.tunnels {
+		wantTunnels[name] = true
 	}
 
+	wantGroups := make(map[string]bool)
+	for _, name := range filter.groups {
+		wantGroups[name] = true
+	}
+	var groups []ipc.GroupStatus
+	for _, g := range filtered.Groups {
+		if !wantGroups[g.Name] {
+			continue
+		}
+		groups = append(groups, g)
+		for _, name := range g.Tunnels {
+			wantTunnels[name] = true
+		}
+		delete(wantGroups, g.Name)
+	}
+	for name := range wantGroups {
+		return nil, &ipc.Error{Code: ipc.ErrCodeGroupNotFound, Message: fmt.Sprintf("group '%s' not found", name)}
+	}
+
+	var tunnels []ipc.TunnelStatus
+	for _, t := range filtered.Tunnels {
+		if wantTunnels[t.Name] {
+			tunnels = append(tunnels, t)
+		}
+	}
+
+	filtered.Tunnels = tunnels
+	filtered.Groups = groups
+	return &filtered, nil
+}
+
+// versionMismatch describes how the daemon's build differs from the client's,
+// or returns "" if they match
+func versionMismatch(daemon *version.Info, client version.Info) string {
+	if daemon == nil {
+		return fmt.Sprintf("daemon predates this client (%s); restart it with 'bore stop && bore start'", client.Version)
+	}
+	if daemon.Matches(client) {
+		return ""
+	}
+	return fmt.Sprintf("daemon is running %s but this client is %s; restart it with 'bore stop && bore start'", daemon, client)
+}
+
+// printStatusJSON writes the status as indented JSON
+func printStatusJSON(status *ipc.StatusResponse) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(status)
+}
+
+// printStatus renders the daemon, tunnel, and group status tables
+func printStatus(status *ipc.StatusResponse) {
 	// Print daemon status
 	fmt.Printf("Daemon: running (PID %d, uptime %s)\n", status.PID, status.Uptime)
+	if status.Version != nil {
+		fmt.Printf("Version: %s\n", status.Version)
+	}
 	fmt.Printf("Network: %s\n", status.Network.Status)
+	if status.ConfigError != "" {
+		fmt.Printf("Config: error (fix it and run 'bore config reload')\n  %s\n", strings.ReplaceAll(status.ConfigError, "\n", "\n  "))
+	} else if status.ConfigChanged {
+		fmt.Println("Config: changed since the daemon last applied it (run 'bore config reload')")
+	}
+	if warning := versionMismatch(status.Version, version.Get()); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
 	fmt.Println()
 
 	// Print tunnels
@@ -46,16 +266,24 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Println("Tunnels:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "  NAME\tTYPE\tHOST\tSTATUS\tLOCAL\tREMOTE\tTRAFFIC\tCONNS\tRECONNECTS")
+		fmt.Fprintln(w, "  NAME\tTYPE\tHOST\tSTATUS\tLOCAL\tREMOTE\tTRAFFIC\tRATE\tRTT\tCONNS\tRECONNECTS")
 
+		now := time.Now()
 		for _, t := range status.Tunnels {
 			statusStr := formatStatus(t.Status)
-			local := fmt.Sprintf("%d", t.LocalPort)
-			remote := fmt.Sprintf("%s:%d", t.RemoteHost, t.RemotePort)
+			if t.NextRetry != nil {
+				statusStr += " (" + formatRetry(*t.NextRetry, now) + ")"
+			}
+			local := formatLocal(t)
+			remote := formatRemote(t)
 			traffic := formatBytes(t.BytesSent + t.BytesReceived)
+			rate := formatRate(t.SendRate, t.RecvRate)
+			rtt := formatRTT(t.RTTMillis)
+			conns := formatConns(t.Connections, t.ActiveConns, t.PeakConns)
+			reconnects := formatReconnects(t.ReconnectCount, t.RecentReconnects)
 
-			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
-				t.Name, t.Type, t.Host, statusStr, local, remote, traffic, t.Connections, t.ReconnectCount)
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				t.Name, t.Type, t.Host, statusStr, local, remote, traffic, rate, rtt, conns, reconnects)
 		}
 		w.Flush()
 	}
@@ -77,8 +305,6 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 		w.Flush()
 	}
-
-	return nil
 }
 
 func formatStatus(status tunnel.Status) string {
@@ -93,6 +319,10 @@ func formatStatus(status tunnel.Status) string {
 		return "error"
 	case tunnel.StatusStopped:
 		return "stopped"
+	case tunnel.StatusIdle:
+		return "listening (idle)"
+	case tunnel.StatusPaused:
+		return "paused"
 	default:
 		return string(status)
 	}
@@ -116,3 +346,91 @@ func formatBytes(bytes int64) string {
 		return fmt.Sprintf("%dB", bytes)
 	}
 }
+
+// formatHost shows a tunnel's host alias and the endpoint it resolved to
+func formatHost(t ipc.TunnelStatus) string {
+	host := t.Host
+	if t.PrimaryHost != "" {
+		host = fmt.Sprintf("%s (fallback for %s)", t.Host, t.PrimaryHost)
+	}
+	if t.Endpoint == "" {
+		return host
+	}
+	return fmt.Sprintf("%s [%s]", host, t.Endpoint)
+}
+
+// formatLocal shows a tunnel's local port or port range, with the address
+// unless it is localhost
+func formatLocal(t ipc.TunnelStatus) string {
+	ports := strconv.Itoa(t.LocalPort)
+	if t.LocalPortRange != "" {
+		ports = t.LocalPortRange
+	}
+	if t.LocalHost != "" && t.LocalHost != "localhost" {
+		return net.JoinHostPort(t.LocalHost, ports)
+	}
+	return ports
+}
+
+// formatRemote shows where a tunnel forwards to on the server
+func formatRemote(t ipc.TunnelStatus) string {
+	if t.RemoteSocket != "" {
+		return t.RemoteSocket
+	}
+	ports := strconv.Itoa(t.RemotePort)
+	if t.RemotePortRange != "" {
+		ports = t.RemotePortRange
+	}
+	if t.RemoteBind != "" {
+		return net.JoinHostPort(t.RemoteBind, ports)
+	}
+	return net.JoinHostPort(t.RemoteHost, ports)
+}
+
+// formatRate formats the combined send/receive throughput, or "-" before a
+// rate has been sampled
+func formatRate(sendRate, recvRate *float64) string {
+	if sendRate == nil || recvRate == nil {
+		return "-"
+	}
+	return formatBytes(int64(*sendRate+*recvRate)) + "/s"
+}
+
+// formatReconnects shows the total reconnect count, plus the last hour's
+// count when there were any so a flaky link stands out
+func formatReconnects(total, lastHour int) string {
+	if lastHour == 0 {
+		return strconv.Itoa(total)
+	}
+	return fmt.Sprintf("%d (%d/hr)", total, lastHour)
+}
+
+// formatConns shows the connections forwarded since the tunnel started, with
+// how many are open now and the most that were open at once
+func formatConns(total, active, peak int64) string {
+	if peak == 0 {
+		return strconv.FormatInt(total, 10)
+	}
+	return fmt.Sprintf("%d (%d open, peak %d)", total, active, peak)
+}
+
+// formatRetry describes when a scheduled reconnect attempt will happen
+func formatRetry(next, now time.Time) string {
+	wait := next.Sub(now)
+	if wait <= 0 {
+		return "retrying"
+	}
+	// Round up so a pending retry never shows as "in 0s"
+	return fmt.Sprintf("retry in %s", (wait + time.Second - 1).Truncate(time.Second))
+}
+
+// formatRTT formats a round-trip time in milliseconds, or "-" if unmeasured
+func formatRTT(rttMillis *float64) string {
+	if rttMillis == nil {
+		return "-"
+	}
+	if *rttMillis < 10 {
+		return fmt.Sprintf("%.1fms", *rttMillis)
+	}
+	return fmt.Sprintf("%.0fms", *rttMillis)
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/tunnel"
+	"github.com/pjtatlow/bore/internal/version"
+)
+
+func TestFilterStatus(t *testing.T) {
+	status := &ipc.StatusResponse{
+		Running: true,
+		Tunnels: []ipc.TunnelStatus{{Name: "web"}, {Name: "api"}, {Name: "db"}, {Name: "cache"}},
+		Groups: []ipc.GroupStatus{
+			{Name: "dev", Tunnels: []string{"web", "api"}},
+			{Name: "data", Tunnels: []string{"db"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		filter      statusFilter
+		wantTunnels []string
+		wantGroups  []string
+		wantErr     bool
+	}{
+		{"no filter sorts everything", statusFilter{}, []string{"api", "cache", "db", "web"}, []string{"data", "dev"}, false},
+		{"by tunnel", statusFilter{tunnels: []string{"db", "cache"}}, []string{"cache", "db"}, nil, false},
+		{"by group", statusFilter{groups: []string{"dev"}}, []string{"api", "web"}, []string{"dev"}, false},
+		{"tunnel and group", statusFilter{tunnels: []string{"cache"}, groups: []string{"data"}}, []string{"cache", "db"}, []string{"data"}, false},
+		{"unknown group", statusFilter{groups: []string{"nope"}}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterStatus(status, tt.filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var tunnels []string
+			for _, tun := range got.Tunnels {
+				tunnels = append(tunnels, tun.Name)
+			}
+			var groups []string
+			for _, g := range got.Groups {
+				groups = append(groups, g.Name)
+			}
+			if !slices.Equal(tunnels, tt.wantTunnels) {
+				t.Errorf("expected tunnels %v, got %v", tt.wantTunnels, tunnels)
+			}
+			if !slices.Equal(groups, tt.wantGroups) {
+				t.Errorf("expected groups %v, got %v", tt.wantGroups, groups)
+			}
+		})
+	}
+
+	// The original response must be left untouched
+	if status.Tunnels[0].Name != "web" {
+		t.Error("filterStatus modified its input")
+	}
+}
+
+func TestFormatRTT(t *testing.T) {
+	ms := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		rtt  *float64
+		want string
+	}{
+		{nil, "-"},
+		{ms(0.42), "0.4ms"},
+		{ms(42.3), "42ms"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRTT(tt.rtt); got != tt.want {
+			t.Errorf("formatRTT(%v) = %q, want %q", tt.rtt, got, tt.want)
+		}
+	}
+}
+
+func TestFormatHost(t *testing.T) {
+	if got := formatHost(ipc.TunnelStatus{Host: "bastion"}); got != "bastion" {
+		t.Errorf("expected just the alias without an endpoint, got %q", got)
+	}
+	got := formatHost(ipc.TunnelStatus{Host: "bastion", Endpoint: "deploy@10.0.0.5:2222"})
+	if got != "bastion [deploy@10.0.0.5:2222]" {
+		t.Errorf("expected the alias and resolved endpoint, got %q", got)
+	}
+	got = formatHost(ipc.TunnelStatus{Host: "bastion-b", PrimaryHost: "bastion"})
+	if got != "bastion-b (fallback for bastion)" {
+		t.Errorf("expected the fallback and its primary, got %q", got)
+	}
+}
+
+func TestFormatReconnects(t *testing.T) {
+	tests := []struct {
+		total, lastHour int
+		want            string
+	}{
+		{0, 0, "0"},
+		{7, 0, "7"},
+		{7, 3, "7 (3/hr)"},
+	}
+
+	for _, tt := range tests {
+		if got := formatReconnects(tt.total, tt.lastHour); got != tt.want {
+			t.Errorf("formatReconnects(%d, %d) = %q, want %q", tt.total, tt.lastHour, got, tt.want)
+		}
+	}
+}
+
+func TestFormatConns(t *testing.T) {
+	tests := []struct {
+		total, active, peak int64
+		want                string
+	}{
+		{0, 0, 0, "0"},
+		{42, 0, 3, "42 (0 open, peak 3)"},
+		{42, 2, 3, "42 (2 open, peak 3)"},
+	}
+
+	for _, tt := range tests {
+		if got := formatConns(tt.total, tt.active, tt.peak); got != tt.want {
+			t.Errorf("formatConns(%d, %d, %d) = %q, want %q", tt.total, tt.active, tt.peak, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRetry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		next time.Time
+		want string
+	}{
+		{now.Add(14 * time.Second), "retry in 14s"},
+		{now.Add(13500 * time.Millisecond), "retry in 14s"},
+		{now.Add(90 * time.Second), "retry in 1m30s"},
+		{now, "retrying"},
+		{now.Add(-time.Second), "retrying"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRetry(tt.next, now); got != tt.want {
+			t.Errorf("formatRetry(%v) = %q, want %q", tt.next.Sub(now), got, tt.want)
+		}
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	client := version.Info{Version: "v1.2.0", Commit: "abc123", Date: "2024-01-01T00:00:00Z"}
+
+	tests := []struct {
+		name   string
+		daemon *version.Info
+		want   bool
+	}{
+		{"same build", &version.Info{Version: "v1.2.0", Commit: "abc123", Date: "2024-01-01T00:00:00Z"}, false},
+		{"different version", &version.Info{Version: "v1.1.0", Commit: "def456"}, true},
+		{"same version different commit", &version.Info{Version: "v1.2.0", Commit: "def456"}, true},
+		{"daemon without version", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionMismatch(tt.daemon, client) != ""; got != tt.want {
+				t.Errorf("expected mismatch %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("tunnels: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := config.HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if configChanged(applied, path) {
+		t.Error("expected an unchanged file to match")
+	}
+	if configChanged("", path) {
+		t.Error("expected a daemon without a hash to never report a change")
+	}
+	if err := os.WriteFile(path, []byte("tunnels: {}\ngroups: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !configChanged(applied, path) {
+		t.Error("expected an edited file to be reported as changed")
+	}
+}
+
+func TestAllConnected(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []tunnel.Status
+		want     bool
+	}{
+		{"no tunnels", nil, true},
+		{"all connected", []tunnel.Status{tunnel.StatusConnected, tunnel.StatusConnected}, true},
+		{"one errored", []tunnel.Status{tunnel.StatusConnected, tunnel.StatusError}, false},
+		{"one reconnecting", []tunnel.Status{tunnel.StatusReconnecting}, false},
+		{"idle lazy tunnel", []tunnel.Status{tunnel.StatusConnected, tunnel.StatusIdle}, true},
+		{"paused tunnel", []tunnel.Status{tunnel.StatusConnected, tunnel.StatusPaused}, true},
+		{"still connecting", []tunnel.Status{tunnel.StatusConnecting}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tunnels []ipc.TunnelStatus
+			for _, s := range tt.statuses {
+				tunnels = append(tunnels, ipc.TunnelStatus{Status: s})
+			}
+			if got := allConnected(tunnels); got != tt.want {
+				t.Errorf("allConnected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietExit(t *testing.T) {
+	err := quietExit(nil, ExitTunnelsUnhealthy)
+	if !Quiet(err) {
+		t.Error("expected the exit status to be quiet")
+	}
+	if got := ExitCode(err); got != ExitTunnelsUnhealthy {
+		t.Errorf("ExitCode() = %d, want %d", got, ExitTunnelsUnhealthy)
+	}
+	if Quiet(ipc.ErrDaemonNotRunning) {
+		t.Error("expected other errors to be printed")
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/pjtatlow/bore/internal/daemon"
@@ -10,37 +11,71 @@ import (
 )
 
 func newStopCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the bore daemon",
 		Long:  "Stop the bore daemon and all managed tunnels.",
 		RunE:  runStop,
 	}
+
+	cmd.Flags().Bool("force", false, "Kill the daemon if it doesn't stop gracefully")
+
+	return cmd
 }
 
 func runStop(cmd *cobra.Command, args []string) error {
-	if !ipc.IsDaemonRunning() {
-		fmt.Println("Daemon is not running")
-		return nil
+	// The interactive selector calls this without a command
+	force := false
+	if cmd != nil {
+		force, _ = cmd.Flags().GetBool("force")
+	}
+	pid, pidErr := daemon.ReadPID()
+	out := progress(cmd)
+
+	// A wedged daemon may hold its PID without answering on the socket, in
+	// which case it's stopped once its process exits. The PID must still be
+	// bore's, as a stale PID file can name a process that reused it.
+	stopped := func() bool { return !ipc.IsDaemonRunning() }
+	if stopped() {
+		if pidErr != nil || !daemon.IsProcessRunning(pid) || !daemon.IsBoreProcess(pid) {
+			fmt.Fprintln(out, "Daemon is not running")
+			return nil
+		}
+		stopped = func() bool { return !daemon.IsProcessRunning(pid) }
 	}
 
-	fmt.Print("Stopping daemon")
+	fmt.Fprint(out, "Stopping daemon")
 
 	if err := daemon.StopDaemon(); err != nil {
-		fmt.Println()
+		fmt.Fprintln(out)
 		return fmt.Errorf("failed to stop daemon: %w", err)
 	}
 
 	// Wait for daemon to stop
 	for i := 0; i < 50; i++ {
 		time.Sleep(100 * time.Millisecond)
-		if !ipc.IsDaemonRunning() {
-			fmt.Println(" done")
+		if stopped() {
+			fmt.Fprintln(out, " done")
 			return nil
 		}
-		fmt.Print(".")
+		fmt.Fprint(out, ".")
 	}
 
-	fmt.Println(" timeout")
-	return fmt.Errorf("daemon failed to stop")
+	fmt.Fprintln(out, " timeout")
+	if force {
+		return killDaemon(out, pid)
+	}
+	if pidErr == nil {
+		return fmt.Errorf("daemon (PID %d) failed to stop; use 'bore stop --force' to kill it", pid)
+	}
+	return fmt.Errorf("daemon failed to stop; use 'bore stop --force' to kill it")
+}
+
+// killDaemon forcibly kills a daemon that didn't stop gracefully
+func killDaemon(out io.Writer, pid int) error {
+	fmt.Fprintf(out, "Killing daemon (PID %d)\n", pid)
+	if err := daemon.KillDaemon(); err != nil {
+		return fmt.Errorf("failed to kill daemon: %w", err)
+	}
+	return nil
 }
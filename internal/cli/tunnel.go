@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/ipc"
 	"github.com/spf13/cobra"
 )
@@ -11,11 +12,16 @@ func newTunnelCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "tunnel",
 		Short: "Manage individual tunnels",
-		Long:  "Start or stop individual tunnels.",
+		Long:  "Start, stop, restart, pause, or watch individual tunnels, or drop their connections.",
 	}
 
 	cmd.AddCommand(newTunnelUpCmd())
 	cmd.AddCommand(newTunnelDownCmd())
+	cmd.AddCommand(newTunnelRestartCmd())
+	cmd.AddCommand(newTunnelPauseCmd())
+	cmd.AddCommand(newTunnelResumeCmd())
+	cmd.AddCommand(newTunnelKillCmd())
+	cmd.AddCommand(newTunnelWatchCmd())
 
 	return cmd
 }
@@ -24,12 +30,18 @@ func newTunnelUpCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "up <name>",
 		Short: "Start a tunnel",
-		Long:  "Start an individual tunnel by name, connecting through the specified host.",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runTunnelUp,
+		Long: `Start an individual tunnel by name, connecting through the specified host or the tunnel's default host.
+
+Use --remote-host and --remote-port to point the tunnel at a different remote
+target for this run only; the config file is left untouched.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTunnelUp,
 	}
-	cmd.Flags().String("host", "", "SSH host to connect through (required)")
-	cmd.MarkFlagRequired("host")
+	cmd.Flags().String("host", "", "SSH host to connect through (defaults to the tunnel's configured host)")
+	cmd.Flags().Bool("force", false, "Move the tunnel if it is already running via a different host, or restart it with a new remote target; also accepts a --host that isn't in bore's config or ~/.ssh/config")
+	cmd.Flags().String("remote-host", "", "Forward to this remote host instead of the configured one (local tunnels only)")
+	cmd.Flags().Int("remote-port", 0, "Forward to this remote port instead of the configured one")
+	cmd.RegisterFlagCompletionFunc("host", completeHosts)
 	return cmd
 }
 
@@ -43,24 +55,127 @@ func newTunnelDownCmd() *cobra.Command {
 	}
 }
 
+func newTunnelRestartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart <name>",
+		Short: "Restart a tunnel",
+		Long: `Tear down a running tunnel and reconnect it through the same host.
+
+This also brings back a tunnel left in an error state because reconnect is
+disabled for it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTunnelRestart,
+	}
+}
+
+func newTunnelPauseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <name>",
+		Short: "Pause a tunnel",
+		Long: `Stop a tunnel forwarding without forgetting it. The tunnel closes its
+listener and releases its SSH connection, but keeps its host and stats and is
+not reconnected until it is resumed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTunnelPause,
+	}
+}
+
+func newTunnelResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <name>",
+		Short: "Resume a paused tunnel",
+		Long:  "Start a paused tunnel again through the host it was using.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTunnelResume,
+	}
+}
+
+func newTunnelKillCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <name>",
+		Short: "Drop a tunnel's open connections",
+		Long: `Close every connection a tunnel is forwarding, such as one stuck on a hung
+query, without stopping the tunnel. Its listener stays up, so new connections
+are forwarded as usual.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTunnelKill,
+	}
+}
+
+// newKillCmd is "bore kill", the same as "bore tunnel kill", so a stuck
+// connection can be dropped without reaching for the tunnel subcommand
+func newKillCmd() *cobra.Command {
+	cmd := newTunnelKillCmd()
+	cmd.Long += "\n\nThis is the same as 'bore tunnel kill'."
+	return cmd
+}
+
 func runTunnelUp(cmd *cobra.Command, args []string) error {
 	tunnelName := args[0]
 	host, _ := cmd.Flags().GetString("host")
+	force, _ := cmd.Flags().GetBool("force")
+	remoteHost, _ := cmd.Flags().GetString("remote-host")
+	remotePort, _ := cmd.Flags().GetInt("remote-port")
 
-	if !ipc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running (start with 'bore start')")
+	if cmd.Flags().Changed("remote-port") && (remotePort <= 0 || remotePort > 65535) {
+		return fmt.Errorf("--remote-port must be between 1 and 65535")
+	}
+	if msg := config.ValidateHostField(remoteHost); msg != "" {
+		return fmt.Errorf("--remote-host %s", msg)
+	}
+
+	if host != "" && !force {
+		if err := checkHostFlag(host); err != nil {
+			return err
+		}
+	}
+
+	if host == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		t, ok := cfg.GetTunnel(tunnelName)
+		if !ok {
+			return &ipc.Error{Code: ipc.ErrCodeTunnelNotFound, Message: fmt.Sprintf("tunnel '%s' not found in config", tunnelName)}
+		}
+		if t.Host == "" {
+			return fmt.Errorf("no host specified for tunnel '%s' (use --host or set host in config)", tunnelName)
+		}
+		host = t.Host
+	}
+
+	if err := ensureDaemon(cmd); err != nil {
+		return err
 	}
 
-	client, err := ipc.NewClient()
+	client, err := newClient(cmd, ipc.DefaultTimeout)
 	if err != nil {
 		return err
 	}
 
-	if err := client.TunnelUp(tunnelName, host); err != nil {
+	up, err := client.TunnelUp(ipc.TunnelRequest{
+		Name:       tunnelName,
+		Host:       host,
+		Force:      force,
+		RemoteHost: remoteHost,
+		RemotePort: remotePort,
+	})
+	if err != nil {
 		return fmt.Errorf("failed to start tunnel '%s': %w", tunnelName, err)
 	}
 
-	fmt.Printf("Started tunnel '%s' via host '%s'\n", tunnelName, host)
+	out := progress(cmd)
+	switch {
+	case up.AlreadyRunning:
+		fmt.Fprintf(out, "Tunnel '%s' is already up via host '%s'\n", tunnelName, up.Host)
+	case up.PreviousHost != "" && up.PreviousHost != up.Host:
+		fmt.Fprintf(out, "Moved tunnel '%s' from host '%s' to '%s'\n", tunnelName, up.PreviousHost, up.Host)
+	case up.PreviousHost != "":
+		fmt.Fprintf(out, "Restarted tunnel '%s' via host '%s'\n", tunnelName, up.Host)
+	default:
+		fmt.Fprintf(out, "Started tunnel '%s' via host '%s'\n", tunnelName, up.Host)
+	}
 	return nil
 }
 
@@ -68,10 +183,10 @@ func runTunnelDown(cmd *cobra.Command, args []string) error {
 	tunnelName := args[0]
 
 	if !ipc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running (start with 'bore start')")
+		return ipc.ErrDaemonNotRunning
 	}
 
-	client, err := ipc.NewClient()
+	client, err := newClient(cmd, ipc.DefaultTimeout)
 	if err != nil {
 		return err
 	}
@@ -80,6 +195,93 @@ func runTunnelDown(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to stop tunnel '%s': %w", tunnelName, err)
 	}
 
-	fmt.Printf("Stopped tunnel '%s'\n", tunnelName)
+	fmt.Fprintf(progress(cmd), "Stopped tunnel '%s'\n", tunnelName)
+	return nil
+}
+
+func runTunnelRestart(cmd *cobra.Command, args []string) error {
+	tunnelName := args[0]
+
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	host, err := client.TunnelRestart(tunnelName)
+	if err != nil {
+		return fmt.Errorf("failed to restart tunnel '%s': %w", tunnelName, err)
+	}
+
+	fmt.Fprintf(progress(cmd), "Restarted tunnel '%s' via host '%s'\n", tunnelName, host)
+	return nil
+}
+
+func runTunnelPause(cmd *cobra.Command, args []string) error {
+	tunnelName := args[0]
+
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	if err := client.TunnelPause(tunnelName); err != nil {
+		return fmt.Errorf("failed to pause tunnel '%s': %w", tunnelName, err)
+	}
+
+	fmt.Fprintf(progress(cmd), "Paused tunnel '%s'\n", tunnelName)
+	return nil
+}
+
+func runTunnelKill(cmd *cobra.Command, args []string) error {
+	tunnelName := args[0]
+
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	dropped, err := client.TunnelKill(tunnelName)
+	if err != nil {
+		return fmt.Errorf("failed to drop connections on tunnel '%s': %w", tunnelName, err)
+	}
+
+	noun := "connections"
+	if dropped == 1 {
+		noun = "connection"
+	}
+	fmt.Fprintf(progress(cmd), "Dropped %d %s on tunnel '%s'\n", dropped, noun, tunnelName)
+	return nil
+}
+
+func runTunnelResume(cmd *cobra.Command, args []string) error {
+	tunnelName := args[0]
+
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	host, err := client.TunnelResume(tunnelName)
+	if err != nil {
+		return fmt.Errorf("failed to resume tunnel '%s': %w", tunnelName, err)
+	}
+
+	fmt.Fprintf(progress(cmd), "Resumed tunnel '%s' via host '%s'\n", tunnelName, host)
 	return nil
 }
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pjtatlow/bore/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show version information",
+		Long:  "Print the bore version, git commit, and build date.",
+		Args:  cobra.NoArgs,
+		RunE:  runVersion,
+	}
+
+	cmd.Flags().Bool("json", false, "Output version information as JSON")
+
+	return cmd
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := version.Get()
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Printf("bore %s\n", info.Version)
+	fmt.Printf("  Commit: %s\n", info.Commit)
+	fmt.Printf("  Built:  %s\n", info.Date)
+	return nil
+}
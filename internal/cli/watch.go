@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/spf13/cobra"
+)
+
+const (
+	tunnelWatchInterval = time.Second
+	gaugeWidth          = 30
+)
+
+func newTunnelWatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch <name>",
+		Short: "Show a tunnel's live throughput",
+		Long: `Show one running tunnel's send and receive rate and open connections,
+refreshing every second until Ctrl+C. The gauges are scaled to the highest
+rate seen while watching.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTunnelWatch,
+	}
+}
+
+func runTunnelWatch(cmd *cobra.Command, args []string) error {
+	tunnelName := args[0]
+
+	if !ipc.IsDaemonRunning() {
+		return ipc.ErrDaemonNotRunning
+	}
+
+	client, err := newClient(cmd, ipc.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(tunnelWatchInterval)
+	defer ticker.Stop()
+
+	var sampler rateSampler
+	for {
+		status, err := client.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+		t, ok := findTunnelStatus(status, tunnelName)
+		if !ok {
+			return &ipc.Error{Code: ipc.ErrCodeNotRunning, Message: fmt.Sprintf("tunnel '%s' is not running", tunnelName)}
+		}
+
+		// Clear the screen and redraw
+		fmt.Print("\033[H\033[2J")
+		fmt.Print(renderTunnelWatch(t, sampler.sample(t, time.Now()), sampler.peak))
+		fmt.Printf("\nRefreshing every %s (Ctrl+C to stop)\n", tunnelWatchInterval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// findTunnelStatus returns the named tunnel from a status response
+func findTunnelStatus(status *ipc.StatusResponse, name string) (ipc.TunnelStatus, bool) {
+	for _, t := range status.Tunnels {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return ipc.TunnelStatus{}, false
+}
+
+// throughput is a tunnel's send and receive rate in bytes/sec
+type throughput struct {
+	send, recv float64
+	ok         bool // false until two samples have been taken
+}
+
+// rateSampler turns successive byte counters into rates. It differences the
+// counters itself rather than using the daemon's rates, which are measured
+// since whichever client asked last.
+type rateSampler struct {
+	sent, received int64
+	at             time.Time
+	peak           float64 // highest send or receive rate seen, for scaling gauges
+}
+
+// sample records the tunnel's counters and returns the rates since the
+// previous sample
+func (s *rateSampler) sample(t ipc.TunnelStatus, now time.Time) throughput {
+	prevSent, prevReceived, prevAt := s.sent, s.received, s.at
+	s.sent, s.received, s.at = t.BytesSent, t.BytesReceived, now
+
+	elapsed := now.Sub(prevAt).Seconds()
+	// Counters restart from zero when the tunnel reconnects
+	if prevAt.IsZero() || elapsed <= 0 || t.BytesSent < prevSent || t.BytesReceived < prevReceived {
+		return throughput{}
+	}
+
+	rate := throughput{
+		send: float64(t.BytesSent-prevSent) / elapsed,
+		recv: float64(t.BytesReceived-prevReceived) / elapsed,
+		ok:   true,
+	}
+	s.peak = max(s.peak, rate.send, rate.recv)
+	return rate
+}
+
+// renderTunnelWatch draws one frame of bore tunnel watch
+func renderTunnelWatch(t ipc.TunnelStatus, rate throughput, peak float64) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Tunnel %s: %s (%s via %s, %s -> %s)\n\n", t.Name, formatStatus(t.Status), t.Type, formatHost(t), formatLocal(t), formatRemote(t))
+
+	if rate.ok {
+		fmt.Fprintf(&b, "  Send  %10s  %s\n", formatBytes(int64(rate.send))+"/s", formatGauge(rate.send, peak, gaugeWidth))
+		fmt.Fprintf(&b, "  Recv  %10s  %s\n", formatBytes(int64(rate.recv))+"/s", formatGauge(rate.recv, peak, gaugeWidth))
+	} else {
+		fmt.Fprintf(&b, "  Send  %10s\n", "-")
+		fmt.Fprintf(&b, "  Recv  %10s\n", "-")
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "  Connections: %d open, %d peak, %d total\n", t.ActiveConns, t.PeakConns, t.Connections)
+	fmt.Fprintf(&b, "  Traffic:     %s sent, %s received\n", formatBytes(t.BytesSent), formatBytes(t.BytesReceived))
+
+	return b.String()
+}
+
+// formatGauge draws rate as a bar of width cells, full at peak
+func formatGauge(rate, peak float64, width int) string {
+	filled := 0
+	if peak > 0 {
+		filled = int(rate / peak * float64(width))
+		// Any traffic at all shows up
+		if rate > 0 && filled == 0 {
+			filled = 1
+		}
+	}
+	filled = min(filled, width)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(" ", width-filled) + "]"
+}
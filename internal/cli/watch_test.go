@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestRateSampler(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var s rateSampler
+
+	if rate := s.sample(ipc.TunnelStatus{BytesSent: 1000, BytesReceived: 500}, start); rate.ok {
+		t.Fatal("expected no rate from the first sample")
+	}
+
+	rate := s.sample(ipc.TunnelStatus{BytesSent: 3000, BytesReceived: 1500}, start.Add(2*time.Second))
+	if !rate.ok || rate.send != 1000 || rate.recv != 500 {
+		t.Errorf("expected 1000/500 B/s, got %+v", rate)
+	}
+	if s.peak != 1000 {
+		t.Errorf("expected peak 1000, got %v", s.peak)
+	}
+
+	// A reconnect resets the counters; skip that interval rather than go negative
+	if rate := s.sample(ipc.TunnelStatus{BytesSent: 10}, start.Add(3*time.Second)); rate.ok {
+		t.Errorf("expected no rate after counters reset, got %+v", rate)
+	}
+	rate = s.sample(ipc.TunnelStatus{BytesSent: 110}, start.Add(4*time.Second))
+	if !rate.ok || rate.send != 100 {
+		t.Errorf("expected 100 B/s after reset, got %+v", rate)
+	}
+	if s.peak != 1000 {
+		t.Errorf("expected peak to stay 1000, got %v", s.peak)
+	}
+}
+
+func TestFormatGauge(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate, peak float64
+		want       string
+	}{
+		{"nothing yet", 0, 0, "[          ]"},
+		{"idle", 0, 100, "[          ]"},
+		{"half", 50, 100, "[#####     ]"},
+		{"at peak", 100, 100, "[##########]"},
+		{"trickle still shows", 0.5, 100, "[#         ]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGauge(tt.rate, tt.peak, 10); got != tt.want {
+				t.Errorf("formatGauge(%v, %v) = %q, want %q", tt.rate, tt.peak, got, tt.want)
+			}
+		})
+	}
+}
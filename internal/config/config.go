@@ -1,9 +1,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -15,12 +20,28 @@ type Config struct {
 	Hosts    map[string]Host   `yaml:"hosts"`
 	Tunnels  map[string]Tunnel `yaml:"tunnels"`
 	Groups   map[string]Group  `yaml:"groups"`
+
+	Hash string `yaml:"-"` // hash of the file this was loaded from; see HashFile
 }
 
-// Defaults contains default settings for reconnection and keepalive
+// Defaults contains default settings for reconnection, keepalive, notifications, and logging
 type Defaults struct {
-	Reconnect ReconnectConfig `yaml:"reconnect"`
-	KeepAlive KeepAliveConfig `yaml:"keep_alive"`
+	Reconnect      ReconnectConfig `yaml:"reconnect"`
+	KeepAlive      KeepAliveConfig `yaml:"keep_alive"`
+	Notifications  bool            `yaml:"notifications"`   // desktop notifications on tunnel failure/recovery
+	LogFormat      string          `yaml:"log_format"`      // "text" or "json"
+	LogConnections bool            `yaml:"log_connections"` // log each forwarded connection at debug level
+	ProbeHosts     bool            `yaml:"probe_hosts"`     // judge network availability by dialing the SSH hosts in use instead of public DNS
+	NetworkPoll    time.Duration   `yaml:"network_poll"`    // how often to check the network where it is polled; 5s when unset
+	DNSTimeout     time.Duration   `yaml:"dns_timeout"`     // how long each poll's DNS lookup may take; network_poll when unset
+	MaxRate        int64           `yaml:"max_rate"`        // bytes/sec shared by all tunnels, both directions; 0 is unlimited
+	PruneState     bool            `yaml:"prune_state"`     // forget saved tunnels and groups that are no longer in the config instead of keeping them
+	HealthAddr     string          `yaml:"health_addr"`     // address to serve /healthz and /readyz on, e.g. 127.0.0.1:9190; empty disables
+
+	StrictKeyPermissions bool `yaml:"strict_key_permissions"` // refuse private keys other users can read, as OpenSSH does, instead of warning
+	AutoStartDaemon      bool `yaml:"auto_start_daemon"`      // start the daemon from bore tunnel up / group enable instead of failing when it isn't running
+
+	MaxConnectionAge time.Duration `yaml:"max_connection_age"` // replace SSH connections up this long with fresh ones while idle; 0 never does
 }
 
 // ReconnectConfig controls automatic reconnection behavior
@@ -29,30 +50,128 @@ type ReconnectConfig struct {
 	MaxBackoff     time.Duration `yaml:"max_backoff"`
 	InitialBackoff time.Duration `yaml:"initial_backoff"`
 	Multiplier     float64       `yaml:"multiplier"`
+	Strategy       string        `yaml:"strategy"` // "exponential" (default) or "constant"
 }
 
 // KeepAliveConfig controls SSH keepalive settings
 type KeepAliveConfig struct {
-	Interval time.Duration `yaml:"interval"`
+	Interval  time.Duration `yaml:"interval"`   // 0 disables keepalives; 30s when unset
+	MaxMissed int           `yaml:"max_missed"` // consecutive keepalives unanswered for a whole interval before the connection is considered lost
+	Method    string        `yaml:"method"`     // how liveness is checked: "golang" (default), "openssh", or "session"
 }
 
+// Keepalive methods, which differ in what the server has to answer
+const (
+	KeepAliveGolang  = "golang"  // a keepalive@golang.com global request
+	KeepAliveOpenSSH = "openssh" // a keepalive@openssh.com global request, as OpenSSH's ServerAliveInterval sends
+	KeepAliveSession = "session" // opening and closing a session channel
+)
+
 // Host represents an SSH host configuration
 type Host struct {
-	Hostname     string `yaml:"hostname"`
-	User         string `yaml:"user"`
-	Port         int    `yaml:"port"`
-	IdentityFile string `yaml:"identity_file"`
-	ProxyJump    string `yaml:"proxy_jump"`
+	Hostname       string `yaml:"hostname"`
+	User           string `yaml:"user"`
+	Port           int    `yaml:"port"`
+	IdentityFile   string `yaml:"identity_file"`
+	CertFile       string `yaml:"cert_file"`
+	IdentitiesOnly bool   `yaml:"identities_only"` // only offer identity_file, skipping the agent and default keys
+	IdentityAgent  string `yaml:"identity_agent"`  // agent socket path overriding SSH_AUTH_SOCK, or "none"
+	ProxyJump      string `yaml:"proxy_jump"`
+
+	ConnectTimeout    time.Duration  `yaml:"connect_timeout"`               // overrides SSH config ConnectTimeout
+	KeepAliveInterval *time.Duration `yaml:"keep_alive_interval,omitempty"` // overrides SSH config ServerAliveInterval and defaults.keep_alive.interval; 0 disables
+	KeepAliveMethod   string         `yaml:"keep_alive_method,omitempty"`   // overrides defaults.keep_alive.method
 }
 
-// Tunnel represents a single tunnel configuration
+// Tunnel represents a single tunnel configuration.
+//
+// For local tunnels, LocalHost:LocalPort is the address bore listens on and
+// RemoteHost:RemotePort, or the Unix socket RemoteSocket, is dialed from the
+// SSH server.
+// For remote tunnels, the SSH server listens on RemotePort and connections are
+// forwarded to LocalHost:LocalPort, dialed from the machine running bore. LocalHost
+// may be any host reachable from that machine, not just localhost.
 type Tunnel struct {
-	Type       TunnelType `yaml:"type"`
-	Host       string     `yaml:"host"`
-	LocalHost  string     `yaml:"local_host"`
-	LocalPort  int        `yaml:"local_port"`
-	RemoteHost string     `yaml:"remote_host"`
-	RemotePort int        `yaml:"remote_port"`
+	Type         TunnelType `yaml:"type"`
+	Host         string     `yaml:"host"`
+	LocalHost    string     `yaml:"local_host"`
+	LocalPort    int        `yaml:"local_port"`
+	RemoteHost   string     `yaml:"remote_host"`
+	RemotePort   int        `yaml:"remote_port"`
+	RemoteSocket string     `yaml:"remote_socket,omitempty"` // local tunnels: dial this Unix socket on the server instead of RemoteHost:RemotePort
+	RemoteBind   string     `yaml:"remote_bind,omitempty"`   // remote tunnels: address the server listens on (default localhost)
+	Verify       bool       `yaml:"verify"`                  // local tunnels: dial the remote end once before reporting connected
+	Autostart    bool       `yaml:"autostart"`               // start via Host whenever the daemon starts
+	AlertBytes   int64      `yaml:"alert_bytes"`             // warn once per run when total traffic crosses this many bytes; 0 disables
+	Reconnect    *bool      `yaml:"reconnect,omitempty"`     // overrides defaults.reconnect.enabled for this tunnel
+	NoDelay      *bool      `yaml:"nodelay,omitempty"`       // TCP_NODELAY on this machine's side of each connection; unset keeps Go's default (on)
+
+	// FallbackHosts are tried in order when the host the tunnel was started
+	// via can't be reached
+	FallbackHosts []string `yaml:"fallback_hosts,omitempty"`
+
+	// Port ranges forward each port to its counterpart in the other range
+	// under this one tunnel. remote_port_range defaults to the local range.
+	LocalPortRange  string `yaml:"local_port_range,omitempty"`
+	RemotePortRange string `yaml:"remote_port_range,omitempty"`
+
+	// Lazy local tunnels only connect to their host when the first
+	// connection arrives, and let go of it after IdleTimeout without any
+	Lazy        bool          `yaml:"lazy,omitempty"`
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"`
+}
+
+// DefaultIdleTimeout is how long a lazy tunnel keeps its SSH connection with
+// no forwarded connections open, unless idle_timeout says otherwise
+const DefaultIdleTimeout = 5 * time.Minute
+
+// LazyIdleTimeout returns how long a lazy tunnel stays connected while idle
+func (t Tunnel) LazyIdleTimeout() time.Duration {
+	if t.IdleTimeout > 0 {
+		return t.IdleTimeout
+	}
+	return DefaultIdleTimeout
+}
+
+// KeepAliveInterval returns how often to send keepalives to a resolved host,
+// or 0 if they are disabled. Only an unset host interval falls back to the
+// default, so 0 can turn them off for one host.
+func (c *Config) KeepAliveInterval(host Host) time.Duration {
+	if host.KeepAliveInterval != nil {
+		return max(*host.KeepAliveInterval, 0)
+	}
+	return max(c.Defaults.KeepAlive.Interval, 0)
+}
+
+// KeepAliveMethod returns how keepalives check a resolved host is alive: its
+// own method, else the default, else a keepalive@golang.com request
+func (c *Config) KeepAliveMethod(host Host) string {
+	if host.KeepAliveMethod != "" {
+		return host.KeepAliveMethod
+	}
+	if c.Defaults.KeepAlive.Method != "" {
+		return c.Defaults.KeepAlive.Method
+	}
+	return KeepAliveGolang
+}
+
+// RemoteTarget returns what a local tunnel dials on the server: the remote
+// socket path, or remote host:port with IPv6 literals bracketed. For a port
+// range the port is the whole range, as each port is dialed separately.
+func (t Tunnel) RemoteTarget() string {
+	if t.RemoteSocket != "" {
+		return t.RemoteSocket
+	}
+	return net.JoinHostPort(t.RemoteHost, t.RemotePorts().String())
+}
+
+// RemoteBindAddress returns the address a remote tunnel listens on at the
+// server: loopback unless remote_bind opens it to other interfaces
+func (t Tunnel) RemoteBindAddress() string {
+	if t.RemoteBind == "" {
+		return "localhost"
+	}
+	return t.RemoteBind
 }
 
 // TunnelType indicates whether the tunnel is local or remote forwarding
@@ -66,7 +185,57 @@ const (
 // Group represents a named collection of tunnels
 type Group struct {
 	Description string   `yaml:"description"`
-	Tunnels     []string `yaml:"tunnels"`
+	Host        string   `yaml:"host"`
+	Autostart   bool     `yaml:"autostart"` // enable via Host whenever the daemon starts
+	Tunnels     []string `yaml:"tunnels"`   // tunnel names, optionally as "tunnel@host" to use a different host
+
+	// FallbackHosts are tried in order for members started via the group's
+	// host, when it can't be reached
+	FallbackHosts []string `yaml:"fallback_hosts,omitempty"`
+
+	// Stagger waits this long between starting each tunnel, to spread the
+	// channel opens on a shared host. Zero starts them back to back.
+	Stagger time.Duration `yaml:"stagger,omitempty"`
+}
+
+// GroupMember is a tunnel in a group and the host it overrides the group's with, if any
+type GroupMember struct {
+	Tunnel string
+	Host   string
+}
+
+// ParseGroupMember parses a group entry written as "tunnel" or "tunnel@host"
+func ParseGroupMember(entry string) GroupMember {
+	name, host, _ := strings.Cut(entry, "@")
+	return GroupMember{Tunnel: name, Host: host}
+}
+
+// Members returns the group's tunnels along with any per-tunnel hosts
+func (g Group) Members() []GroupMember {
+	members := make([]GroupMember, 0, len(g.Tunnels))
+	for _, entry := range g.Tunnels {
+		members = append(members, ParseGroupMember(entry))
+	}
+	return members
+}
+
+// TunnelNames returns the names of the group's tunnels without any host suffixes
+func (g Group) TunnelNames() []string {
+	names := make([]string, 0, len(g.Tunnels))
+	for _, m := range g.Members() {
+		names = append(names, m.Tunnel)
+	}
+	return names
+}
+
+// NeedsHost reports whether any tunnel in the group connects through the group's host
+func (g Group) NeedsHost() bool {
+	for _, m := range g.Members() {
+		if m.Host == "" {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -78,10 +247,14 @@ func DefaultConfig() *Config {
 				MaxBackoff:     30 * time.Second,
 				InitialBackoff: 1 * time.Second,
 				Multiplier:     2.0,
+				Strategy:       "exponential",
 			},
 			KeepAlive: KeepAliveConfig{
-				Interval: 30 * time.Second,
+				Interval:  30 * time.Second,
+				MaxMissed: 3,
+				Method:    KeepAliveGolang,
 			},
+			LogFormat: "text",
 		},
 		Hosts:   make(map[string]Host),
 		Tunnels: make(map[string]Tunnel),
@@ -89,8 +262,21 @@ func DefaultConfig() *Config {
 	}
 }
 
-// ConfigDir returns the path to the bore configuration directory
+// HomeEnvVar overrides the directory bore keeps its config, socket, PID,
+// log, and state files in
+const HomeEnvVar = "BORE_HOME"
+
+// ConfigDir returns the path to the bore configuration directory: $BORE_HOME
+// if set, otherwise ~/.bore
 func ConfigDir() (string, error) {
+	if dir := os.Getenv(HomeEnvVar); dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", HomeEnvVar, err)
+		}
+		return abs, nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -121,11 +307,39 @@ func LoadFrom(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return DefaultConfig(), nil
+			cfg := DefaultConfig()
+			cfg.Hash = hashConfig(nil)
+			return cfg, nil
 		}
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Hash = hashConfig(data)
+	return cfg, nil
+}
+
+// HashFile returns the hash of the config file at path, comparable with
+// Config.Hash. A missing file hashes the same as an empty one.
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	return hashConfig(data), nil
+}
+
+// hashConfig hashes the raw contents of a config file
+func hashConfig(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Parse parses configuration from YAML, applying defaults
+func Parse(data []byte) (*Config, error) {
 	cfg := DefaultConfig()
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -136,9 +350,10 @@ func LoadFrom(path string) (*Config, error) {
 		if t.LocalHost == "" {
 			t.LocalHost = "localhost"
 		}
-		if t.RemoteHost == "" {
+		if t.RemoteHost == "" && t.RemoteSocket == "" {
 			t.RemoteHost = "localhost"
 		}
+		t.normalizePortRanges()
 		cfg.Tunnels[name] = t
 	}
 
@@ -179,12 +394,63 @@ func (c *Config) GetTunnel(name string) (Tunnel, bool) {
 	return t, ok
 }
 
+// ReconnectEnabled reports whether a tunnel should be reconnected automatically
+// after it drops: its own reconnect setting if set, else the default
+func (c *Config) ReconnectEnabled(tunnelName string) bool {
+	if t, ok := c.Tunnels[tunnelName]; ok && t.Reconnect != nil {
+		return *t.Reconnect
+	}
+	return c.Defaults.Reconnect.Enabled
+}
+
 // GetHost returns a host by name
 func (c *Config) GetHost(name string) (Host, bool) {
 	h, ok := c.Hosts[name]
 	return h, ok
 }
 
+// IsKnownHost reports whether host is defined in bore's hosts or matches a
+// Host block in ~/.ssh/config
+func (c *Config) IsKnownHost(host string, sshReader *SSHConfigReader) bool {
+	if _, ok := c.Hosts[host]; ok {
+		return true
+	}
+	return sshReader.HasHost(host)
+}
+
+// ResolveHostName resolves a host given by name, as in --host or a tunnel's
+// host field. A name that isn't a defined host but parses as an inline
+// user@hostname:port is used as given, with SSH config filling in the rest
+// by hostname as ssh would.
+func (c *Config) ResolveHostName(name string, sshReader *SSHConfigReader) Host {
+	if !c.IsKnownHost(name, sshReader) && IsHostSpec(name) {
+		if spec, err := ParseHostSpec(name); err == nil {
+			return ResolveHost(spec.Hostname, spec, sshReader)
+		}
+	}
+	boreHost, _ := c.GetHost(name)
+	return ResolveHost(name, boreHost, sshReader)
+}
+
+// KnownHosts lists the hosts defined in bore's config and ~/.ssh/config,
+// sorted and without duplicates
+func (c *Config) KnownHosts(sshReader *SSHConfigReader) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for name := range c.Hosts {
+		seen[name] = true
+		hosts = append(hosts, name)
+	}
+	for _, name := range sshReader.Aliases() {
+		if !seen[name] {
+			seen[name] = true
+			hosts = append(hosts, name)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
 // GetGroup returns a group by name
 func (c *Config) GetGroup(name string) (Group, bool) {
 	g, ok := c.Groups[name]
@@ -197,5 +463,14 @@ func (c *Config) GetTunnelsForGroup(groupName string) ([]string, error) {
 	if !ok {
 		return nil, fmt.Errorf("group not found: %s", groupName)
 	}
-	return group.Tunnels, nil
+	return group.TunnelNames(), nil
+}
+
+// GetGroupMembers returns the tunnels in a group along with any per-tunnel hosts
+func (c *Config) GetGroupMembers(groupName string) ([]GroupMember, error) {
+	group, ok := c.Groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("group not found: %s", groupName)
+	}
+	return group.Members(), nil
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
@@ -25,6 +26,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Defaults.KeepAlive.Interval != 30*time.Second {
 		t.Errorf("expected keepalive interval 30s, got %v", cfg.Defaults.KeepAlive.Interval)
 	}
+	if cfg.Defaults.KeepAlive.MaxMissed != 3 {
+		t.Errorf("expected keepalive max missed 3, got %d", cfg.Defaults.KeepAlive.MaxMissed)
+	}
 }
 
 func TestLoadFrom(t *testing.T) {
@@ -59,12 +63,15 @@ tunnels:
   api-server:
     type: remote
     host: bastion
+    autostart: true
     local_port: 3000
     remote_port: 9000
 
 groups:
   development:
     description: "Dev tunnels"
+    host: bastion
+    autostart: true
     tunnels: [web-app, api-server]
 `
 
@@ -117,6 +124,15 @@ groups:
 	if webApp.LocalPort != 8080 {
 		t.Errorf("expected local port 8080, got %d", webApp.LocalPort)
 	}
+	if webApp.Host != "bastion" {
+		t.Errorf("expected tunnel host bastion, got %s", webApp.Host)
+	}
+	if webApp.Autostart {
+		t.Error("expected web-app autostart to default to false")
+	}
+	if !cfg.Tunnels["api-server"].Autostart {
+		t.Error("expected api-server autostart to be true")
+	}
 
 	// Check groups
 	if len(cfg.Groups) != 1 {
@@ -129,6 +145,44 @@ groups:
 	if len(dev.Tunnels) != 2 {
 		t.Errorf("expected 2 tunnels in group, got %d", len(dev.Tunnels))
 	}
+	if dev.Host != "bastion" {
+		t.Errorf("expected group host bastion, got %s", dev.Host)
+	}
+	if !dev.Autostart {
+		t.Error("expected development autostart to be true")
+	}
+}
+
+func TestParseRemoteSocket(t *testing.T) {
+	cfg, err := Parse([]byte(`
+tunnels:
+  docker:
+    type: local
+    local_port: 2375
+    remote_socket: /var/run/docker.sock
+  db:
+    type: local
+    local_port: 5432
+    remote_port: 5432
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// A socket tunnel has no remote host to default
+	docker := cfg.Tunnels["docker"]
+	if docker.RemoteHost != "" {
+		t.Errorf("expected no remote host for a socket tunnel, got %q", docker.RemoteHost)
+	}
+	if got := docker.RemoteTarget(); got != "/var/run/docker.sock" {
+		t.Errorf("RemoteTarget() = %q, want the socket path", got)
+	}
+	if got := cfg.Tunnels["db"].RemoteTarget(); got != "localhost:5432" {
+		t.Errorf("RemoteTarget() = %q, want localhost:5432", got)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
 }
 
 func TestLoadFromNonExistent(t *testing.T) {
@@ -143,6 +197,38 @@ func TestLoadFromNonExistent(t *testing.T) {
 	}
 }
 
+func TestConfigHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	missing, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if hash, _ := HashFile(path); hash != missing.Hash {
+		t.Errorf("expected a missing file to hash like an empty one, got %q and %q", missing.Hash, hash)
+	}
+
+	if err := os.WriteFile(path, []byte("tunnels:\n  web:\n    type: local\n    local_port: 8080\n    remote_port: 80\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+	hash, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile failed: %v", err)
+	}
+	if cfg.Hash != hash {
+		t.Errorf("expected the loaded config's hash %q to match the file's %q", cfg.Hash, hash)
+	}
+	if cfg.Hash == missing.Hash {
+		t.Error("expected editing the file to change its hash")
+	}
+}
+
 func TestGetTunnel(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Tunnels["test"] = Tunnel{
@@ -186,3 +272,147 @@ func TestGetTunnelsForGroup(t *testing.T) {
 		t.Error("expected error for nonexistent group")
 	}
 }
+
+func TestGroupMembers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Groups["dev"] = Group{
+		Host:    "bastion",
+		Tunnels: []string{"web", "db@bastion-west"},
+	}
+
+	members, err := cfg.GetGroupMembers("dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []GroupMember{
+		{Tunnel: "web"},
+		{Tunnel: "db", Host: "bastion-west"},
+	}
+	if !slices.Equal(members, want) {
+		t.Errorf("expected members %v, got %v", want, members)
+	}
+
+	tunnels, _ := cfg.GetTunnelsForGroup("dev")
+	if !slices.Equal(tunnels, []string{"web", "db"}) {
+		t.Errorf("expected tunnel names without hosts, got %v", tunnels)
+	}
+
+	if !cfg.Groups["dev"].NeedsHost() {
+		t.Error("expected group with a bare tunnel to need a host")
+	}
+	allHosts := Group{Tunnels: []string{"web@east", "db@west"}}
+	if allHosts.NeedsHost() {
+		t.Error("expected group whose tunnels all set a host not to need one")
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv(HomeEnvVar, "")
+	dir, err := ConfigDir()
+	if err != nil {
+		t.Fatalf("ConfigDir failed: %v", err)
+	}
+	if want := filepath.Join(home, ".bore"); dir != want {
+		t.Errorf("expected default %s, got %s", want, dir)
+	}
+
+	custom := filepath.Join(t.TempDir(), "bore")
+	t.Setenv(HomeEnvVar, custom)
+	if dir, _ := ConfigDir(); dir != custom {
+		t.Errorf("expected %s from %s, got %s", custom, HomeEnvVar, dir)
+	}
+
+	// Relative paths are resolved against the working directory
+	t.Setenv(HomeEnvVar, "relative")
+	cwd, _ := os.Getwd()
+	if dir, _ := ConfigDir(); dir != filepath.Join(cwd, "relative") {
+		t.Errorf("expected relative %s to be made absolute, got %s", HomeEnvVar, dir)
+	}
+}
+
+func TestReconnectEnabled(t *testing.T) {
+	data := []byte(`
+defaults:
+  reconnect:
+    enabled: false
+tunnels:
+  debug:
+    type: local
+    local_port: 8080
+    remote_port: 80
+  db:
+    type: local
+    local_port: 5432
+    remote_port: 5432
+    reconnect: true
+`)
+	cfg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.ReconnectEnabled("debug") {
+		t.Error("expected debug to inherit reconnect disabled from defaults")
+	}
+	if !cfg.ReconnectEnabled("db") {
+		t.Error("expected db's own reconnect: true to override the default")
+	}
+
+	// Reconnect stays on by default
+	if !DefaultConfig().ReconnectEnabled("anything") {
+		t.Error("expected reconnect to be enabled by default")
+	}
+}
+
+func TestKeepAliveInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want time.Duration
+	}{
+		{"default", `hosts: {bastion: {}}`, 30 * time.Second},
+		{"default changed", "defaults: {keep_alive: {interval: 10s}}\nhosts: {bastion: {}}", 10 * time.Second},
+		{"disabled by default", "defaults: {keep_alive: {interval: 0s}}\nhosts: {bastion: {}}", 0},
+		{"host override", `hosts: {bastion: {keep_alive_interval: 5s}}`, 5 * time.Second},
+		{"disabled for host", `hosts: {bastion: {keep_alive_interval: 0s}}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := cfg.KeepAliveInterval(cfg.Hosts["bastion"]); got != tt.want {
+				t.Errorf("KeepAliveInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepAliveMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"default", `hosts: {bastion: {}}`, KeepAliveGolang},
+		{"default changed", "defaults: {keep_alive: {method: openssh}}\nhosts: {bastion: {}}", KeepAliveOpenSSH},
+		{"host override", "defaults: {keep_alive: {method: openssh}}\nhosts: {bastion: {keep_alive_method: session}}", KeepAliveSession},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := cfg.KeepAliveMethod(cfg.Hosts["bastion"]); got != tt.want {
+				t.Errorf("KeepAliveMethod() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigDiff describes how the tunnels and groups of one config differ from
+// another's
+type ConfigDiff struct {
+	TunnelsAdded   []string            `json:"tunnels_added,omitempty"`
+	TunnelsRemoved []string            `json:"tunnels_removed,omitempty"`
+	TunnelsChanged map[string][]string `json:"tunnels_changed,omitempty"` // tunnel name to the fields that changed
+	GroupsAdded    []string            `json:"groups_added,omitempty"`
+	GroupsRemoved  []string            `json:"groups_removed,omitempty"`
+	GroupsChanged  map[string][]string `json:"groups_changed,omitempty"` // group name to the fields that changed
+}
+
+// Empty reports whether the configs had the same tunnels and groups
+func (d *ConfigDiff) Empty() bool {
+	return len(d.TunnelsAdded) == 0 && len(d.TunnelsRemoved) == 0 && len(d.TunnelsChanged) == 0 &&
+		len(d.GroupsAdded) == 0 && len(d.GroupsRemoved) == 0 && len(d.GroupsChanged) == 0
+}
+
+// Diff compares the tunnels and groups of two configs. Changed fields are
+// named as in the YAML. A nil config counts as empty.
+func Diff(old, new *Config) *ConfigDiff {
+	if old == nil {
+		old = &Config{}
+	}
+	if new == nil {
+		new = &Config{}
+	}
+
+	d := &ConfigDiff{}
+	d.TunnelsAdded, d.TunnelsRemoved, d.TunnelsChanged = diffMaps(old.Tunnels, new.Tunnels)
+	d.GroupsAdded, d.GroupsRemoved, d.GroupsChanged = diffMaps(old.Groups, new.Groups)
+	return d
+}
+
+// diffMaps compares two maps of config sections by name
+func diffMaps[T any](old, new map[string]T) (added, removed []string, changed map[string][]string) {
+	for name, next := range new {
+		prev, ok := old[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		if fields := changedFields(prev, next); len(fields) > 0 {
+			if changed == nil {
+				changed = make(map[string][]string)
+			}
+			changed[name] = fields
+		}
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, changed
+}
+
+// changedFields lists the YAML names of the fields that differ between two
+// values of the same struct type, in declaration order
+func changedFields(old, new interface{}) []string {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := ov.Type()
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if !reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
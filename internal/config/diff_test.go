@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	yes := true
+	old := &Config{
+		Tunnels: map[string]Tunnel{
+			"web":   {Type: TunnelTypeLocal, LocalPort: 8080, RemoteHost: "web.internal", RemotePort: 80},
+			"db":    {Type: TunnelTypeLocal, LocalPort: 5432, RemoteHost: "db.internal", RemotePort: 5432},
+			"cache": {Type: TunnelTypeLocal, LocalPort: 6379, RemoteHost: "cache.internal", RemotePort: 6379},
+		},
+		Groups: map[string]Group{
+			"dev":  {Host: "bastion", Tunnels: []string{"web", "db"}},
+			"prod": {Host: "prod-bastion", Tunnels: []string{"db"}},
+		},
+	}
+	new := &Config{
+		Tunnels: map[string]Tunnel{
+			"web":     {Type: TunnelTypeLocal, LocalPort: 8081, RemoteHost: "web-2.internal", RemotePort: 80},
+			"db":      {Type: TunnelTypeLocal, LocalPort: 5432, RemoteHost: "db.internal", RemotePort: 5432, Reconnect: &yes},
+			"metrics": {Type: TunnelTypeLocal, LocalPort: 9090, RemoteHost: "prometheus.internal", RemotePort: 9090},
+		},
+		Groups: map[string]Group{
+			"dev": {Host: "bastion", Tunnels: []string{"web", "db", "metrics"}, Stagger: time.Second},
+			"ops": {Host: "bastion", Tunnels: []string{"metrics"}},
+		},
+	}
+
+	want := &ConfigDiff{
+		TunnelsAdded:   []string{"metrics"},
+		TunnelsRemoved: []string{"cache"},
+		TunnelsChanged: map[string][]string{
+			"web": {"local_port", "remote_host"},
+			"db":  {"reconnect"},
+		},
+		GroupsAdded:   []string{"ops"},
+		GroupsRemoved: []string{"prod"},
+		GroupsChanged: map[string][]string{
+			"dev": {"tunnels", "stagger"},
+		},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+
+	if d := Diff(old, old); !d.Empty() {
+		t.Errorf("expected no differences between a config and itself, got %+v", d)
+	}
+
+	// A config that never loaded counts as empty
+	if d := Diff(nil, new); len(d.TunnelsAdded) != 3 || len(d.GroupsAdded) != 2 {
+		t.Errorf("expected everything to be added, got %+v", d)
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortRange is an inclusive range of ports, written as "8000-8010"
+type PortRange struct {
+	First int
+	Last  int
+}
+
+// ParsePortRange parses a "first-last" port range. A single port is a range
+// of one.
+func ParsePortRange(s string) (PortRange, error) {
+	first, last, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		last = first
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return PortRange{}, fmt.Errorf("'%s' is not a port range like 8000-8010", s)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(last))
+	if err != nil {
+		return PortRange{}, fmt.Errorf("'%s' is not a port range like 8000-8010", s)
+	}
+	r := PortRange{First: start, Last: end}
+	if start <= 0 || end > 65535 {
+		return r, fmt.Errorf("'%s' must be between 1 and 65535", s)
+	}
+	if end < start {
+		return r, fmt.Errorf("'%s' ends before it starts", s)
+	}
+	return r, nil
+}
+
+// Len returns the number of ports in the range
+func (r PortRange) Len() int {
+	return r.Last - r.First + 1
+}
+
+// Contains reports whether port is in the range
+func (r PortRange) Contains(port int) bool {
+	return port >= r.First && port <= r.Last
+}
+
+// Overlaps reports whether two ranges share a port
+func (r PortRange) Overlaps(other PortRange) bool {
+	return r.First <= other.Last && other.First <= r.Last
+}
+
+func (r PortRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// IsRange reports whether the tunnel forwards a range of ports
+func (t Tunnel) IsRange() bool {
+	return t.LocalPortRange != ""
+}
+
+// LocalPorts returns the local ports the tunnel uses: its port range, or
+// just local_port
+func (t Tunnel) LocalPorts() PortRange {
+	if t.LocalPortRange != "" {
+		if r, err := ParsePortRange(t.LocalPortRange); err == nil {
+			return r
+		}
+	}
+	return PortRange{First: t.LocalPort, Last: t.LocalPort}
+}
+
+// RemotePorts returns the remote ports the tunnel uses. A range tunnel
+// without remote_port_range uses as many ports starting at remote_port.
+func (t Tunnel) RemotePorts() PortRange {
+	if t.RemotePortRange != "" {
+		if r, err := ParsePortRange(t.RemotePortRange); err == nil {
+			return r
+		}
+	}
+	if t.IsRange() {
+		return PortRange{First: t.RemotePort, Last: t.RemotePort + t.LocalPorts().Len() - 1}
+	}
+	return PortRange{First: t.RemotePort, Last: t.RemotePort}
+}
+
+// Expand returns one single-port tunnel per port of a range tunnel, pairing
+// local and remote ports in order. Other tunnels expand to themselves.
+func (t Tunnel) Expand() []Tunnel {
+	if !t.IsRange() {
+		return []Tunnel{t}
+	}
+	local, remote := t.LocalPorts(), t.RemotePorts()
+	tunnels := make([]Tunnel, 0, local.Len())
+	for i := 0; i < local.Len(); i++ {
+		port := t
+		port.LocalPortRange = ""
+		port.RemotePortRange = ""
+		port.LocalPort = local.First + i
+		port.RemotePort = remote.First + i
+		tunnels = append(tunnels, port)
+	}
+	return tunnels
+}
+
+// normalizePortRanges fills local_port and remote_port from a tunnel's
+// ranges, so code that only looks at a single port sees the first one
+func (t *Tunnel) normalizePortRanges() {
+	if !t.IsRange() {
+		return
+	}
+	if t.LocalPort == 0 {
+		t.LocalPort = t.LocalPorts().First
+	}
+	if t.RemotePort == 0 && t.RemoteSocket == "" {
+		if t.RemotePortRange != "" {
+			t.RemotePort = t.RemotePorts().First
+		} else {
+			t.RemotePort = t.LocalPort
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PortRange
+		wantErr bool
+	}{
+		{"8000-8010", PortRange{8000, 8010}, false},
+		{" 8000 - 8010 ", PortRange{8000, 8010}, false},
+		{"8000", PortRange{8000, 8000}, false},
+		{"8010-8000", PortRange{}, true},
+		{"0-10", PortRange{}, true},
+		{"65530-65536", PortRange{}, true},
+		{"8000-", PortRange{}, true},
+		{"ftp", PortRange{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePortRange(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePortRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePortRange(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortRangeOverlaps(t *testing.T) {
+	r := PortRange{8000, 8010}
+	if !r.Overlaps(PortRange{8010, 8020}) || !r.Overlaps(PortRange{8005, 8005}) {
+		t.Error("expected touching and contained ranges to overlap")
+	}
+	if r.Overlaps(PortRange{8011, 8020}) {
+		t.Error("expected adjacent ranges not to overlap")
+	}
+}
+
+func TestTunnelExpand(t *testing.T) {
+	cfg, err := Parse([]byte(`
+tunnels:
+  rtp:
+    type: local
+    local_port_range: 10000-10002
+  ftp:
+    type: remote
+    local_port_range: 3000-3001
+    remote_port_range: 9000-9001
+  web:
+    type: local
+    local_port: 8080
+    remote_port: 80
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	ports := func(name string) [][2]int {
+		var got [][2]int
+		for _, t := range cfg.Tunnels[name].Expand() {
+			got = append(got, [2]int{t.LocalPort, t.RemotePort})
+		}
+		return got
+	}
+	if got, want := ports("rtp"), [][2]int{{10000, 10000}, {10001, 10001}, {10002, 10002}}; !slices.Equal(got, want) {
+		t.Errorf("rtp expanded to %v, want %v", got, want)
+	}
+	if got, want := ports("ftp"), [][2]int{{3000, 9000}, {3001, 9001}}; !slices.Equal(got, want) {
+		t.Errorf("ftp expanded to %v, want %v", got, want)
+	}
+	if got, want := ports("web"), [][2]int{{8080, 80}}; !slices.Equal(got, want) {
+		t.Errorf("web expanded to %v, want %v", got, want)
+	}
+	for _, port := range cfg.Tunnels["ftp"].Expand() {
+		if port.IsRange() {
+			t.Errorf("expanded port %d is still a range", port.LocalPort)
+		}
+	}
+}
@@ -3,14 +3,20 @@ package config
 import (
 	"bufio"
 	"bytes"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kevinburke/ssh_config"
 )
 
+// maxIncludeDepth bounds nested Include directives, matching OpenSSH
+const maxIncludeDepth = 16
+
 // SSHConfigReader reads host information from ~/.ssh/config
 type SSHConfigReader struct {
 	cfg *ssh_config.Config
@@ -23,7 +29,8 @@ func NewSSHConfigReader() (*SSHConfigReader, error) {
 		return nil, err
 	}
 
-	configPath := filepath.Join(home, ".ssh", "config")
+	sshDir := filepath.Join(home, ".ssh")
+	configPath := filepath.Join(sshDir, "config")
 	content, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -33,8 +40,9 @@ func NewSSHConfigReader() (*SSHConfigReader, error) {
 		return nil, err
 	}
 
-	// Filter out Match blocks which aren't supported by the ssh_config library
-	filtered := filterMatchBlocks(content)
+	// Filter out Match blocks which aren't supported by the ssh_config library,
+	// here and in every included file
+	filtered := expandIncludes(filterMatchBlocks(content), sshDir, map[string]bool{configPath: true})
 
 	cfg, err := ssh_config.Decode(bytes.NewReader(filtered))
 	if err != nil {
@@ -75,6 +83,113 @@ func filterMatchBlocks(content []byte) []byte {
 	return result.Bytes()
 }
 
+// expandIncludes replaces Include directives with the contents of the files
+// they name, so hosts defined in included files are seen and their Match
+// blocks filtered too. Relative paths are under sshDir and globs expand in
+// lexical order, as with OpenSSH. active holds the files being expanded,
+// to stop include loops.
+func expandIncludes(content []byte, sshDir string, active map[string]bool) []byte {
+	var result bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	// The Host line whose block we're in, restored after an included file so
+	// the lines following the Include still belong to it
+	currentHost := "Host *"
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value := splitDirective(line)
+
+		switch key {
+		case "host":
+			currentHost = strings.TrimSpace(line)
+		case "include":
+			if len(active) > maxIncludeDepth {
+				continue
+			}
+			for _, path := range includePaths(value, sshDir) {
+				if active[path] {
+					continue
+				}
+				included, err := os.ReadFile(path)
+				if err != nil {
+					continue
+				}
+				active[path] = true
+				result.Write(expandIncludes(filterMatchBlocks(included), sshDir, active))
+				delete(active, path)
+				result.WriteString(currentHost + "\n")
+			}
+			continue
+		}
+
+		result.WriteString(line)
+		result.WriteString("\n")
+	}
+
+	return result.Bytes()
+}
+
+// splitDirective returns a config line's lowercased keyword and its value,
+// which may follow whitespace or "="
+func splitDirective(line string) (key, value string) {
+	trimmed := strings.TrimSpace(line)
+	end := strings.IndexAny(trimmed, " \t=")
+	if end < 0 {
+		return strings.ToLower(trimmed), ""
+	}
+	value = strings.TrimLeft(trimmed[end:], " \t")
+	value = strings.TrimPrefix(value, "=")
+	return strings.ToLower(trimmed[:end]), strings.TrimSpace(value)
+}
+
+// includePaths expands the paths and globs of an Include directive
+func includePaths(value, sshDir string) []string {
+	var paths []string
+	for _, pattern := range strings.Fields(value) {
+		pattern = expandPath(pattern)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(sshDir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// HasHost reports whether a Host block other than the catch-all "Host *" matches alias
+func (r *SSHConfigReader) HasHost(alias string) bool {
+	for _, host := range r.cfg.Hosts {
+		if !host.Matches(alias) {
+			continue
+		}
+		for _, pattern := range host.Patterns {
+			if pattern.String() != "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Aliases lists the names of Host blocks, skipping wildcard and negated
+// patterns that don't name a single host
+func (r *SSHConfigReader) Aliases() []string {
+	var aliases []string
+	for _, host := range r.cfg.Hosts {
+		for _, pattern := range host.Patterns {
+			// Matches is false for a name the block negates
+			name := pattern.String()
+			if !strings.ContainsAny(name, "*?") && host.Matches(name) {
+				aliases = append(aliases, name)
+			}
+		}
+	}
+	return aliases
+}
+
 // GetHostname returns the actual hostname for an alias
 func (r *SSHConfigReader) GetHostname(alias string) string {
 	hostname, _ := r.cfg.Get(alias, "HostName")
@@ -109,20 +224,111 @@ func (r *SSHConfigReader) GetIdentityFile(alias string) string {
 	return expandPath(identityFile)
 }
 
+// GetCertificateFile returns the certificate file for a host
+func (r *SSHConfigReader) GetCertificateFile(alias string) string {
+	certFile, _ := r.cfg.Get(alias, "CertificateFile")
+	return expandPath(certFile)
+}
+
+// GetIdentityAgent returns the agent socket for a host
+func (r *SSHConfigReader) GetIdentityAgent(alias string) string {
+	identityAgent, _ := r.cfg.Get(alias, "IdentityAgent")
+	return identityAgent
+}
+
+// GetIdentitiesOnly reports whether a host only offers its configured identity files
+func (r *SSHConfigReader) GetIdentitiesOnly(alias string) bool {
+	identitiesOnly, _ := r.cfg.Get(alias, "IdentitiesOnly")
+	return strings.EqualFold(identitiesOnly, "yes")
+}
+
+// GetConnectTimeout returns the connect timeout for a host, or 0 if unset
+func (r *SSHConfigReader) GetConnectTimeout(alias string) time.Duration {
+	timeout, _ := r.cfg.Get(alias, "ConnectTimeout")
+	return parseSeconds(timeout)
+}
+
+// GetServerAliveInterval returns the keepalive interval for a host, or 0 if unset
+func (r *SSHConfigReader) GetServerAliveInterval(alias string) time.Duration {
+	interval, _ := r.cfg.Get(alias, "ServerAliveInterval")
+	return parseSeconds(interval)
+}
+
+// parseSeconds parses an ssh_config duration given in whole seconds
+func parseSeconds(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 // GetProxyJump returns the proxy jump host for a host
 func (r *SSHConfigReader) GetProxyJump(alias string) string {
 	proxyJump, _ := r.cfg.Get(alias, "ProxyJump")
 	return proxyJump
 }
 
+// IsHostSpec reports whether host looks like an inline [user@]hostname[:port]
+// rather than the name of a defined host
+func IsHostSpec(host string) bool {
+	return strings.ContainsAny(host, "@:")
+}
+
+// ParseHostSpec parses an inline host written as [user@]hostname[:port].
+// IPv6 addresses need brackets when a port is given, e.g. admin@[::1]:2222.
+// An unset port is left zero.
+func ParseHostSpec(spec string) (Host, error) {
+	var host Host
+	rest := spec
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		host.User, rest = spec[:i], spec[i+1:]
+		if host.User == "" {
+			return Host{}, fmt.Errorf("invalid host '%s': empty user", spec)
+		}
+	}
+
+	// A bare IPv6 address has several colons and no port
+	if strings.HasPrefix(rest, "[") || strings.Count(rest, ":") == 1 {
+		hostname, port, err := net.SplitHostPort(rest)
+		if err != nil {
+			return Host{}, fmt.Errorf("invalid host '%s': %w", spec, err)
+		}
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return Host{}, fmt.Errorf("invalid host '%s': port must be between 1 and 65535", spec)
+		}
+		host.Port = n
+		rest = hostname
+	} else if strings.Contains(rest, ":") && net.ParseIP(rest) == nil {
+		return Host{}, fmt.Errorf("invalid host '%s': not a hostname or IP address", spec)
+	}
+
+	if rest == "" || strings.ContainsAny(rest, " \t/@") {
+		return Host{}, fmt.Errorf("invalid host '%s': not a hostname or IP address", spec)
+	}
+	host.Hostname = rest
+	return host, nil
+}
+
 // ResolveHost combines bore config and SSH config to get full host details
 func ResolveHost(hostName string, boreHost Host, sshReader *SSHConfigReader) Host {
 	resolved := Host{
-		Hostname:     boreHost.Hostname,
-		User:         boreHost.User,
-		Port:         boreHost.Port,
-		IdentityFile: boreHost.IdentityFile,
-		ProxyJump:    boreHost.ProxyJump,
+		Hostname:       boreHost.Hostname,
+		User:           boreHost.User,
+		Port:           boreHost.Port,
+		IdentityFile:   boreHost.IdentityFile,
+		CertFile:       boreHost.CertFile,
+		IdentitiesOnly: boreHost.IdentitiesOnly,
+		IdentityAgent:  boreHost.IdentityAgent,
+		ProxyJump:      boreHost.ProxyJump,
+
+		ConnectTimeout:    boreHost.ConnectTimeout,
+		KeepAliveInterval: boreHost.KeepAliveInterval,
+		KeepAliveMethod:   boreHost.KeepAliveMethod,
 	}
 
 	// Fill in missing values from SSH config
@@ -138,9 +344,26 @@ func ResolveHost(hostName string, boreHost Host, sshReader *SSHConfigReader) Hos
 	if resolved.IdentityFile == "" {
 		resolved.IdentityFile = sshReader.GetIdentityFile(hostName)
 	}
+	if resolved.CertFile == "" {
+		resolved.CertFile = sshReader.GetCertificateFile(hostName)
+	}
+	if !resolved.IdentitiesOnly {
+		resolved.IdentitiesOnly = sshReader.GetIdentitiesOnly(hostName)
+	}
+	if resolved.IdentityAgent == "" {
+		resolved.IdentityAgent = sshReader.GetIdentityAgent(hostName)
+	}
 	if resolved.ProxyJump == "" {
 		resolved.ProxyJump = sshReader.GetProxyJump(hostName)
 	}
+	if resolved.ConnectTimeout == 0 {
+		resolved.ConnectTimeout = sshReader.GetConnectTimeout(hostName)
+	}
+	if resolved.KeepAliveInterval == nil {
+		if interval := sshReader.GetServerAliveInterval(hostName); interval > 0 {
+			resolved.KeepAliveInterval = &interval
+		}
+	}
 
 	// Apply defaults
 	if resolved.Hostname == "" {
@@ -150,8 +373,10 @@ func ResolveHost(hostName string, boreHost Host, sshReader *SSHConfigReader) Hos
 		resolved.Port = 22
 	}
 
-	// Expand identity file path
+	// Expand identity and certificate file paths
 	resolved.IdentityFile = expandPath(resolved.IdentityFile)
+	resolved.CertFile = expandPath(resolved.CertFile)
+	resolved.IdentityAgent = expandPath(resolved.IdentityAgent)
 
 	return resolved
 }
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/ssh_config"
+)
+
+func newTestSSHReader(t *testing.T, content string) *SSHConfigReader {
+	t.Helper()
+	cfg, err := ssh_config.Decode(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to decode SSH config: %v", err)
+	}
+	return &SSHConfigReader{cfg: cfg}
+}
+
+func TestResolveHostSSHConfigDirectives(t *testing.T) {
+	reader := newTestSSHReader(t, `
+Host bastion
+  HostName bastion.example.com
+  IdentitiesOnly yes
+  ConnectTimeout 10
+  ServerAliveInterval 15
+
+Host plain
+  HostName plain.example.com
+`)
+
+	resolved := ResolveHost("bastion", Host{}, reader)
+	if !resolved.IdentitiesOnly {
+		t.Error("expected IdentitiesOnly from SSH config")
+	}
+	if resolved.ConnectTimeout != 10*time.Second {
+		t.Errorf("expected connect timeout 10s, got %v", resolved.ConnectTimeout)
+	}
+	if resolved.KeepAliveInterval == nil || *resolved.KeepAliveInterval != 15*time.Second {
+		t.Errorf("expected keepalive interval 15s, got %v", resolved.KeepAliveInterval)
+	}
+
+	resolved = ResolveHost("plain", Host{}, reader)
+	if resolved.IdentitiesOnly {
+		t.Error("expected IdentitiesOnly to default to false")
+	}
+	if resolved.ConnectTimeout != 0 {
+		t.Errorf("expected no connect timeout, got %v", resolved.ConnectTimeout)
+	}
+	if resolved.KeepAliveInterval != nil {
+		t.Errorf("expected no keepalive interval, got %v", *resolved.KeepAliveInterval)
+	}
+}
+
+func TestResolveHostBoreConfigOverridesSSHConfig(t *testing.T) {
+	reader := newTestSSHReader(t, `
+Host bastion
+  ConnectTimeout 10
+  ServerAliveInterval 15
+`)
+
+	interval := time.Minute
+	resolved := ResolveHost("bastion", Host{
+		ConnectTimeout:    5 * time.Second,
+		KeepAliveInterval: &interval,
+	}, reader)
+	if resolved.ConnectTimeout != 5*time.Second {
+		t.Errorf("expected connect timeout 5s, got %v", resolved.ConnectTimeout)
+	}
+	if resolved.KeepAliveInterval == nil || *resolved.KeepAliveInterval != time.Minute {
+		t.Errorf("expected keepalive interval 1m, got %v", resolved.KeepAliveInterval)
+	}
+}
+
+func TestParseSeconds(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"10s", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseSeconds(tt.value); got != tt.want {
+			t.Errorf("parseSeconds(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSSHConfigInclude(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sshDir := filepath.Join(home, ".ssh")
+
+	files := map[string]string{
+		"config": `
+Include config.d/*
+
+Host bastion
+  HostName bastion.example.com
+  Include ~/.ssh/bastion-extra
+  Port 2222
+`,
+		"config.d/10-work": `
+Host work-bastion
+  HostName work.example.com
+  User deploy
+
+Match host *.internal
+  User nobody
+
+# Loops back to the main config
+Include config
+`,
+		"config.d/20-lab": `
+Host lab
+  HostName lab.example.com
+`,
+		"bastion-extra": `User admin
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(sshDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	reader, err := NewSSHConfigReader()
+	if err != nil {
+		t.Fatalf("NewSSHConfigReader failed: %v", err)
+	}
+
+	for _, alias := range []string{"work-bastion", "lab", "bastion"} {
+		if !reader.HasHost(alias) {
+			t.Errorf("expected host %s from an included file", alias)
+		}
+	}
+	if got := reader.GetHostname("work-bastion"); got != "work.example.com" {
+		t.Errorf("expected included HostName, got %q", got)
+	}
+	if got := reader.GetUser("work-bastion"); got != "deploy" {
+		t.Errorf("expected included User, got %q", got)
+	}
+
+	// An Include inside a Host block applies to it, and so do the lines after it
+	if got := reader.GetUser("bastion"); got != "admin" {
+		t.Errorf("expected User from the file included in the Host block, got %q", got)
+	}
+	if got := reader.GetPort("bastion"); got != 2222 {
+		t.Errorf("expected Port after the Include to stay with its Host block, got %d", got)
+	}
+
+	// A tunnel referencing an included host isn't flagged as undefined
+	cfg := &Config{Tunnels: map[string]Tunnel{"db": {Host: "work-bastion"}}}
+	if warnings := cfg.Warnings(reader); len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	reader := newTestSSHReader(t, `
+Host bastion jump
+  HostName bastion.example.com
+
+Host *.internal !secret.internal
+  User deploy
+
+Host *
+  ServerAliveInterval 30
+`)
+	cfg := &Config{Hosts: map[string]Host{"prod": {Hostname: "prod.example.com"}, "bastion": {}}}
+
+	want := []string{"bastion", "jump", "prod"}
+	if got := cfg.KnownHosts(reader); !reflect.DeepEqual(got, want) {
+		t.Errorf("KnownHosts() = %v, want %v", got, want)
+	}
+
+	for host, want := range map[string]bool{
+		"prod":         true,
+		"jump":         true,
+		"db.internal":  true, // matched by a wildcard block
+		"example.com":  false,
+		"bastion.typo": false,
+	} {
+		if got := cfg.IsKnownHost(host, reader); got != want {
+			t.Errorf("IsKnownHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestParseHostSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Host
+		wantErr bool
+	}{
+		{"admin@1.2.3.4:2222", Host{User: "admin", Hostname: "1.2.3.4", Port: 2222}, false},
+		{"admin@bastion.example.com", Host{User: "admin", Hostname: "bastion.example.com"}, false},
+		{"bastion.example.com:2222", Host{Hostname: "bastion.example.com", Port: 2222}, false},
+		{"admin@[2001:db8::1]:2222", Host{User: "admin", Hostname: "2001:db8::1", Port: 2222}, false},
+		{"admin@2001:db8::1", Host{User: "admin", Hostname: "2001:db8::1"}, false},
+		{"git@team@host", Host{User: "git@team", Hostname: "host"}, false},
+		{"@host", Host{}, true},
+		{"admin@", Host{}, true},
+		{"host:0", Host{}, true},
+		{"host:ssh", Host{}, true},
+		{"host:22:33", Host{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseHostSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHostSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseHostSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveHostName(t *testing.T) {
+	reader := newTestSSHReader(t, `
+Host *.example.com
+  IdentityFile ~/.ssh/example
+
+Host jump@odd
+  HostName odd.example.com
+`)
+	cfg := &Config{Hosts: map[string]Host{"bastion": {Hostname: "bastion.example.com", User: "admin"}}}
+
+	got := cfg.ResolveHostName("deploy@db.example.com:2222", reader)
+	if got.User != "deploy" || got.Hostname != "db.example.com" || got.Port != 2222 {
+		t.Errorf("expected the inline user, hostname, and port, got %+v", got)
+	}
+	if !strings.HasSuffix(got.IdentityFile, "/.ssh/example") {
+		t.Errorf("expected SSH config to match the hostname, got identity file %q", got.IdentityFile)
+	}
+
+	if got := cfg.ResolveHostName("deploy@db.example.com", reader); got.Port != 22 {
+		t.Errorf("expected the default port, got %d", got.Port)
+	}
+	if got := cfg.ResolveHostName("bastion", reader); got.Hostname != "bastion.example.com" || got.User != "admin" {
+		t.Errorf("expected the bore host, got %+v", got)
+	}
+
+	// A defined alias wins over parsing it as user@hostname
+	if got := cfg.ResolveHostName("jump@odd", reader); got.Hostname != "odd.example.com" || got.User != "" {
+		t.Errorf("expected the SSH config alias, got %+v", got)
+	}
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"sort"
 	"strings"
 )
 
@@ -33,8 +36,19 @@ func (e ValidationErrors) Error() string {
 func (c *Config) Validate() error {
 	var errs ValidationErrors
 
-	// Validate defaults
-	if c.Defaults.Reconnect.Multiplier <= 0 {
+	// Validate defaults. A constant backoff only uses initial_backoff.
+	constant := false
+	switch c.Defaults.Reconnect.Strategy {
+	case "", "exponential":
+	case "constant":
+		constant = true
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "defaults.reconnect.strategy",
+			Message: fmt.Sprintf("must be 'exponential' or 'constant', got '%s'", c.Defaults.Reconnect.Strategy),
+		})
+	}
+	if !constant && c.Defaults.Reconnect.Multiplier <= 0 {
 		errs = append(errs, ValidationError{
 			Field:   "defaults.reconnect.multiplier",
 			Message: "must be greater than 0",
@@ -46,7 +60,7 @@ func (c *Config) Validate() error {
 			Message: "must be non-negative",
 		})
 	}
-	if c.Defaults.Reconnect.MaxBackoff < c.Defaults.Reconnect.InitialBackoff {
+	if !constant && c.Defaults.Reconnect.MaxBackoff < c.Defaults.Reconnect.InitialBackoff {
 		errs = append(errs, ValidationError{
 			Field:   "defaults.reconnect.max_backoff",
 			Message: "must be greater than or equal to initial_backoff",
@@ -58,6 +72,78 @@ func (c *Config) Validate() error {
 			Message: "must be non-negative",
 		})
 	}
+	if c.Defaults.KeepAlive.MaxMissed < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "defaults.keep_alive.max_missed",
+			Message: "must be non-negative",
+		})
+	}
+	if msg := checkKeepAliveMethod(c.Defaults.KeepAlive.Method); msg != "" {
+		errs = append(errs, ValidationError{Field: "defaults.keep_alive.method", Message: msg})
+	}
+
+	if c.Defaults.MaxRate < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "defaults.max_rate",
+			Message: "must be non-negative",
+		})
+	}
+
+	if c.Defaults.NetworkPoll < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "defaults.network_poll",
+			Message: "must be non-negative",
+		})
+	}
+	if c.Defaults.DNSTimeout < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "defaults.dns_timeout",
+			Message: "must be non-negative",
+		})
+	}
+	if c.Defaults.MaxConnectionAge < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "defaults.max_connection_age",
+			Message: "must be non-negative",
+		})
+	}
+
+	if c.Defaults.HealthAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Defaults.HealthAddr); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "defaults.health_addr",
+				Message: fmt.Sprintf("must be host:port, e.g. 127.0.0.1:9190: %v", err),
+			})
+		}
+	}
+
+	switch c.Defaults.LogFormat {
+	case "", "text", "json":
+	default:
+		errs = append(errs, ValidationError{
+			Field:   "defaults.log_format",
+			Message: fmt.Sprintf("must be 'text' or 'json', got '%s'", c.Defaults.LogFormat),
+		})
+	}
+
+	// Validate hosts
+	for name, host := range c.Hosts {
+		if host.ConnectTimeout < 0 {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("hosts.%s.connect_timeout", name),
+				Message: "must be non-negative",
+			})
+		}
+		if host.KeepAliveInterval != nil && *host.KeepAliveInterval < 0 {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("hosts.%s.keep_alive_interval", name),
+				Message: "must be non-negative",
+			})
+		}
+		if msg := checkKeepAliveMethod(host.KeepAliveMethod); msg != "" {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("hosts.%s.keep_alive_method", name), Message: msg})
+		}
+	}
 
 	// Validate tunnels
 	for name, tunnel := range c.Tunnels {
@@ -67,13 +153,23 @@ func (c *Config) Validate() error {
 	// Check for duplicate local ports across tunnels
 	portToTunnel := make(map[int]string)
 	for name, tunnel := range c.Tunnels {
-		if existingName, exists := portToTunnel[tunnel.LocalPort]; exists {
-			errs = append(errs, ValidationError{
-				Field:   fmt.Sprintf("tunnels.%s.local_port", name),
-				Message: fmt.Sprintf("port %d conflicts with tunnel '%s'", tunnel.LocalPort, existingName),
-			})
-		} else {
-			portToTunnel[tunnel.LocalPort] = name
+		ports := tunnel.LocalPorts()
+		conflict := false
+		for port := ports.First; port <= ports.Last; port++ {
+			if existingName, exists := portToTunnel[port]; exists {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("tunnels.%s.%s", name, localPortField(tunnel)),
+					Message: fmt.Sprintf("port %d conflicts with tunnel '%s'", port, existingName),
+				})
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+		for port := ports.First; port <= ports.Last; port++ {
+			portToTunnel[port] = name
 		}
 	}
 
@@ -88,6 +184,146 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Warnings checks for problems that may be intentional, such as an identity
+// file that is missing because the host authenticates through an agent, or a
+// tunnel host that is meant to be dialed directly by hostname
+func (c *Config) Warnings(sshReader *SSHConfigReader) ValidationErrors {
+	var warnings ValidationErrors
+
+	for name, host := range c.Hosts {
+		resolved := ResolveHost(name, host, sshReader)
+		if msg := checkReadable(resolved.IdentityFile); msg != "" {
+			warnings = append(warnings, ValidationError{
+				Field:   fmt.Sprintf("hosts.%s.identity_file", name),
+				Message: msg,
+			})
+		}
+		if msg := checkReadable(resolved.CertFile); msg != "" {
+			warnings = append(warnings, ValidationError{
+				Field:   fmt.Sprintf("hosts.%s.cert_file", name),
+				Message: msg,
+			})
+		}
+	}
+
+	for name, tunnel := range c.Tunnels {
+		if msg := c.checkHostRef(tunnel.Host, sshReader); msg != "" {
+			warnings = append(warnings, ValidationError{
+				Field:   fmt.Sprintf("tunnels.%s.host", name),
+				Message: msg,
+			})
+		}
+		for i, host := range tunnel.FallbackHosts {
+			if msg := c.checkHostRef(host, sshReader); msg != "" {
+				warnings = append(warnings, ValidationError{
+					Field:   fmt.Sprintf("tunnels.%s.fallback_hosts[%d]", name, i),
+					Message: msg,
+				})
+			}
+		}
+	}
+
+	for name, group := range c.Groups {
+		if msg := c.checkHostRef(group.Host, sshReader); msg != "" {
+			warnings = append(warnings, ValidationError{
+				Field:   fmt.Sprintf("groups.%s.host", name),
+				Message: msg,
+			})
+		}
+		for i, host := range group.FallbackHosts {
+			if msg := c.checkHostRef(host, sshReader); msg != "" {
+				warnings = append(warnings, ValidationError{
+					Field:   fmt.Sprintf("groups.%s.fallback_hosts[%d]", name, i),
+					Message: msg,
+				})
+			}
+		}
+		for i, member := range group.Members() {
+			if msg := c.checkHostRef(member.Host, sshReader); msg != "" {
+				warnings = append(warnings, ValidationError{
+					Field:   fmt.Sprintf("groups.%s.tunnels[%d]", name, i),
+					Message: msg,
+				})
+			}
+		}
+	}
+
+	sort.Slice(warnings, func(i, j int) bool { return warnings[i].Field < warnings[j].Field })
+	return warnings
+}
+
+// Notes lists advisory findings that never fail validation, even with
+// --strict: tunnels that no group references, which tend to be leftovers.
+// Configs without groups and autostart tunnels, which are used without a
+// group, aren't reported.
+func (c *Config) Notes() ValidationErrors {
+	if len(c.Groups) == 0 {
+		return nil
+	}
+
+	referenced := make(map[string]bool)
+	for _, group := range c.Groups {
+		for _, name := range group.TunnelNames() {
+			referenced[name] = true
+		}
+	}
+
+	var notes ValidationErrors
+	for name, tunnel := range c.Tunnels {
+		if !referenced[name] && !tunnel.Autostart {
+			notes = append(notes, ValidationError{
+				Field:   fmt.Sprintf("tunnels.%s", name),
+				Message: "is not in any group",
+			})
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Field < notes[j].Field })
+	return notes
+}
+
+// checkReadable reports why path can't be opened, or "" if it can (or is unset)
+func checkReadable(path string) string {
+	if path == "" {
+		return ""
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("'%s' does not exist", path)
+		}
+		return fmt.Sprintf("'%s' is not readable: %v", path, err)
+	}
+	f.Close()
+	return ""
+}
+
+// checkKeepAliveMethod reports a keepalive method that isn't one of the
+// supported ones, where empty means the default
+func checkKeepAliveMethod(method string) string {
+	switch method {
+	case "", KeepAliveGolang, KeepAliveOpenSSH, KeepAliveSession:
+		return ""
+	}
+	return fmt.Sprintf("must be '%s', '%s', or '%s', got '%s'", KeepAliveGolang, KeepAliveOpenSSH, KeepAliveSession, method)
+}
+
+// checkHostRef reports a host reference that neither bore nor SSH config defines
+func (c *Config) checkHostRef(host string, sshReader *SSHConfigReader) string {
+	if host == "" {
+		return ""
+	}
+	if c.IsKnownHost(host, sshReader) {
+		return ""
+	}
+	if IsHostSpec(host) {
+		if _, err := ParseHostSpec(host); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	return fmt.Sprintf("'%s' is not defined in bore hosts or ~/.ssh/config and will be dialed as a hostname", host)
+}
+
 func (c *Config) validateTunnel(name string, t Tunnel) ValidationErrors {
 	var errs ValidationErrors
 	prefix := fmt.Sprintf("tunnels.%s", name)
@@ -99,7 +335,52 @@ func (c *Config) validateTunnel(name string, t Tunnel) ValidationErrors {
 		})
 	}
 
-	// Host field in tunnel config is now optional - host is specified at runtime
+	// Host field in tunnel config is an optional default - --host overrides it at runtime,
+	// but autostart has no runtime flag to fall back on
+	if t.Autostart && t.Host == "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".host",
+			Message: "is required when autostart is enabled",
+		})
+	}
+
+	if t.AlertBytes < 0 {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".alert_bytes",
+			Message: "must be non-negative",
+		})
+	}
+
+	if err := ValidateHostField(t.LocalHost); err != "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".local_host",
+			Message: err,
+		})
+	}
+
+	if err := ValidateHostField(t.RemoteHost); err != "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_host",
+			Message: err,
+		})
+	}
+
+	if t.RemoteBind != "" {
+		if t.Type != TunnelTypeRemote {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".remote_bind",
+				Message: "is only supported for remote tunnels",
+			})
+		} else if err := ValidateHostField(t.RemoteBind); err != "" {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".remote_bind",
+				Message: err,
+			})
+		}
+	}
+
+	errs = append(errs, validateLazy(prefix, t)...)
+	errs = append(errs, validatePortRanges(prefix, t)...)
 
 	if t.LocalPort <= 0 || t.LocalPort > 65535 {
 		errs = append(errs, ValidationError{
@@ -108,7 +389,9 @@ func (c *Config) validateTunnel(name string, t Tunnel) ValidationErrors {
 		})
 	}
 
-	if t.RemotePort <= 0 || t.RemotePort > 65535 {
+	if t.RemoteSocket != "" {
+		errs = append(errs, validateRemoteSocket(prefix, t)...)
+	} else if t.RemotePort <= 0 || t.RemotePort > 65535 {
 		errs = append(errs, ValidationError{
 			Field:   prefix + ".remote_port",
 			Message: "must be between 1 and 65535",
@@ -118,6 +401,163 @@ func (c *Config) validateTunnel(name string, t Tunnel) ValidationErrors {
 	return errs
 }
 
+// validateLazy checks the connect-on-demand settings, which only make sense
+// for local tunnels, whose listener doesn't need the SSH connection
+func validateLazy(prefix string, t Tunnel) ValidationErrors {
+	var errs ValidationErrors
+	if t.Lazy && t.Type == TunnelTypeRemote {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".lazy",
+			Message: "is only supported for local tunnels",
+		})
+	}
+	if t.Lazy && t.Verify {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".verify",
+			Message: "can't be used with lazy, which doesn't connect until needed",
+		})
+	}
+	if t.IdleTimeout < 0 {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".idle_timeout",
+			Message: "must be non-negative",
+		})
+	} else if t.IdleTimeout > 0 && !t.Lazy {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".idle_timeout",
+			Message: "only applies to lazy tunnels",
+		})
+	}
+	return errs
+}
+
+// validatePortRanges checks that a range tunnel's local and remote ranges
+// parse and pair up port for port
+func validatePortRanges(prefix string, t Tunnel) ValidationErrors {
+	var errs ValidationErrors
+	if !t.IsRange() {
+		if t.RemotePortRange != "" {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".remote_port_range",
+				Message: "requires local_port_range",
+			})
+		}
+		return errs
+	}
+
+	local, err := ParsePortRange(t.LocalPortRange)
+	if err != nil {
+		return append(errs, ValidationError{
+			Field:   prefix + ".local_port_range",
+			Message: err.Error(),
+		})
+	}
+	if t.LocalPort != local.First {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".local_port",
+			Message: fmt.Sprintf("must be the first port of local_port_range (%d) if set", local.First),
+		})
+	}
+	if t.Lazy {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".local_port_range",
+			Message: "can't be used with lazy",
+		})
+	}
+	if t.RemoteSocket != "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".local_port_range",
+			Message: "can't be used with remote_socket",
+		})
+		return errs
+	}
+
+	if t.RemotePortRange == "" {
+		if remote := t.RemotePorts(); remote.Last > 65535 {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".remote_port",
+				Message: fmt.Sprintf("%d ports starting at %d run past 65535", local.Len(), remote.First),
+			})
+		}
+		return errs
+	}
+	remote, err := ParsePortRange(t.RemotePortRange)
+	if err != nil {
+		return append(errs, ValidationError{
+			Field:   prefix + ".remote_port_range",
+			Message: err.Error(),
+		})
+	}
+	if remote.Len() != local.Len() {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_port_range",
+			Message: fmt.Sprintf("has %d ports but local_port_range has %d", remote.Len(), local.Len()),
+		})
+	}
+	if t.RemotePort != remote.First {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_port",
+			Message: fmt.Sprintf("must be the first port of remote_port_range (%d) if set", remote.First),
+		})
+	}
+	return errs
+}
+
+// localPortField names the setting that gives a tunnel its local ports
+func localPortField(t Tunnel) string {
+	if t.IsRange() {
+		return "local_port_range"
+	}
+	return "local_port"
+}
+
+// validateRemoteSocket checks a tunnel that forwards to a Unix socket on the
+// server, which replaces remote_host and remote_port
+func validateRemoteSocket(prefix string, t Tunnel) ValidationErrors {
+	var errs ValidationErrors
+	if t.Type == TunnelTypeRemote {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_socket",
+			Message: "is only supported for local tunnels",
+		})
+	}
+	if !strings.HasPrefix(t.RemoteSocket, "/") {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_socket",
+			Message: fmt.Sprintf("'%s' must be an absolute path", t.RemoteSocket),
+		})
+	}
+	if t.RemoteHost != "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_host",
+			Message: "can't be set with remote_socket",
+		})
+	}
+	if t.RemotePort != 0 {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".remote_port",
+			Message: "can't be set with remote_socket",
+		})
+	}
+	return errs
+}
+
+// ValidateHostField checks that a tunnel host is a bare hostname or IP address.
+// An empty value is allowed since it defaults to localhost.
+func ValidateHostField(host string) string {
+	if host == "" {
+		return ""
+	}
+	if strings.ContainsAny(host, " \t/") {
+		return fmt.Sprintf("'%s' is not a valid hostname or IP address", host)
+	}
+	// Colons are only valid in IPv6 literals; anything else is likely host:port
+	if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+		return fmt.Sprintf("'%s' must be a hostname or IP address without a port", host)
+	}
+	return ""
+}
+
 func (c *Config) validateGroup(name string, g Group) ValidationErrors {
 	var errs ValidationErrors
 	prefix := fmt.Sprintf("groups.%s", name)
@@ -129,31 +569,90 @@ func (c *Config) validateGroup(name string, g Group) ValidationErrors {
 		})
 	}
 
-	for i, tunnelName := range g.Tunnels {
-		if _, ok := c.Tunnels[tunnelName]; !ok {
+	if g.Stagger < 0 {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".stagger",
+			Message: "must be non-negative",
+		})
+	}
+
+	if g.Autostart && g.Host == "" && g.NeedsHost() {
+		errs = append(errs, ValidationError{
+			Field:   prefix + ".host",
+			Message: "is required when autostart is enabled, unless every tunnel sets its own host",
+		})
+	}
+
+	for i, entry := range g.Tunnels {
+		member := ParseGroupMember(entry)
+		field := fmt.Sprintf("%s.tunnels[%d]", prefix, i)
+		if strings.Contains(entry, "@") && member.Host == "" {
 			errs = append(errs, ValidationError{
-				Field:   fmt.Sprintf("%s.tunnels[%d]", prefix, i),
-				Message: fmt.Sprintf("references unknown tunnel '%s'", tunnelName),
+				Field:   field,
+				Message: fmt.Sprintf("'%s' is missing a host after '@'", entry),
+			})
+		}
+		if _, ok := c.Tunnels[member.Tunnel]; !ok {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("references unknown tunnel '%s'", member.Tunnel),
 			})
 		}
 	}
 
+	errs = append(errs, c.validateGroupPorts(prefix, g)...)
+
 	return errs
 }
 
-// CheckPortConflicts checks for port conflicts between active tunnels and new tunnels
-func CheckPortConflicts(activeTunnels map[string]Tunnel, newTunnels map[string]Tunnel) error {
-	// Build a map of local ports in use
-	usedPorts := make(map[int]string)
-	for name, t := range activeTunnels {
-		usedPorts[t.LocalPort] = name
+// validateGroupPorts reports members of a group that would bind the same local
+// port, which enabling the group would otherwise only discover at runtime
+func (c *Config) validateGroupPorts(prefix string, g Group) ValidationErrors {
+	var errs ValidationErrors
+	ports := make(map[int]string)
+	for _, member := range g.Members() {
+		tunnel, ok := c.Tunnels[member.Tunnel]
+		if !ok {
+			continue
+		}
+		local := tunnel.LocalPorts()
+		var existing string
+		var port int
+		taken := false
+		for port = local.First; port <= local.Last; port++ {
+			if existing, taken = ports[port]; taken {
+				break
+			}
+		}
+		switch {
+		case !taken:
+			for port := local.First; port <= local.Last; port++ {
+				ports[port] = member.Tunnel
+			}
+		case existing == member.Tunnel:
+			errs = append(errs, ValidationError{
+				Field:   prefix,
+				Message: fmt.Sprintf("lists tunnel '%s' more than once, so its local port %d would be bound twice", member.Tunnel, port),
+			})
+		default:
+			errs = append(errs, ValidationError{
+				Field:   prefix,
+				Message: fmt.Sprintf("tunnels '%s' and '%s' both use local port %d", existing, member.Tunnel, port),
+			})
+		}
 	}
+	return errs
+}
 
+// CheckPortConflicts checks for port conflicts between active tunnels and new tunnels
+func CheckPortConflicts(activeTunnels map[string]Tunnel, newTunnels map[string]Tunnel) error {
 	// Check new tunnels for conflicts
 	for name, t := range newTunnels {
-		if existingName, ok := usedPorts[t.LocalPort]; ok {
-			return fmt.Errorf("port conflict: %d already used by tunnel '%s', cannot enable '%s'",
-				t.LocalPort, existingName, name)
+		for existingName, active := range activeTunnels {
+			if active.LocalPorts().Overlaps(t.LocalPorts()) {
+				return fmt.Errorf("port conflict: %s already used by tunnel '%s', cannot enable '%s'",
+					t.LocalPorts(), existingName, name)
+			}
 		}
 	}
 
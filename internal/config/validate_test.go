@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -60,6 +63,150 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_backoff",
 		},
+		{
+			name: "negative keepalive max missed",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					KeepAlive: KeepAliveConfig{
+						Interval:  30 * time.Second,
+						MaxMissed: -1,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_missed",
+		},
+		{
+			name: "unknown keepalive method",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					KeepAlive: KeepAliveConfig{Method: "ping"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "defaults.keep_alive.method",
+		},
+		{
+			name: "unknown host keepalive method",
+			config: &Config{
+				Defaults: DefaultConfig().Defaults,
+				Hosts:    map[string]Host{"bastion": {KeepAliveMethod: "ping"}},
+			},
+			wantErr: true,
+			errMsg:  "hosts.bastion.keep_alive_method",
+		},
+		{
+			name: "invalid log format",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					LogFormat: "xml",
+				},
+			},
+			wantErr: true,
+			errMsg:  "log_format",
+		},
+		{
+			name: "constant backoff ignores multiplier",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Strategy:       "constant",
+						InitialBackoff: 5 * time.Second,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown backoff strategy",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Strategy:       "linear",
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "strategy",
+		},
+		{
+			name: "health address without port",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					HealthAddr: "localhost",
+				},
+			},
+			wantErr: true,
+			errMsg:  "health_addr",
+		},
+		{
+			name: "negative max rate",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					MaxRate: -1,
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_rate",
+		},
+		{
+			name: "negative dns timeout",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					DNSTimeout: -time.Second,
+				},
+			},
+			wantErr: true,
+			errMsg:  "dns_timeout",
+		},
+		{
+			name: "negative max connection age",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+					MaxConnectionAge: -time.Hour,
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_connection_age",
+		},
 		{
 			name: "tunnel with invalid type",
 			config: &Config{
@@ -124,6 +271,125 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "local_port",
 		},
+		{
+			name: "remote tunnel forwarding to another host is valid",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeRemote,
+						LocalHost:  "10.0.0.5",
+						LocalPort:  3000,
+						RemotePort: 9000,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tunnel local host with port",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeRemote,
+						LocalHost:  "10.0.0.5:3000",
+						LocalPort:  3000,
+						RemotePort: 9000,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "local_host",
+		},
+		{
+			name: "autostart tunnel without host",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  8080,
+						RemotePort: 80,
+						Autostart:  true,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "required when autostart",
+		},
+		{
+			name: "group with negative stagger",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  8080,
+						RemotePort: 80,
+					},
+				},
+				Groups: map[string]Group{
+					"dev": {
+						Tunnels: []string{"test"},
+						Stagger: -time.Second,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "stagger",
+		},
+		{
+			name: "autostart group without host",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  8080,
+						RemotePort: 80,
+					},
+				},
+				Groups: map[string]Group{
+					"dev": {
+						Autostart: true,
+						Tunnels:   []string{"test"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "required when autostart",
+		},
 		{
 			name: "group with unknown tunnel",
 			config: &Config{
@@ -145,6 +411,63 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "unknown tunnel",
 		},
+		{
+			name: "group member with empty host",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"test": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  8080,
+						RemotePort: 80,
+					},
+				},
+				Groups: map[string]Group{
+					"dev": {
+						Tunnels: []string{"test@"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "missing a host",
+		},
+		{
+			name: "autostart group whose tunnels all set a host",
+			config: &Config{
+				Defaults: Defaults{
+					Reconnect: ReconnectConfig{
+						Multiplier:     2.0,
+						InitialBackoff: 1 * time.Second,
+						MaxBackoff:     30 * time.Second,
+					},
+				},
+				Tunnels: map[string]Tunnel{
+					"web": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  8080,
+						RemotePort: 80,
+					},
+					"db": {
+						Type:       TunnelTypeLocal,
+						LocalPort:  5432,
+						RemotePort: 5432,
+					},
+				},
+				Groups: map[string]Group{
+					"dev": {
+						Autostart: true,
+						Tunnels:   []string{"web@bastion-east", "db@bastion-west"},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "group with empty tunnels",
 			config: &Config{
@@ -212,6 +535,163 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateRemoteSocket(t *testing.T) {
+	tests := []struct {
+		name       string
+		tunnel     Tunnel
+		wantFields []string
+	}{
+		{"local tunnel", Tunnel{Type: TunnelTypeLocal, LocalPort: 2375, RemoteSocket: "/var/run/docker.sock"}, nil},
+		{"remote tunnel", Tunnel{Type: TunnelTypeRemote, LocalPort: 2375, RemoteSocket: "/var/run/docker.sock"}, []string{"remote_socket"}},
+		{"relative path", Tunnel{Type: TunnelTypeLocal, LocalPort: 2375, RemoteSocket: "docker.sock"}, []string{"remote_socket"}},
+		{"with remote port", Tunnel{Type: TunnelTypeLocal, LocalPort: 2375, RemoteSocket: "/var/run/docker.sock", RemotePort: 2375}, []string{"remote_port"}},
+		{"with remote host", Tunnel{Type: TunnelTypeLocal, LocalPort: 2375, RemoteSocket: "/var/run/docker.sock", RemoteHost: "db"}, []string{"remote_host"}},
+		{"neither socket nor port", Tunnel{Type: TunnelTypeLocal, LocalPort: 2375}, []string{"remote_port"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			var fields []string
+			for _, err := range cfg.validateTunnel("docker", tt.tunnel) {
+				fields = append(fields, strings.TrimPrefix(err.Field, "tunnels.docker."))
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidateRemoteBind(t *testing.T) {
+	tests := []struct {
+		name       string
+		tunnel     Tunnel
+		wantFields []string
+	}{
+		{"default", Tunnel{Type: TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000}, nil},
+		{"all interfaces", Tunnel{Type: TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000, RemoteBind: "0.0.0.0"}, nil},
+		{"hostname", Tunnel{Type: TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000, RemoteBind: "gateway.internal"}, nil},
+		{"invalid address", Tunnel{Type: TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000, RemoteBind: "host:9000"}, []string{"remote_bind"}},
+		{"local tunnel", Tunnel{Type: TunnelTypeLocal, LocalPort: 3000, RemotePort: 9000, RemoteBind: "0.0.0.0"}, []string{"remote_bind"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			var fields []string
+			for _, err := range cfg.validateTunnel("dev", tt.tunnel) {
+				fields = append(fields, strings.TrimPrefix(err.Field, "tunnels.dev."))
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidateLazy(t *testing.T) {
+	tests := []struct {
+		name       string
+		tunnel     Tunnel
+		wantFields []string
+	}{
+		{"lazy local tunnel", Tunnel{Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432, Lazy: true, IdleTimeout: time.Minute}, nil},
+		{"lazy remote tunnel", Tunnel{Type: TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000, Lazy: true}, []string{"lazy"}},
+		{"lazy with verify", Tunnel{Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432, Lazy: true, Verify: true}, []string{"verify"}},
+		{"negative idle timeout", Tunnel{Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432, Lazy: true, IdleTimeout: -time.Second}, []string{"idle_timeout"}},
+		{"idle timeout without lazy", Tunnel{Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432, IdleTimeout: time.Minute}, []string{"idle_timeout"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			var fields []string
+			for _, err := range cfg.validateTunnel("db", tt.tunnel) {
+				fields = append(fields, strings.TrimPrefix(err.Field, "tunnels.db."))
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidatePortRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		tunnel     Tunnel
+		wantFields []string
+	}{
+		{"same ports", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePort: 8000}, nil},
+		{"shifted remote ports", Tunnel{Type: TunnelTypeRemote, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePortRange: "9000-9010", RemotePort: 9000}, nil},
+		{"remote ports from remote_port", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePort: 9000}, nil},
+		{"unequal sizes", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePortRange: "9000-9005", RemotePort: 9000}, []string{"remote_port_range"}},
+		{"invalid range", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8010-8000", LocalPort: 8010, RemotePort: 8010}, []string{"local_port_range"}},
+		{"local_port outside range", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 7000, RemotePort: 8000}, []string{"local_port"}},
+		{"remote ports past 65535", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePort: 65530}, []string{"remote_port"}},
+		{"remote range without local range", Tunnel{Type: TunnelTypeLocal, LocalPort: 8000, RemotePortRange: "9000-9010", RemotePort: 9000}, []string{"remote_port_range"}},
+		{"lazy", Tunnel{Type: TunnelTypeLocal, LocalPortRange: "8000-8010", LocalPort: 8000, RemotePort: 8000, Lazy: true}, []string{"local_port_range"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			var fields []string
+			for _, err := range cfg.validateTunnel("rtp", tt.tunnel) {
+				fields = append(fields, strings.TrimPrefix(err.Field, "tunnels.rtp."))
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidateGroupPorts(t *testing.T) {
+	cfg := &Config{
+		Tunnels: map[string]Tunnel{
+			"web":   {Type: TunnelTypeLocal, LocalPort: 8080, RemotePort: 80},
+			"admin": {Type: TunnelTypeLocal, LocalPort: 8080, RemotePort: 8000},
+			"db":    {Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432},
+			"rtp":   {Type: TunnelTypeLocal, LocalPortRange: "8070-8090", LocalPort: 8070, RemotePort: 8070},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		tunnels []string
+		wantMsg string // "" for no error
+	}{
+		{"distinct ports", []string{"web", "db"}, ""},
+		{"two tunnels share a port", []string{"web", "db", "admin"}, "tunnels 'web' and 'admin' both use local port 8080"},
+		{"same tunnel via two hosts", []string{"db@east", "db@west"}, "lists tunnel 'db' more than once"},
+		{"port range covers a port", []string{"web", "rtp"}, "tunnels 'web' and 'rtp' both use local port 8080"},
+		{"unknown tunnel is reported elsewhere", []string{"web", "missing"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := cfg.validateGroupPorts("groups.dev", Group{Tunnels: tt.tunnels})
+			if tt.wantMsg == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected one error, got %v", errs)
+			}
+			if errs[0].Field != "groups.dev" {
+				t.Errorf("expected field groups.dev, got %s", errs[0].Field)
+			}
+			if !strings.Contains(errs[0].Message, tt.wantMsg) {
+				t.Errorf("expected message containing %q, got %q", tt.wantMsg, errs[0].Message)
+			}
+		})
+	}
+}
+
 func TestCheckPortConflicts(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -251,3 +731,67 @@ func TestCheckPortConflicts(t *testing.T) {
 		})
 	}
 }
+
+func TestWarnings(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	missingPath := filepath.Join(dir, "missing")
+
+	reader := newTestSSHReader(t, `
+Host jump
+  HostName jump.example.com
+
+Host *
+  User admin
+`)
+
+	cfg := DefaultConfig()
+	cfg.Hosts["good"] = Host{Hostname: "good.example.com", IdentityFile: keyPath}
+	cfg.Hosts["bad"] = Host{Hostname: "bad.example.com", IdentityFile: missingPath, CertFile: missingPath}
+	cfg.Tunnels["db"] = Tunnel{Type: TunnelTypeLocal, Host: "good", LocalPort: 5432, RemotePort: 5432, FallbackHosts: []string{"jump", "lost"}}
+	cfg.Tunnels["web"] = Tunnel{Type: TunnelTypeLocal, Host: "jump", LocalPort: 8080, RemotePort: 80}
+	cfg.Tunnels["api"] = Tunnel{Type: TunnelTypeLocal, Host: "unknown", LocalPort: 9000, RemotePort: 9000}
+	cfg.Groups["dev"] = Group{Host: "nowhere", Tunnels: []string{"db"}, FallbackHosts: []string{"good", "gone"}}
+
+	var got []string
+	for _, w := range cfg.Warnings(reader) {
+		got = append(got, w.Field)
+	}
+	want := []string{
+		"groups.dev.fallback_hosts[1]",
+		"groups.dev.host",
+		"hosts.bad.cert_file",
+		"hosts.bad.identity_file",
+		"tunnels.api.host",
+		"tunnels.db.fallback_hosts[1]",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected warnings %v, got %v", want, got)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tunnels["db"] = Tunnel{Type: TunnelTypeLocal, LocalPort: 5432, RemotePort: 5432}
+	cfg.Tunnels["web"] = Tunnel{Type: TunnelTypeLocal, LocalPort: 8080, RemotePort: 80}
+	cfg.Tunnels["old"] = Tunnel{Type: TunnelTypeLocal, LocalPort: 9000, RemotePort: 9000}
+	cfg.Tunnels["metrics"] = Tunnel{Type: TunnelTypeLocal, LocalPort: 9090, RemotePort: 9090, Autostart: true, Host: "bastion"}
+
+	// Without groups there's nothing to be missing from
+	if notes := cfg.Notes(); len(notes) != 0 {
+		t.Errorf("expected no notes without groups, got %v", notes)
+	}
+
+	cfg.Groups["dev"] = Group{Tunnels: []string{"db", "web@bastion"}}
+	var got []string
+	for _, n := range cfg.Notes() {
+		got = append(got, n.Error())
+	}
+	want := []string{"tunnels.old: is not in any group"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected notes %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// trafficCheckInterval is how often tunnel traffic is compared against alert_bytes
+const trafficCheckInterval = 10 * time.Second
+
+// byteAlerts remembers which tunnel runs have already crossed their alert_bytes
+// threshold so that each run warns only once
+type byteAlerts struct {
+	mu      sync.Mutex
+	alerted map[string]time.Time // tunnel name -> stats start time of the run that alerted
+}
+
+func newByteAlerts() *byteAlerts {
+	return &byteAlerts{
+		alerted: make(map[string]time.Time),
+	}
+}
+
+// crossed reports whether a tunnel has newly crossed its alert_bytes threshold.
+// A restarted tunnel has fresh stats with a new start time, which re-arms the alert.
+func (a *byteAlerts) crossed(info tunnel.Info) bool {
+	threshold := info.Config.AlertBytes
+	if threshold <= 0 || info.Stats.TotalBytes() < threshold {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if started, ok := a.alerted[info.Name]; ok && started.Equal(info.Stats.StartTime) {
+		return false
+	}
+	a.alerted[info.Name] = info.Stats.StartTime
+	return true
+}
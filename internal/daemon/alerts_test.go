@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestByteAlertsCrossed(t *testing.T) {
+	a := newByteAlerts()
+	start := time.Now()
+	info := func(total int64, started time.Time) tunnel.Info {
+		return tunnel.Info{
+			Name:   "db",
+			Config: config.Tunnel{AlertBytes: 1000},
+			Stats:  tunnel.StatsSnapshot{BytesSent: total, StartTime: started},
+		}
+	}
+
+	if a.crossed(info(999, start)) {
+		t.Error("expected no alert below threshold")
+	}
+	if !a.crossed(info(1000, start)) {
+		t.Error("expected alert at threshold")
+	}
+	if a.crossed(info(5000, start)) {
+		t.Error("expected alert only once per run")
+	}
+	if !a.crossed(info(1500, start.Add(time.Minute))) {
+		t.Error("expected alert to re-arm after the tunnel restarts")
+	}
+
+	disabled := tunnel.Info{Name: "web", Stats: tunnel.StatsSnapshot{BytesSent: 1 << 30}}
+	if a.crossed(disabled) {
+		t.Error("expected no alert without a threshold")
+	}
+}
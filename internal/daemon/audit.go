@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+// Audit log events
+const (
+	AuditDaemonStart   = "daemon_start"
+	AuditDaemonStop    = "daemon_stop"
+	AuditTunnelUp      = "tunnel_up"
+	AuditTunnelDown    = "tunnel_down"
+	AuditTunnelRestart = "tunnel_restart"
+	AuditTunnelPause   = "tunnel_pause"
+	AuditTunnelResume  = "tunnel_resume"
+	AuditTunnelKill    = "tunnel_kill"
+	AuditGroupEnable   = "group_enable"
+	AuditGroupDisable  = "group_disable"
+	AuditConfigReload  = "config_reload"
+	AuditDenied        = "request_denied"
+)
+
+// AuditEntry is one line of the audit log. UID and PID identify the process
+// that made the request, when the platform can report them; Signal is set
+// instead for actions triggered by a signal.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Request string    `json:"request,omitempty"`
+	Tunnel  string    `json:"tunnel,omitempty"`
+	Group   string    `json:"group,omitempty"`
+	Host    string    `json:"host,omitempty"`
+	UID     *int      `json:"uid,omitempty"`
+	PID     *int      `json:"pid,omitempty"`
+	Signal  string    `json:"signal,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ParseAuditEntry parses one line of the audit log
+func ParseAuditEntry(line string) (AuditEntry, bool) {
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Event == "" {
+		return AuditEntry{}, false
+	}
+	return entry, true
+}
+
+// Text renders the entry as a single line for bore audit
+func (e AuditEntry) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-14s", e.Time.Local().Format(textTimeLayout), e.Event)
+	field := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, " %s=%s", key, value)
+		}
+	}
+	field("request", e.Request)
+	field("tunnel", e.Tunnel)
+	field("group", e.Group)
+	field("host", e.Host)
+	if e.UID != nil {
+		field("uid", fmt.Sprint(*e.UID))
+	}
+	if e.PID != nil {
+		field("pid", fmt.Sprint(*e.PID))
+	}
+	field("signal", e.Signal)
+	if e.Error != "" {
+		field("error", fmt.Sprintf("%q", e.Error))
+	}
+	return b.String()
+}
+
+// auditLog appends entries to the audit log, one JSON object per line. A nil
+// auditLog discards entries.
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// openAuditLog opens the audit log file for appending
+func openAuditLog() (*auditLog, error) {
+	path, err := ipc.AuditPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{w: file}, nil
+}
+
+// record writes entry, stamping it with the current time
+func (a *auditLog) record(entry AuditEntry) error {
+	if a == nil {
+		return nil
+	}
+	entry.Time = time.Now().UTC()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// audit records entry, logging rather than failing if it can't be written
+func (d *Daemon) audit(entry AuditEntry) {
+	if err := d.auditLog.record(entry); err != nil {
+		d.logger.Errorf("%v", err)
+	}
+}
+
+// auditRequest records a client request and its outcome
+func (d *Daemon) auditRequest(entry AuditEntry, caller Caller, resp ipc.Response) {
+	entry.UID = caller.uidPtr()
+	entry.PID = caller.pidPtr()
+	if !resp.Success {
+		entry.Error = resp.Error
+	}
+	d.audit(entry)
+}
@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+func TestAuditLogRecord(t *testing.T) {
+	var buf bytes.Buffer
+	d := &Daemon{auditLog: &auditLog{w: &buf}}
+
+	d.auditRequest(AuditEntry{Event: AuditTunnelUp, Tunnel: "web", Host: "bastion"}, Caller{UID: 501}, ipc.Response{Success: true})
+	d.auditRequest(AuditEntry{Event: AuditGroupDisable, Group: "dev"}, unknownCaller, ipc.Response{Error: "group 'dev' not found"})
+	d.audit(AuditEntry{Event: AuditDaemonStop, Signal: "terminated"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 audit lines, got %d: %q", len(lines), buf.String())
+	}
+
+	up, ok := ParseAuditEntry(lines[0])
+	if !ok {
+		t.Fatalf("failed to parse %q", lines[0])
+	}
+	if up.Event != AuditTunnelUp || up.Tunnel != "web" || up.Host != "bastion" || up.UID == nil || *up.UID != 501 || up.Time.IsZero() {
+		t.Errorf("unexpected tunnel_up entry: %+v", up)
+	}
+
+	disable, _ := ParseAuditEntry(lines[1])
+	if disable.UID != nil {
+		t.Errorf("expected no uid for an unknown caller, got %d", *disable.UID)
+	}
+	if disable.Error != "group 'dev' not found" {
+		t.Errorf("expected the failure to be recorded, got %q", disable.Error)
+	}
+
+	stop, _ := ParseAuditEntry(lines[2])
+	if text := stop.Text(); !strings.Contains(text, "daemon_stop") || !strings.Contains(text, "signal=terminated") {
+		t.Errorf("unexpected text rendering %q", text)
+	}
+}
+
+func TestAuditLogNilDiscards(t *testing.T) {
+	var a *auditLog
+	if err := a.record(AuditEntry{Event: AuditDaemonStart}); err != nil {
+		t.Errorf("expected a nil audit log to discard entries, got %v", err)
+	}
+}
+
+func TestParseAuditEntryRejectsOtherLines(t *testing.T) {
+	for _, line := range []string{"", "not json", `{"level":"info","msg":"hello"}`} {
+		if _, ok := ParseAuditEntry(line); ok {
+			t.Errorf("expected %q not to parse as an audit entry", line)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"os"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// configWatchInterval is how often the config file is checked for changes
+const configWatchInterval = 2 * time.Second
+
+// fileStamp identifies a version of a file well enough to notice edits
+// without reading it. A missing file has the zero stamp.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stampFile returns the current stamp of the file at path
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// watchConfig refreshes the manager's cached config when the config file
+// changes, so new tunnel operations see edits. Running tunnels are left
+// alone; applying changes to them is what reload is for.
+func (d *Daemon) watchConfig() {
+	path, err := config.ConfigPath()
+	if err != nil {
+		d.logger.Warnf("failed to watch config file: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	last := stampFile(path)
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			last = d.refreshConfigIfChanged(path, last)
+		}
+	}
+}
+
+// refreshConfigIfChanged reloads the cached config if the file's stamp differs
+// from last, and returns the stamp to compare against next time
+func (d *Daemon) refreshConfigIfChanged(path string, last fileStamp) fileStamp {
+	stamp := stampFile(path)
+	if stamp == last {
+		return last
+	}
+	if _, err := d.manager.LoadConfig(); err != nil {
+		d.logger.Warnf("Config file changed but has errors: %v", err)
+	} else {
+		d.logger.Debugf("Config file changed; refreshed cached config")
+	}
+	return stamp
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestRefreshConfigIfChanged(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	path := filepath.Join(home, ".bore", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	d := &Daemon{manager: manager, logger: NewLogger(io.Discard, LogFormatText)}
+
+	write("tunnels:\n  web:\n    type: local\n    local_port: 8080\n    remote_port: 80\n")
+	if _, err := manager.Config(); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	stamp := stampFile(path)
+
+	// An unchanged file isn't reread
+	if got := d.refreshConfigIfChanged(path, stamp); got != stamp {
+		t.Errorf("expected the stamp to stay the same, got %+v", got)
+	}
+
+	write("tunnels:\n  db:\n    type: local\n    local_port: 5432\n    remote_port: 5432\n")
+	if got := d.refreshConfigIfChanged(path, stamp); got == stamp {
+		t.Error("expected a new stamp after the file changed")
+	}
+	cfg, err := manager.Config()
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if _, ok := cfg.GetTunnel("db"); !ok {
+		t.Error("expected the cached config to pick up the edit")
+	}
+}
@@ -3,33 +3,82 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/pjtatlow/bore/internal/config"
 	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/lib"
 	"github.com/pjtatlow/bore/internal/reconnect"
 	"github.com/pjtatlow/bore/internal/state"
 	"github.com/pjtatlow/bore/internal/tunnel"
+	"github.com/pjtatlow/bore/internal/version"
+	"github.com/pjtatlow/bore/pkg/bore"
 )
 
 // Daemon is the main daemon process
 type Daemon struct {
 	manager        *tunnel.Manager
+	tunnels        *bore.Manager // manager through the public API, for status and host defaults
 	server         *Server
 	state          *state.State
 	networkMonitor *reconnect.Monitor
 	ctx            context.Context
 	cancel         context.CancelFunc
-	logger         *log.Logger
+	ownerUID       int // only this user may send requests; -1 if unknown
+	logger         *Logger
+	auditLog       *auditLog
+	notifier       *notifier
+	byteAlerts     *byteAlerts
+	health         *http.Server // serves /healthz and /readyz; nil unless defaults.health_addr is set
+
+	reconnectMu  sync.Mutex
+	reconnecting map[string]bool // tunnels with a reconnect loop in flight
+	reconnectWG  sync.WaitGroup  // tracks reconnect loops so shutdown can wait for them
+	closing      bool            // set during shutdown; no new reconnect loops may start
+
+	reloadMu sync.Mutex // serializes config reloads from signals and IPC
+
+	wakeCheck atomic.Bool // set while connections are checked after a wake
+
+	appliedMu sync.Mutex
+	applied   *config.Config // config last applied at start or reload
+}
+
+// reconnectShutdownTimeout bounds how long shutdown waits for reconnect loops to exit
+const reconnectShutdownTimeout = 10 * time.Second
+
+// Option configures a Daemon
+type Option func(*options)
+
+type options struct {
+	logOutput io.Writer
+}
+
+// WithLogOutput sends daemon logs to w instead of the log file, e.g. stdout
+// when running in the foreground under a process supervisor
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.logOutput = w
+	}
 }
 
 // New creates a new daemon instance
-func New() (*Daemon, error) {
+func New(opts ...Option) (*Daemon, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	manager, err := tunnel.NewManager()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tunnel manager: %w", err)
@@ -41,22 +90,62 @@ func New() (*Daemon, error) {
 	}
 
 	// Set up logging
-	logPath, err := ipc.LogPath()
-	if err != nil {
-		return nil, err
+	logOutput := o.logOutput
+	if logOutput == nil {
+		logPath, err := ipc.LogPath()
+		if err != nil {
+			return nil, err
+		}
+		logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		logOutput = logFile
+	}
+	logFormat := LogFormatText
+	networkMonitor := reconnect.NewMonitor()
+	// Load the config once up front so a broken file is reported clearly here
+	// rather than as a failure of every tunnel operation
+	cfg, cfgErr := manager.LoadConfig()
+	if cfg != nil {
+		logFormat = cfg.Defaults.LogFormat
+		if cfg.Defaults.ProbeHosts {
+			networkMonitor.SetProbeTargets(manager.ProbeAddresses)
+		}
+		networkMonitor.SetPollInterval(cfg.Defaults.NetworkPoll)
+		networkMonitor.SetDNSTimeout(cfg.Defaults.DNSTimeout)
+	}
+	logger := NewLogger(logOutput, logFormat)
+	switch {
+	case cfg == nil:
+		logger.Errorf("Config can't be loaded, so no tunnels can start until it's fixed and reloaded with 'bore config reload': %v", cfgErr)
+	case cfgErr != nil:
+		logger.Errorf("Config is invalid; fix it and run 'bore config reload': %v", cfgErr)
 	}
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+
+	auditLog, err := openAuditLog()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return nil, err
 	}
-	logger := log.New(logFile, "", log.LstdFlags)
 
 	d := &Daemon{
 		manager:        manager,
+		tunnels:        (*bore.Manager)(lib.New(manager)),
 		state:          st,
-		networkMonitor: reconnect.NewMonitor(),
+		networkMonitor: networkMonitor,
+		ownerUID:       os.Getuid(),
 		logger:         logger,
+		auditLog:       auditLog,
+		notifier:       newNotifier(manager.Config),
+		byteAlerts:     newByteAlerts(),
+		reconnecting:   make(map[string]bool),
 	}
+	d.setAppliedConfig(cfg)
+	manager.SetOnHostDisconnect(d.onHostDisconnect)
+	manager.SetOnStatusChange(d.onStatusChange)
+	manager.SetConnLogger(d.connLoggerFor)
+	manager.SetWarnLogger(d.warnLoggerFor)
+	manager.SetHostWarnLogger(d.hostWarnLoggerFor)
 
 	server, err := NewServer(d)
 	if err != nil {
@@ -85,48 +174,89 @@ func (d *Daemon) Run() error {
 
 	// Start network monitor
 	if err := d.networkMonitor.Start(d.ctx); err != nil {
-		d.logger.Printf("Warning: failed to start network monitor: %v", err)
+		d.logger.Warnf("failed to start network monitor: %v", err)
 	}
 	d.networkMonitor.SetOnChange(d.onNetworkChange)
+	d.networkMonitor.SetOnWake(d.onWake)
+
+	// Serve health checks before restoring, so liveness holds while tunnels
+	// come up and readiness tracks them
+	if cfg, err := d.manager.Config(); err == nil && cfg.Defaults.HealthAddr != "" {
+		if err := d.startHealthServer(cfg.Defaults.HealthAddr); err != nil {
+			d.logger.Warnf("%v", err)
+		}
+	}
 
 	// Restore previous state
 	if err := d.restoreState(); err != nil {
-		d.logger.Printf("Warning: failed to restore state: %v", err)
+		d.logger.Warnf("failed to restore state: %v", err)
+	}
+
+	// Bring up anything marked autostart that wasn't already restored
+	if err := d.startAutostart(); err != nil {
+		d.logger.Warnf("failed to autostart tunnels: %v", err)
 	}
 
-	d.logger.Printf("Daemon started (PID %d)", os.Getpid())
+	go d.watchTraffic()
+	go d.watchConnectionAge()
+	go d.watchConfig()
+
+	d.logger.Infof("Daemon started (PID %d)", os.Getpid())
+	d.audit(AuditEntry{Event: AuditDaemonStart, UID: knownID(d.ownerUID), PID: knownID(os.Getpid())})
 
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-
-	// Wait for either signal or context cancellation
-	select {
-	case <-sigCh:
-		d.logger.Printf("Shutdown signal received")
-	case <-d.ctx.Done():
-		d.logger.Printf("Shutdown requested via IPC")
+	reloadCh := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(reloadCh, reloadSignals...)
 	}
 
-	return d.shutdown()
+	// Wait for a shutdown signal or context cancellation, reloading the
+	// config on request in the meantime
+	for {
+		select {
+		case sig := <-reloadCh:
+			d.logger.Infof("Reload signal received")
+			entry := AuditEntry{Event: AuditConfigReload, Signal: sig.String()}
+			if _, err := d.reloadConfig(); err != nil {
+				d.logger.Errorf("Failed to reload config: %v", err)
+				entry.Error = err.Error()
+			}
+			d.audit(entry)
+			continue
+		case sig := <-sigCh:
+			d.logger.Infof("Shutdown signal received")
+			d.audit(AuditEntry{Event: AuditDaemonStop, Signal: sig.String()})
+		case <-d.ctx.Done():
+			d.logger.Infof("Shutdown requested via IPC")
+		}
+		return d.shutdown()
+	}
 }
 
 // shutdown performs a graceful shutdown
 func (d *Daemon) shutdown() error {
 	d.cancel()
+	d.stopHealthServer()
+
+	// Let in-flight reconnects notice the cancellation before tearing down the manager
+	if !d.waitReconnects(reconnectShutdownTimeout) {
+		d.logger.Warnf("timed out waiting for reconnect loops to exit")
+	}
 
 	// Save state before stopping tunnels
 	if err := d.state.Save(); err != nil {
-		d.logger.Printf("Warning: failed to save state: %v", err)
+		d.logger.Warnf("failed to save state: %v", err)
 	}
 
 	// Stop all tunnels
-	if err := d.manager.StopAll(); err != nil {
-		d.logger.Printf("Warning: error stopping tunnels: %v", err)
+	if err := d.tunnels.Close(); err != nil {
+		d.logger.Warnf("error stopping tunnels: %v", err)
 	}
 
 	d.networkMonitor.Stop()
-	d.logger.Printf("Daemon stopped")
+	d.logger.Infof("Daemon stopped")
 
 	return nil
 }
@@ -137,34 +267,224 @@ func (d *Daemon) restoreState() error {
 		return err
 	}
 
-	// Restore groups first (they may contain tunnels)
+	// Restore groups first (they may contain tunnels). Entries that fail to
+	// start are retried, kept, or pruned; see restoreActionFor.
 	for _, gs := range d.state.GetActiveGroups() {
-		if err := d.manager.StartGroup(d.ctx, gs.Name, gs.Host); err != nil {
-			d.logger.Printf("Failed to restore group '%s': %v", gs.Name, err)
-		} else {
-			d.logger.Printf("Restored group '%s' via host '%s'", gs.Name, gs.Host)
-		}
+		d.restoreEntry(d.savedGroup(gs.Name, gs.Host))
 	}
 
 	// Restore individual tunnels
 	for _, ts := range d.state.GetActiveTunnels() {
-		if err := d.manager.StartTunnel(d.ctx, ts.Name, ts.Host); err != nil {
-			d.logger.Printf("Failed to restore tunnel '%s': %v", ts.Name, err)
-		} else {
-			d.logger.Printf("Restored tunnel '%s' via host '%s'", ts.Name, ts.Host)
-		}
+		d.restoreEntry(d.savedTunnel(ts.Name, ts.Host))
 	}
 
 	return nil
 }
 
+// startAutostart starts autostart groups and tunnels from the config that
+// aren't already running, using their configured host
+func (d *Daemon) startAutostart() error {
+	cfg, err := d.manager.Config()
+	if err != nil {
+		return err
+	}
+
+	activeGroups := make(map[string]bool)
+	for _, gs := range d.state.GetActiveGroups() {
+		activeGroups[gs.Name] = true
+	}
+
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	for _, name := range groupNames {
+		group := cfg.Groups[name]
+		if !group.Autostart || activeGroups[name] {
+			continue
+		}
+		if group.Host == "" && group.NeedsHost() {
+			d.logger.Warnf("Skipping autostart for group '%s': no host configured", name)
+			continue
+		}
+		if err := d.manager.StartGroup(d.ctx, name, group.Host); err != nil {
+			d.logger.WithHost(group.Host).Errorf("Failed to autostart group '%s': %v", name, err)
+			continue
+		}
+		d.state.AddGroup(name, group.Host)
+		d.logger.WithHost(group.Host).Infof("Autostarted group '%s' via host '%s'", name, group.Host)
+	}
+
+	tunnelNames := make([]string, 0, len(cfg.Tunnels))
+	for name := range cfg.Tunnels {
+		tunnelNames = append(tunnelNames, name)
+	}
+	sort.Strings(tunnelNames)
+
+	for _, name := range tunnelNames {
+		tun := cfg.Tunnels[name]
+		// Skip tunnels already running, whether restored directly or via a group
+		if !tun.Autostart || d.manager.GetTunnelHost(name) != "" {
+			continue
+		}
+		if tun.Host == "" {
+			d.logger.WithTunnel(name).Warnf("Skipping autostart for tunnel '%s': no host configured", name)
+			continue
+		}
+		if err := d.manager.StartTunnel(d.ctx, name, tun.Host); err != nil {
+			d.logger.WithTunnel(name).WithHost(tun.Host).Errorf("Failed to autostart tunnel '%s': %v", name, err)
+			continue
+		}
+		d.state.AddTunnel(name, tun.Host)
+		d.logger.WithTunnel(name).WithHost(tun.Host).Infof("Autostarted tunnel '%s' via host '%s'", name, tun.Host)
+	}
+
+	return d.state.Save()
+}
+
 // onNetworkChange handles network status changes
 func (d *Daemon) onNetworkChange(status reconnect.NetworkStatus) {
 	if status == reconnect.NetworkAvailable {
-		d.logger.Printf("Network restored, reconnecting tunnels...")
+		d.logger.Infof("Network restored, reconnecting tunnels...")
 		d.reconnectAllTunnels()
 	} else {
-		d.logger.Printf("Network unavailable")
+		d.logger.Warnf("Network unavailable")
+	}
+}
+
+// onWake checks every SSH connection straight after the system wakes from
+// sleep. Connections often die during sleep without either side noticing, and
+// would otherwise look healthy until the next keepalive fails. A failed check
+// drops the connection, which reconnects its tunnels.
+func (d *Daemon) onWake(slept time.Duration) {
+	if !d.wakeCheck.CompareAndSwap(false, true) {
+		return
+	}
+	if slept > 0 {
+		d.logger.Infof("System woke after sleeping about %s, checking connections", slept.Truncate(time.Second))
+	} else {
+		d.logger.Debugf("Network re-reported, checking connections")
+	}
+
+	go func() {
+		defer d.wakeCheck.Store(false)
+		d.manager.CheckHealth()
+	}()
+}
+
+// connLoggerFor returns the connection logger for a tunnel, or nil when
+// connection logging is disabled
+func (d *Daemon) connLoggerFor(tunnelName string) tunnel.ConnLogger {
+	cfg, err := d.manager.Config()
+	if err != nil || !cfg.Defaults.LogConnections {
+		return nil
+	}
+	return d.logger.WithTunnel(tunnelName)
+}
+
+// warnLoggerFor returns the logger for a tunnel's warnings
+func (d *Daemon) warnLoggerFor(tunnelName string) tunnel.WarnLogger {
+	return d.logger.WithTunnel(tunnelName)
+}
+
+// hostWarnLoggerFor returns the logger for an SSH host's warnings
+func (d *Daemon) hostWarnLoggerFor(hostName string) tunnel.WarnLogger {
+	return d.logger.WithHost(hostName)
+}
+
+// onStatusChange is registered with the tunnel manager. It may be called while
+// the manager holds its lock, so it must never block.
+func (d *Daemon) onStatusChange(name string, status tunnel.Status, err error) {
+	d.notifier.onStatusChange(name, status, err)
+
+	// A tunnel whose listener broke, or whose remote port the server never
+	// released after a reconnect, won't recover on its own; rebuild it
+	switch {
+	case status != tunnel.StatusError:
+	case errors.Is(err, tunnel.ErrAcceptFailed):
+		d.logger.WithTunnel(name).Warnf("Tunnel '%s' stopped accepting connections: %v", name, err)
+		go d.reconnectTunnelWithBackoff(name)
+	case errors.Is(err, tunnel.ErrRemotePortBusy):
+		d.logger.WithTunnel(name).Warnf("Tunnel '%s' couldn't bind its remote port: %v", name, err)
+		go d.reconnectTunnelWithBackoff(name)
+	}
+}
+
+// watchTraffic periodically checks tunnels against their alert_bytes thresholds
+func (d *Daemon) watchTraffic() {
+	ticker := time.NewTicker(trafficCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.checkByteAlerts(d.manager.GetAllTunnelInfo())
+		}
+	}
+}
+
+// connectionAgeCheckInterval is how often SSH connections are checked
+// against defaults.max_connection_age
+const connectionAgeCheckInterval = time.Minute
+
+// watchConnectionAge periodically replaces SSH connections older than
+// defaults.max_connection_age, picking up changes to it without a reload
+func (d *Daemon) watchConnectionAge() {
+	ticker := time.NewTicker(connectionAgeCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			cfg, err := d.manager.Config()
+			if err != nil || cfg.Defaults.MaxConnectionAge <= 0 {
+				continue
+			}
+			d.recycleConnections(cfg.Defaults.MaxConnectionAge)
+		}
+	}
+}
+
+// recycleConnections replaces SSH connections up for at least maxAge and
+// retries any tunnel that didn't come back on the new connection
+func (d *Daemon) recycleConnections(maxAge time.Duration) {
+	for _, r := range d.manager.RecycleAgedClients(d.ctx, maxAge) {
+		logger := d.logger.WithHost(r.Host)
+		logger.Infof("Recycled SSH connection to '%s' after %s, restarting %d tunnel(s)", r.Host, r.Age.Truncate(time.Second), len(r.Tunnels)+len(r.Failed))
+		for name, err := range r.Failed {
+			logger.WithTunnel(name).Warnf("Tunnel '%s' failed to restart on the new connection: %v", name, err)
+			go d.reconnectTunnelWithBackoff(name)
+		}
+	}
+}
+
+// checkByteAlerts warns about tunnels whose traffic has crossed alert_bytes
+func (d *Daemon) checkByteAlerts(infos []tunnel.Info) {
+	for _, info := range infos {
+		if !d.byteAlerts.crossed(info) {
+			continue
+		}
+		total := info.Stats.TotalBytes()
+		d.logger.WithTunnel(info.Name).Warnf("ALERT: tunnel '%s' has transferred %d bytes, exceeding its alert_bytes threshold of %d",
+			info.Name, total, info.Config.AlertBytes)
+		d.notifier.notify(
+			fmt.Sprintf("bore: tunnel '%s' traffic alert", info.Name),
+			fmt.Sprintf("Transferred %d bytes (threshold %d)", total, info.Config.AlertBytes),
+		)
+	}
+}
+
+// onHostDisconnect reconnects the tunnels that were using a host whose SSH connection dropped
+func (d *Daemon) onHostDisconnect(hostName string, tunnels []string) {
+	d.logger.WithHost(hostName).Warnf("SSH connection to host '%s' lost, affecting %d tunnel(s)", hostName, len(tunnels))
+	for _, name := range tunnels {
+		d.reconnectTunnelWithBackoff(name)
 	}
 }
 
@@ -182,21 +502,41 @@ func (d *Daemon) reconnectAllTunnels() {
 	}
 }
 
+// newBackoff returns the retry backoff described by the reconnect settings
+func newBackoff(rc config.ReconnectConfig) *reconnect.Backoff {
+	if rc.Strategy == reconnect.StrategyConstant {
+		return reconnect.NewConstantBackoff(rc.InitialBackoff)
+	}
+	return reconnect.NewBackoff(rc.InitialBackoff, rc.MaxBackoff, rc.Multiplier)
+}
+
 // reconnectTunnelWithBackoff attempts to reconnect a tunnel with exponential backoff
 func (d *Daemon) reconnectTunnelWithBackoff(name string) {
-	cfg, err := config.Load()
+	cfg, err := d.manager.Config()
 	if err != nil {
-		d.logger.Printf("Failed to load config for reconnect: %v", err)
+		d.logger.WithTunnel(name).Errorf("Failed to load config for reconnect: %v", err)
+		return
+	}
+
+	// Leave the tunnel in its error state for the user to restart
+	if !cfg.ReconnectEnabled(name) {
+		d.logger.WithTunnel(name).Infof("Reconnect is disabled for tunnel '%s', leaving it down", name)
 		return
 	}
 
-	backoff := reconnect.NewBackoff(
-		cfg.Defaults.Reconnect.InitialBackoff,
-		cfg.Defaults.Reconnect.MaxBackoff,
-		cfg.Defaults.Reconnect.Multiplier,
-	)
+	backoff := newBackoff(cfg.Defaults.Reconnect)
+
+	// Skip if a reconnect loop is already running for this tunnel
+	if !d.beginReconnect(name) {
+		return
+	}
+
+	logger := d.logger.WithTunnel(name).WithHost(d.manager.GetTunnelHost(name))
 
 	go func() {
+		defer d.endReconnect(name)
+		defer d.manager.SetNextRetry(name, time.Time{})
+
 		for {
 			select {
 			case <-d.ctx.Done():
@@ -204,6 +544,12 @@ func (d *Daemon) reconnectTunnelWithBackoff(name string) {
 			default:
 			}
 
+			// Give up if the tunnel was stopped while we were waiting
+			if _, ok := d.manager.GetTunnelInfo(name); !ok {
+				logger.Infof("Tunnel '%s' is no longer running, abandoning reconnect", name)
+				return
+			}
+
 			// Wait for network if unavailable
 			if !d.networkMonitor.IsAvailable() {
 				d.networkMonitor.WaitForNetwork(d.ctx)
@@ -212,26 +558,88 @@ func (d *Daemon) reconnectTunnelWithBackoff(name string) {
 
 			err := d.manager.ReconnectTunnel(d.ctx, name)
 			if err == nil {
-				d.logger.Printf("Reconnected tunnel '%s'", name)
+				if hosts, via := d.manager.GetTunnelHosts(name), d.manager.GetTunnelHost(name); len(hosts) > 0 && via != hosts[0] {
+					logger.Infof("Reconnected tunnel '%s' via fallback host '%s'", name, via)
+				} else {
+					logger.Infof("Reconnected tunnel '%s'", name)
+				}
+				return
+			}
+			if errors.Is(err, tunnel.ErrPaused) {
+				logger.Infof("Tunnel '%s' was paused, abandoning reconnect", name)
 				return
 			}
 
-			d.logger.Printf("Failed to reconnect tunnel '%s': %v", name, err)
+			logger.Warnf("Failed to reconnect tunnel '%s': %v", name, err)
 
 			wait := backoff.Next()
-			d.logger.Printf("Retrying tunnel '%s' in %v", name, wait)
+			logger.Infof("Retrying tunnel '%s' in %v", name, wait)
+			d.manager.SetNextRetry(name, time.Now().Add(wait))
 
 			select {
 			case <-d.ctx.Done():
 				return
 			case <-time.After(wait):
 			}
+			d.manager.SetNextRetry(name, time.Time{})
 		}
 	}()
 }
 
+// beginReconnect marks a tunnel as having a reconnect loop in flight.
+// It returns false if a loop is already running for the tunnel or the daemon
+// is shutting down. Each successful call must be paired with endReconnect.
+func (d *Daemon) beginReconnect(name string) bool {
+	d.reconnectMu.Lock()
+	defer d.reconnectMu.Unlock()
+
+	if d.closing || d.reconnecting[name] {
+		return false
+	}
+	d.reconnecting[name] = true
+	d.reconnectWG.Add(1)
+	return true
+}
+
+// endReconnect clears the in-flight reconnect flag for a tunnel
+func (d *Daemon) endReconnect(name string) {
+	d.reconnectMu.Lock()
+	defer d.reconnectMu.Unlock()
+
+	delete(d.reconnecting, name)
+	d.reconnectWG.Done()
+}
+
+// waitReconnects stops new reconnect loops from starting and waits for
+// running ones to exit. It returns false if they are still running after timeout.
+func (d *Daemon) waitReconnects(timeout time.Duration) bool {
+	d.reconnectMu.Lock()
+	d.closing = true
+	d.reconnectMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.reconnectWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // HandleRequest implements RequestHandler
-func (d *Daemon) HandleRequest(req ipc.Request) ipc.Response {
+func (d *Daemon) HandleRequest(req ipc.Request, caller Caller) ipc.Response {
+	if !caller.allowed(d.ownerUID) {
+		return d.denyRequest(req, caller)
+	}
+	if perr := ipc.ProtocolMismatch(req.Type, req.Version, ipc.ProtocolVersion); perr != nil {
+		return ipc.Response{Success: false, Error: perr.Message, ErrorCode: perr.Code}
+	}
+
 	switch req.Type {
 	case ipc.ReqPing:
 		return ipc.Response{Success: true}
@@ -239,7 +647,11 @@ func (d *Daemon) HandleRequest(req ipc.Request) ipc.Response {
 	case ipc.ReqStatus:
 		return d.handleStatus()
 
+	case ipc.ReqHostStatus:
+		return d.handleHostStatus()
+
 	case ipc.ReqStop:
+		d.auditRequest(AuditEntry{Event: AuditDaemonStop}, caller, ipc.Response{Success: true})
 		go func() {
 			time.Sleep(100 * time.Millisecond)
 			d.cancel()
@@ -247,51 +659,135 @@ func (d *Daemon) HandleRequest(req ipc.Request) ipc.Response {
 		return ipc.Response{Success: true}
 
 	case ipc.ReqTunnelUp:
-		return d.handleTunnelUp(req.Data)
+		return d.handleTunnelUp(req.Data, caller)
 
 	case ipc.ReqTunnelDown:
-		return d.handleTunnelDown(req.Data)
+		return d.handleTunnelDown(req.Data, caller)
+
+	case ipc.ReqTunnelRestart:
+		return d.handleTunnelRestart(req.Data, caller)
+
+	case ipc.ReqTunnelPause:
+		return d.handleTunnelPause(req.Data, caller)
+
+	case ipc.ReqTunnelResume:
+		return d.handleTunnelResume(req.Data, caller)
+
+	case ipc.ReqTunnelKill:
+		return d.handleTunnelKill(req.Data, caller)
 
 	case ipc.ReqGroupEnable:
-		return d.handleGroupEnable(req.Data)
+		return d.handleGroupEnable(req.Data, caller)
 
 	case ipc.ReqGroupDisable:
-		return d.handleGroupDisable(req.Data)
+		return d.handleGroupDisable(req.Data, caller)
+
+	case ipc.ReqReloadConfig:
+		return d.handleReloadConfig(caller)
+
+	case ipc.ReqCapabilities:
+		return ipc.Response{Success: true, Data: ipc.Capabilities()}
 
 	default:
 		return ipc.Response{
-			Success: false,
-			Error:   fmt.Sprintf("unknown request type: %s", req.Type),
+			Success:   false,
+			Error:     fmt.Sprintf("unknown request type: %s", req.Type),
+			ErrorCode: ipc.ErrCodeInvalidRequest,
 		}
 	}
 }
 
+// denyRequest rejects a request from a user other than the daemon's owner
+func (d *Daemon) denyRequest(req ipc.Request, caller Caller) ipc.Response {
+	d.logger.Warnf("Rejected %s request from uid %d (pid %d); only uid %d may use this daemon", req.Type, caller.UID, caller.PID, d.ownerUID)
+	resp := ipc.Response{
+		Success:   false,
+		Error:     fmt.Sprintf("permission denied: the daemon belongs to uid %d", d.ownerUID),
+		ErrorCode: ipc.ErrCodePermissionDenied,
+	}
+	d.auditRequest(AuditEntry{Event: AuditDenied, Request: req.Type}, caller, resp)
+	return resp
+}
+
 func (d *Daemon) handleStatus() ipc.Response {
 	// Check health of all SSH connections before reporting status
 	d.manager.CheckHealth()
 
 	tunnelInfos := d.manager.GetAllTunnelInfo()
-	tunnelStatuses := make([]ipc.TunnelStatus, 0, len(tunnelInfos))
-
+	d.checkByteAlerts(tunnelInfos)
+	infos := make(map[string]tunnel.Info, len(tunnelInfos))
 	for _, info := range tunnelInfos {
+		infos[info.Name] = info
+	}
+
+	// The public status covers the basics; the rest comes from the manager
+	statuses := d.tunnels.Status()
+	tunnelStatuses := make([]ipc.TunnelStatus, 0, len(statuses))
+	for _, st := range statuses {
+		info, ok := infos[st.Name]
+		if !ok {
+			continue // started since tunnelInfos was taken
+		}
 		uptime := ""
 		if info.Stats.Uptime > 0 {
 			uptime = info.Stats.Uptime.Truncate(time.Second).String()
 		}
+		var sendRate, recvRate *float64
+		if rate, ok := d.manager.SampleRate(info); ok {
+			sendRate, recvRate = &rate.Send, &rate.Recv
+		}
+		host := st.Host
+		var primary string
+		if hosts := d.manager.GetTunnelHosts(info.Name); len(hosts) > 0 && hosts[0] != host {
+			primary = hosts[0]
+		}
+		var rtt *float64
+		if hostRTT, ok := d.manager.GetHostRTT(host); ok {
+			rtt = millis(hostRTT)
+		}
+		var nextRetry *time.Time
+		if at, ok := d.manager.GetNextRetry(info.Name); ok {
+			nextRetry = &at
+		}
+		var lastReconnect *time.Time
+		if !info.LastReconnect.IsZero() {
+			lastReconnect = &info.LastReconnect
+		}
+		var localRange, remoteRange string
+		if info.Config.IsRange() {
+			localRange = info.Config.LocalPorts().String()
+			remoteRange = info.Config.RemotePorts().String()
+		}
 		tunnelStatuses = append(tunnelStatuses, ipc.TunnelStatus{
-			Name:           info.Name,
-			Type:           string(info.Config.Type),
-			Host:           d.manager.GetTunnelHost(info.Name),
-			LocalPort:      info.Config.LocalPort,
-			RemoteHost:     info.Config.RemoteHost,
-			RemotePort:     info.Config.RemotePort,
-			Status:         info.Status,
-			Error:          info.Error,
-			BytesSent:      info.Stats.BytesSent,
-			BytesReceived:  info.Stats.BytesReceived,
-			Connections:    info.Stats.Connections,
-			ReconnectCount: info.ReconnectCount,
-			Uptime:         uptime,
+			Name:             st.Name,
+			Type:             string(st.Type),
+			Host:             host,
+			Endpoint:         st.Endpoint,
+			PrimaryHost:      primary,
+			LocalHost:        info.Config.LocalHost,
+			LocalPort:        info.Config.LocalPort,
+			RemoteHost:       info.Config.RemoteHost,
+			RemotePort:       info.Config.RemotePort,
+			RemoteSocket:     info.Config.RemoteSocket,
+			RemoteBind:       remoteBind(info.Config),
+			LocalPortRange:   localRange,
+			RemotePortRange:  remoteRange,
+			Status:           tunnel.Status(st.Status),
+			Error:            st.Error,
+			BytesSent:        st.BytesSent,
+			BytesReceived:    st.BytesReceived,
+			Connections:      st.Connections,
+			ActiveConns:      st.ActiveConns,
+			PeakConns:        st.PeakConns,
+			AcceptErrors:     info.Stats.AcceptErrors,
+			ReconnectCount:   info.ReconnectCount,
+			RecentReconnects: info.RecentReconnects,
+			LastReconnect:    lastReconnect,
+			Uptime:           uptime,
+			SendRate:         sendRate,
+			RecvRate:         recvRate,
+			RTTMillis:        rtt,
+			NextRetry:        nextRetry,
 		})
 	}
 
@@ -301,11 +797,15 @@ func (d *Daemon) handleStatus() ipc.Response {
 		runningTunnels[name] = true
 	}
 
-	cfg, _ := config.Load()
 	groupStatuses := make([]ipc.GroupStatus, 0)
-	for name, group := range cfg.Groups {
+	var groups map[string]config.Group
+	if cfg, err := d.manager.Config(); err == nil {
+		groups = cfg.Groups
+	}
+	for name, group := range groups {
 		enabled := true
-		for _, tunnelName := range group.Tunnels {
+		tunnelNames := group.TunnelNames()
+		for _, tunnelName := range tunnelNames {
 			if !runningTunnels[tunnelName] {
 				enabled = false
 				break
@@ -315,7 +815,7 @@ func (d *Daemon) handleStatus() ipc.Response {
 			Name:        name,
 			Description: group.Description,
 			Enabled:     enabled,
-			Tunnels:     group.Tunnels,
+			Tunnels:     tunnelNames,
 		})
 	}
 
@@ -327,94 +827,342 @@ func (d *Daemon) handleStatus() ipc.Response {
 		networkStatus = "unavailable"
 	}
 
+	buildInfo := version.Get()
 	status := ipc.StatusResponse{
-		Running: true,
-		PID:     os.Getpid(),
-		Uptime:  d.state.Uptime().Truncate(time.Second).String(),
-		Tunnels: tunnelStatuses,
-		Groups:  groupStatuses,
-		Network: ipc.NetworkStatusInfo{Status: networkStatus},
+		Running:         true,
+		PID:             os.Getpid(),
+		Uptime:          d.state.Uptime().Truncate(time.Second).String(),
+		Version:         &buildInfo,
+		ProtocolVersion: ipc.ProtocolVersion,
+		Tunnels:         tunnelStatuses,
+		Groups:          groupStatuses,
+		Network:         ipc.NetworkStatusInfo{Status: networkStatus},
+		ConfigHash:      d.appliedConfigHash(),
+	}
+	if err := d.manager.ConfigError(); err != nil {
+		status.ConfigError = err.Error()
 	}
 
 	return ipc.Response{Success: true, Data: status}
 }
 
-func (d *Daemon) handleTunnelUp(data interface{}) ipc.Response {
+func (d *Daemon) handleHostStatus() ipc.Response {
+	// Check health of all SSH connections before reporting status
+	d.manager.CheckHealth()
+
+	hostInfos := d.manager.GetHostInfo()
+	hostStatuses := make([]ipc.HostStatus, 0, len(hostInfos))
+	for _, info := range hostInfos {
+		lastConnected := ""
+		if !info.ConnectedAt.IsZero() {
+			lastConnected = info.ConnectedAt.Format(time.RFC3339)
+		}
+		var rtt *float64
+		if info.RTT > 0 {
+			rtt = millis(info.RTT)
+		}
+		hostStatuses = append(hostStatuses, ipc.HostStatus{
+			Name:          info.Name,
+			Connected:     info.Connected,
+			Tunnels:       info.Tunnels,
+			LastConnected: lastConnected,
+			RTTMillis:     rtt,
+		})
+	}
+
+	return ipc.Response{Success: true, Data: ipc.HostStatusResponse{Hosts: hostStatuses}}
+}
+
+func (d *Daemon) handleTunnelUp(data interface{}, caller Caller) (resp ipc.Response) {
 	var req ipc.TunnelRequest
 	if err := decodeData(data, &req); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
 	}
 
-	if req.Host == "" {
-		return ipc.Response{Success: false, Error: "host is required"}
+	// Fall back to the tunnel's configured default host
+	host := req.Host
+	defer func() { d.auditRequest(AuditEntry{Event: AuditTunnelUp, Tunnel: req.Name, Host: host}, caller, resp) }()
+	if host == "" {
+		cfg, err := d.tunnels.Config()
+		if err != nil {
+			return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeConfig}
+		}
+		if host, err = cfg.TunnelHost(req.Name); err != nil {
+			return hostErrorResponse(err, "tunnel")
+		}
 	}
 
-	if err := d.manager.StartTunnel(d.ctx, req.Name, req.Host); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+	override := tunnel.RemoteOverride{Host: req.RemoteHost, Port: req.RemotePort}
+	// Compare against the host the tunnel was started via, which it may be
+	// reaching through a fallback
+	var current string
+	if hosts := d.manager.GetTunnelHosts(req.Name); len(hosts) > 0 {
+		current = hosts[0]
+	}
+	info, _ := d.manager.GetTunnelInfo(req.Name)
+	if resp := checkTunnelHost(req.Name, current, host, info.Status.Halted(), !override.IsZero(), req.Force); resp != nil {
+		return *resp
 	}
 
-	d.state.AddTunnel(req.Name, req.Host)
+	if err := d.manager.StartTunnelWithOverride(d.ctx, req.Name, host, override); err != nil {
+		return errorResponse(err)
+	}
+
+	d.state.AddTunnel(req.Name, host)
 	d.state.Save()
-	d.logger.Printf("Started tunnel '%s' via host '%s'", req.Name, req.Host)
+	logger := d.logger.WithTunnel(req.Name).WithHost(host)
+	switch {
+	case current != "" && current != host:
+		logger.Infof("Moved tunnel '%s' from host '%s' to '%s'", req.Name, current, host)
+	case current != "":
+		logger.Infof("Restarted tunnel '%s' via host '%s'", req.Name, host)
+	default:
+		logger.Infof("Started tunnel '%s' via host '%s'", req.Name, host)
+	}
+	if via := d.manager.GetTunnelHost(req.Name); via != "" && via != host {
+		logger.Warnf("Host '%s' is unreachable, tunnel '%s' is using fallback host '%s'", host, req.Name, via)
+	}
+	if info, ok := d.manager.GetTunnelInfo(req.Name); ok && !override.IsZero() {
+		logger.Infof("Tunnel '%s' overrides its remote target to %s", req.Name, info.Config.RemoteTarget())
+	}
 
-	return ipc.Response{Success: true}
+	return ipc.Response{Success: true, Data: ipc.TunnelUpResponse{Host: host, PreviousHost: current}}
 }
 
-func (d *Daemon) handleTunnelDown(data interface{}) ipc.Response {
+// checkTunnelHost decides what tunnel up should do with a tunnel currently
+// running via current ("" if it isn't running), where halted means it is
+// paused or failed and retarget means the request overrides the remote
+// target. It returns the response to send instead of starting the tunnel, or
+// nil to go ahead.
+func checkTunnelHost(name, current, host string, halted, retarget, force bool) *ipc.Response {
+	switch {
+	case current == "":
+		return nil
+	case current == host && halted:
+		return nil
+	case current == host && !retarget:
+		return &ipc.Response{Success: true, Data: ipc.TunnelUpResponse{Host: host, AlreadyRunning: true}}
+	case current == host && !force:
+		return &ipc.Response{
+			Success:   false,
+			Error:     fmt.Sprintf("tunnel '%s' is already up (use --force to restart it with the new remote target)", name),
+			ErrorCode: ipc.ErrCodeAlreadyRunning,
+		}
+	case !force:
+		return &ipc.Response{
+			Success:   false,
+			Error:     fmt.Sprintf("tunnel '%s' is already up via host '%s' (use --force to move it to '%s')", name, current, host),
+			ErrorCode: ipc.ErrCodeAlreadyRunning,
+		}
+	default:
+		return nil
+	}
+}
+
+func (d *Daemon) handleTunnelDown(data interface{}, caller Caller) (resp ipc.Response) {
 	var req ipc.TunnelRequest
 	if err := decodeData(data, &req); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
 	}
+	host := d.manager.GetTunnelHost(req.Name)
+	defer func() { d.auditRequest(AuditEntry{Event: AuditTunnelDown, Tunnel: req.Name, Host: host}, caller, resp) }()
 
-	if err := d.manager.StopTunnel(req.Name); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+	// A saved tunnel that is still waiting to be restored isn't running, but
+	// taking it down should stop the daemon trying
+	if err := d.manager.StopTunnel(req.Name); err != nil && !(errors.Is(err, tunnel.ErrNotRunning) && d.state.HasTunnel(req.Name, "")) {
+		return errorResponse(err)
 	}
 
 	d.state.RemoveTunnel(req.Name)
 	d.state.Save()
-	d.logger.Printf("Stopped tunnel '%s'", req.Name)
+	d.logger.WithTunnel(req.Name).Infof("Stopped tunnel '%s'", req.Name)
 
 	return ipc.Response{Success: true}
 }
 
-func (d *Daemon) handleGroupEnable(data interface{}) ipc.Response {
-	var req ipc.GroupRequest
+// handleTunnelRestart reconnects a running tunnel via the host it is already
+// using. It also revives tunnels left in an error state because reconnect is
+// disabled for them.
+func (d *Daemon) handleTunnelRestart(data interface{}, caller Caller) (resp ipc.Response) {
+	var req ipc.TunnelRequest
 	if err := decodeData(data, &req); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
 	}
+	host := d.manager.GetTunnelHost(req.Name)
+	defer func() {
+		d.auditRequest(AuditEntry{Event: AuditTunnelRestart, Tunnel: req.Name, Host: host}, caller, resp)
+	}()
+
+	if err := d.manager.ReconnectTunnel(d.ctx, req.Name); err != nil {
+		return errorResponse(err)
+	}
+	// Restarting prefers the primary host, so it may have moved off a fallback
+	host = d.manager.GetTunnelHost(req.Name)
+	d.logger.WithTunnel(req.Name).WithHost(host).Infof("Restarted tunnel '%s' via host '%s'", req.Name, host)
+
+	return ipc.Response{Success: true, Data: ipc.TunnelUpResponse{Host: host}}
+}
 
-	if req.Host == "" {
-		return ipc.Response{Success: false, Error: "host is required"}
+// handleTunnelPause stops a tunnel forwarding without forgetting it, so it
+// keeps its host and stats and stays in saved state
+func (d *Daemon) handleTunnelPause(data interface{}, caller Caller) (resp ipc.Response) {
+	var req ipc.TunnelRequest
+	if err := decodeData(data, &req); err != nil {
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
 	}
+	host := d.manager.GetTunnelHost(req.Name)
+	defer func() {
+		d.auditRequest(AuditEntry{Event: AuditTunnelPause, Tunnel: req.Name, Host: host}, caller, resp)
+	}()
 
-	if err := d.manager.StartGroup(d.ctx, req.Name, req.Host); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+	if err := d.manager.PauseTunnel(req.Name); err != nil {
+		return errorResponse(err)
 	}
+	d.logger.WithTunnel(req.Name).WithHost(host).Infof("Paused tunnel '%s'", req.Name)
 
-	d.state.AddGroup(req.Name, req.Host)
+	return ipc.Response{Success: true}
+}
+
+// handleTunnelResume starts a paused tunnel again via the host it was using
+func (d *Daemon) handleTunnelResume(data interface{}, caller Caller) (resp ipc.Response) {
+	var req ipc.TunnelRequest
+	if err := decodeData(data, &req); err != nil {
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
+	}
+	host := d.manager.GetTunnelHost(req.Name)
+	defer func() {
+		d.auditRequest(AuditEntry{Event: AuditTunnelResume, Tunnel: req.Name, Host: host}, caller, resp)
+	}()
+
+	if err := d.manager.ResumeTunnel(d.ctx, req.Name); err != nil {
+		return errorResponse(err)
+	}
+	// Resuming may have fallen back to another host
+	host = d.manager.GetTunnelHost(req.Name)
+	d.logger.WithTunnel(req.Name).WithHost(host).Infof("Resumed tunnel '%s' via host '%s'", req.Name, host)
+
+	return ipc.Response{Success: true, Data: ipc.TunnelUpResponse{Host: host}}
+}
+
+// handleTunnelKill closes a tunnel's open connections, leaving its listener
+// up for new ones
+func (d *Daemon) handleTunnelKill(data interface{}, caller Caller) (resp ipc.Response) {
+	var req ipc.TunnelRequest
+	if err := decodeData(data, &req); err != nil {
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
+	}
+	host := d.manager.GetTunnelHost(req.Name)
+	defer func() {
+		d.auditRequest(AuditEntry{Event: AuditTunnelKill, Tunnel: req.Name, Host: host}, caller, resp)
+	}()
+
+	dropped, err := d.manager.DropConnections(req.Name)
+	if err != nil {
+		return errorResponse(err)
+	}
+	d.logger.WithTunnel(req.Name).WithHost(host).Infof("Dropped %d connections on tunnel '%s'", dropped, req.Name)
+
+	return ipc.Response{Success: true, Data: ipc.TunnelKillResponse{Dropped: dropped}}
+}
+
+func (d *Daemon) handleGroupEnable(data interface{}, caller Caller) (resp ipc.Response) {
+	var req ipc.GroupRequest
+	if err := decodeData(data, &req); err != nil {
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
+	}
+	host := req.Host
+	defer func() { d.auditRequest(AuditEntry{Event: AuditGroupEnable, Group: req.Name, Host: host}, caller, resp) }()
+
+	// Fall back to the group's configured default host. A group whose
+	// tunnels all name their own host doesn't need one.
+	if host == "" {
+		cfg, err := d.tunnels.Config()
+		if err != nil {
+			return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeConfig}
+		}
+		if host, err = cfg.GroupHost(req.Name); err != nil {
+			return hostErrorResponse(err, "group")
+		}
+	}
+
+	if err := d.manager.StartGroup(d.ctx, req.Name, host); err != nil {
+		return errorResponse(err)
+	}
+
+	d.state.AddGroup(req.Name, host)
 	d.state.Save()
-	d.logger.Printf("Enabled group '%s' via host '%s'", req.Name, req.Host)
+	d.logger.WithHost(host).Infof("Enabled group '%s' via host '%s'", req.Name, host)
 
 	return ipc.Response{Success: true}
 }
 
-func (d *Daemon) handleGroupDisable(data interface{}) ipc.Response {
+func (d *Daemon) handleGroupDisable(data interface{}, caller Caller) (resp ipc.Response) {
 	var req ipc.GroupRequest
 	if err := decodeData(data, &req); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeInvalidRequest}
 	}
+	defer func() { d.auditRequest(AuditEntry{Event: AuditGroupDisable, Group: req.Name}, caller, resp) }()
 
-	if err := d.manager.StopGroup(req.Name); err != nil {
-		return ipc.Response{Success: false, Error: err.Error()}
+	// A saved group that has since been removed from the config can still be
+	// disabled, to forget it
+	if err := d.manager.StopGroup(req.Name); err != nil && !(errors.Is(err, tunnel.ErrGroupNotFound) && d.state.HasGroup(req.Name, "")) {
+		return errorResponse(err)
 	}
 
 	d.state.RemoveGroup(req.Name)
 	d.state.Save()
-	d.logger.Printf("Disabled group '%s'", req.Name)
+	d.logger.Infof("Disabled group '%s'", req.Name)
 
 	return ipc.Response{Success: true}
 }
 
+// remoteBind returns the address a remote tunnel listens on at the server,
+// or "" for local tunnels
+func remoteBind(t config.Tunnel) string {
+	if t.Type != config.TunnelTypeRemote {
+		return ""
+	}
+	return t.RemoteBindAddress()
+}
+
+// millis converts a duration to fractional milliseconds for IPC responses
+func millis(d time.Duration) *float64 {
+	ms := float64(d) / float64(time.Millisecond)
+	return &ms
+}
+
+// errorResponse builds a failed response, classifying err by the manager's
+// sentinel errors
+func errorResponse(err error) ipc.Response {
+	return ipc.Response{Success: false, Error: err.Error(), ErrorCode: errorCodeFor(err)}
+}
+
+// hostErrorResponse reports why a tunnel or group has no host to fall back to
+func hostErrorResponse(err error, kind string) ipc.Response {
+	if errors.Is(err, bore.ErrHostRequired) {
+		return ipc.Response{Success: false, Error: fmt.Sprintf("host is required (use --host or set a default host on the %s)", kind), ErrorCode: ipc.ErrCodeHostRequired}
+	}
+	return errorResponse(err)
+}
+
+// errorCodeFor maps an error to its IPC error code
+func errorCodeFor(err error) ipc.ErrorCode {
+	switch {
+	case errors.Is(err, tunnel.ErrTunnelNotFound):
+		return ipc.ErrCodeTunnelNotFound
+	case errors.Is(err, tunnel.ErrGroupNotFound):
+		return ipc.ErrCodeGroupNotFound
+	case errors.Is(err, tunnel.ErrNotRunning), errors.Is(err, tunnel.ErrPaused):
+		return ipc.ErrCodeNotRunning
+	case errors.Is(err, tunnel.ErrPortConflict):
+		return ipc.ErrCodePortConflict
+	case errors.Is(err, tunnel.ErrHostUnreachable):
+		return ipc.ErrCodeHostUnreachable
+	default:
+		return ipc.ErrCodeInternal
+	}
+}
+
 func decodeData(data interface{}, target interface{}) error {
 	if data == nil {
 		return fmt.Errorf("missing request data")
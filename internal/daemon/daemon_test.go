@@ -0,0 +1,276 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/lib"
+	"github.com/pjtatlow/bore/internal/tunnel"
+	"github.com/pjtatlow/bore/pkg/bore"
+)
+
+func TestBeginReconnectDedup(t *testing.T) {
+	d := &Daemon{reconnecting: make(map[string]bool)}
+
+	// Two concurrent triggers for the same tunnel should start only one loop
+	var started atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d.beginReconnect("web") {
+				started.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if started.Load() != 1 {
+		t.Fatalf("expected exactly 1 reconnect loop to start, got %d", started.Load())
+	}
+
+	// A different tunnel is tracked independently
+	if !d.beginReconnect("db") {
+		t.Error("expected reconnect for a different tunnel to start")
+	}
+
+	// Once the loop exits, a new one may start
+	d.endReconnect("web")
+	if !d.beginReconnect("web") {
+		t.Error("expected reconnect to start again after previous loop ended")
+	}
+}
+
+func TestWaitReconnects(t *testing.T) {
+	d := &Daemon{reconnecting: make(map[string]bool)}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// A loop that only exits on cancellation, like one sleeping between retries
+	if !d.beginReconnect("web") {
+		t.Fatal("expected reconnect to start")
+	}
+	go func() {
+		defer d.endReconnect("web")
+		<-ctx.Done()
+	}()
+
+	if d.waitReconnects(50 * time.Millisecond) {
+		t.Fatal("expected wait to time out while the loop is running")
+	}
+
+	cancel()
+	if !d.waitReconnects(5 * time.Second) {
+		t.Fatal("expected loop to exit after cancellation")
+	}
+
+	// No new loops may start once shutdown has begun
+	if d.beginReconnect("db") {
+		t.Error("expected reconnect to be refused during shutdown")
+	}
+}
+
+func TestErrorCodeFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ipc.ErrorCode
+	}{
+		{fmt.Errorf("failed to start tunnel 'db': %w", tunnel.ErrTunnelNotFound), ipc.ErrCodeTunnelNotFound},
+		{tunnel.ErrGroupNotFound, ipc.ErrCodeGroupNotFound},
+		{tunnel.ErrNotRunning, ipc.ErrCodeNotRunning},
+		{fmt.Errorf("wrapped: %w", tunnel.ErrPortConflict), ipc.ErrCodePortConflict},
+		{tunnel.ErrHostUnreachable, ipc.ErrCodeHostUnreachable},
+		{errors.New("something else"), ipc.ErrCodeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := errorCodeFor(tt.err); got != tt.want {
+			t.Errorf("errorCodeFor(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHandleRequestRejectsProtocolMismatch(t *testing.T) {
+	d := &Daemon{}
+
+	resp := d.HandleRequest(ipc.Request{Type: ipc.ReqTunnelUp, Version: ipc.ProtocolVersion + 1}, unknownCaller)
+	if resp.Success {
+		t.Fatal("expected request from a newer client to be rejected")
+	}
+	if resp.ErrorCode != ipc.ErrCodeVersionMismatch {
+		t.Errorf("expected error code %s, got %s", ipc.ErrCodeVersionMismatch, resp.ErrorCode)
+	}
+
+	// Ping must keep working so clients can still find and stop the daemon
+	if resp := d.HandleRequest(ipc.Request{Type: ipc.ReqPing}, unknownCaller); !resp.Success {
+		t.Errorf("expected ping to succeed across versions, got %q", resp.Error)
+	}
+}
+
+func TestCheckTunnelHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		halted    bool
+		retarget  bool
+		force     bool
+		wantStart bool
+		wantCode  ipc.ErrorCode
+	}{
+		{"not running", "", false, false, false, true, ""},
+		{"not running with new target", "", false, true, false, true, ""},
+		{"already up via same host", "bastion", false, false, false, false, ""},
+		{"halted via same host", "bastion", true, false, false, true, ""},
+		{"halted via other host", "other", true, false, false, false, ipc.ErrCodeAlreadyRunning},
+		{"up via other host", "other", false, false, false, false, ipc.ErrCodeAlreadyRunning},
+		{"up via other host with force", "other", false, false, true, true, ""},
+		{"new target on running tunnel", "bastion", false, true, false, false, ipc.ErrCodeAlreadyRunning},
+		{"new target on running tunnel with force", "bastion", false, true, true, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := checkTunnelHost("web", tt.current, "bastion", tt.halted, tt.retarget, tt.force)
+			if tt.wantStart {
+				if resp != nil {
+					t.Fatalf("expected tunnel to be started, got response %+v", resp)
+				}
+				return
+			}
+			if resp == nil {
+				t.Fatal("expected a response instead of starting the tunnel")
+			}
+			if resp.ErrorCode != tt.wantCode {
+				t.Errorf("expected error code %q, got %q", tt.wantCode, resp.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestCallerAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller Caller
+		owner  int
+		want   bool
+	}{
+		{"owner", Caller{UID: 501, PID: 10}, 501, true},
+		{"other user", Caller{UID: 502, PID: 10}, 501, false},
+		{"root is not the owner", Caller{UID: 0, PID: 10}, 501, false},
+		{"unknown caller", unknownCaller, 501, true},
+		{"unknown owner", Caller{UID: 502, PID: 10}, -1, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.caller.allowed(tt.owner); got != tt.want {
+			t.Errorf("%s: allowed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandleRequestRejectsOtherUsers(t *testing.T) {
+	var audit bytes.Buffer
+	d := &Daemon{ownerUID: 501, logger: NewLogger(io.Discard, LogFormatText), auditLog: &auditLog{w: &audit}}
+
+	resp := d.HandleRequest(ipc.Request{Type: ipc.ReqPing, Version: ipc.ProtocolVersion}, Caller{UID: 502, PID: 42})
+	if resp.Success || resp.ErrorCode != ipc.ErrCodePermissionDenied {
+		t.Fatalf("expected a permission_denied error, got %+v", resp)
+	}
+
+	entry, ok := ParseAuditEntry(strings.TrimSpace(audit.String()))
+	if !ok || entry.Event != AuditDenied || entry.Request != ipc.ReqPing || *entry.UID != 502 || *entry.PID != 42 {
+		t.Errorf("expected the rejection to be audited, got %q", audit.String())
+	}
+
+	if resp := d.HandleRequest(ipc.Request{Type: ipc.ReqPing, Version: ipc.ProtocolVersion}, Caller{UID: 501, PID: 42}); !resp.Success {
+		t.Errorf("expected the owner's request to succeed, got %q", resp.Error)
+	}
+}
+
+func TestHandleTunnelRestartNotRunning(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	var audit bytes.Buffer
+	d := &Daemon{ctx: context.Background(), manager: manager, logger: NewLogger(io.Discard, LogFormatText), auditLog: &auditLog{w: &audit}}
+
+	resp := d.HandleRequest(ipc.Request{Type: ipc.ReqTunnelRestart, Version: ipc.ProtocolVersion, Data: ipc.TunnelRequest{Name: "web"}}, unknownCaller)
+	if resp.Success || resp.ErrorCode != ipc.ErrCodeNotRunning {
+		t.Fatalf("expected a not_running error, got %+v", resp)
+	}
+
+	entry, ok := ParseAuditEntry(strings.TrimSpace(audit.String()))
+	if !ok || entry.Event != AuditTunnelRestart || entry.Tunnel != "web" || entry.Error == "" {
+		t.Errorf("expected the failed restart to be audited, got %q", audit.String())
+	}
+}
+
+func TestHandleTunnelPauseNotRunning(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	var audit bytes.Buffer
+	d := &Daemon{ctx: context.Background(), manager: manager, logger: NewLogger(io.Discard, LogFormatText), auditLog: &auditLog{w: &audit}}
+
+	for _, reqType := range []string{ipc.ReqTunnelPause, ipc.ReqTunnelResume, ipc.ReqTunnelKill} {
+		audit.Reset()
+		resp := d.HandleRequest(ipc.Request{Type: reqType, Version: ipc.ProtocolVersion, Data: ipc.TunnelRequest{Name: "web"}}, unknownCaller)
+		if resp.Success || resp.ErrorCode != ipc.ErrCodeNotRunning {
+			t.Errorf("%s: expected a not_running error, got %+v", reqType, resp)
+		}
+
+		entry, ok := ParseAuditEntry(strings.TrimSpace(audit.String()))
+		if !ok || entry.Event != reqType || entry.Tunnel != "web" || entry.Error == "" {
+			t.Errorf("%s: expected the failed request to be audited, got %q", reqType, audit.String())
+		}
+	}
+}
+
+func TestHandleStartNeedsHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	manager.SetConfigLoader(func() (*config.Config, error) {
+		return config.Parse([]byte(`
+tunnels:
+  web: {type: local, local_port: 8080, remote_port: 80}
+groups:
+  dev: {tunnels: [web]}
+`))
+	})
+	d := &Daemon{ctx: context.Background(), manager: manager, tunnels: (*bore.Manager)(lib.New(manager)), logger: NewLogger(io.Discard, LogFormatText)}
+
+	tests := []struct {
+		reqType string
+		data    interface{}
+		want    ipc.ErrorCode
+	}{
+		{ipc.ReqTunnelUp, ipc.TunnelRequest{Name: "web"}, ipc.ErrCodeHostRequired},
+		{ipc.ReqTunnelUp, ipc.TunnelRequest{Name: "db"}, ipc.ErrCodeTunnelNotFound},
+		{ipc.ReqGroupEnable, ipc.GroupRequest{Name: "dev"}, ipc.ErrCodeHostRequired},
+		{ipc.ReqGroupEnable, ipc.GroupRequest{Name: "prod"}, ipc.ErrCodeGroupNotFound},
+		{ipc.ReqGroupEnable, ipc.GroupRequest{Name: "prod", Host: "bastion"}, ipc.ErrCodeGroupNotFound},
+	}
+	for _, tt := range tests {
+		resp := d.HandleRequest(ipc.Request{Type: tt.reqType, Version: ipc.ProtocolVersion, Data: tt.data}, unknownCaller)
+		if resp.Success || resp.ErrorCode != tt.want {
+			t.Errorf("%s %+v: expected %s, got %+v", tt.reqType, tt.data, tt.want, resp)
+		}
+	}
+}
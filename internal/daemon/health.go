@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// healthShutdownTimeout bounds how long shutdown waits for health checks in flight
+const healthShutdownTimeout = 5 * time.Second
+
+// startHealthServer serves /healthz and /readyz on addr for container
+// orchestrators. The listener is bound before returning so a bad address
+// is reported at startup.
+func (d *Daemon) startHealthServer(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for health checks on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", d.handleHealthz)
+	mux.HandleFunc("/readyz", d.handleReadyz)
+	d.health = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := d.health.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Errorf("Health check server stopped: %v", err)
+		}
+	}()
+	d.logger.Infof("Serving health checks on http://%s", ln.Addr())
+	return nil
+}
+
+// stopHealthServer shuts the health check server down, if it was started
+func (d *Daemon) stopHealthServer() {
+	if d.health == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+	defer cancel()
+	if err := d.health.Shutdown(ctx); err != nil {
+		d.logger.Warnf("failed to stop health check server: %v", err)
+	}
+}
+
+// handleHealthz reports that the daemon is alive
+func (d *Daemon) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports whether every autostart tunnel is connected
+func (d *Daemon) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	cfg, err := d.manager.Config()
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "config: %v\n", err)
+		return
+	}
+
+	problems := notReady(cfg, d.manager.GetAllTunnelInfo())
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, strings.Join(problems, "\n"))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// notReady lists the autostart tunnels, directly or via an autostart group,
+// that aren't connected. Idle lazy tunnels and paused ones count as ready.
+func notReady(cfg *config.Config, infos []tunnel.Info) []string {
+	running := make(map[string]tunnel.Info, len(infos))
+	for _, info := range infos {
+		running[info.Name] = info
+	}
+
+	wanted := make(map[string]bool)
+	for name, t := range cfg.Tunnels {
+		if t.Autostart {
+			wanted[name] = true
+		}
+	}
+	for _, g := range cfg.Groups {
+		if g.Autostart {
+			for _, name := range g.TunnelNames() {
+				wanted[name] = true
+			}
+		}
+	}
+
+	var problems []string
+	for name := range wanted {
+		info, ok := running[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: not running", name))
+		case info.Status == tunnel.StatusError && info.Error != "":
+			problems = append(problems, fmt.Sprintf("%s: error: %s", name, info.Error))
+		case !info.Status.Healthy():
+			problems = append(problems, fmt.Sprintf("%s: %s", name, info.Status))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
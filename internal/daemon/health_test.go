@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestNotReady(t *testing.T) {
+	cfg := &config.Config{
+		Tunnels: map[string]config.Tunnel{
+			"web":     {Autostart: true},
+			"db":      {},
+			"metrics": {Autostart: true},
+			"debug":   {},
+		},
+		Groups: map[string]config.Group{
+			"backend": {Autostart: true, Tunnels: []string{"db@bastion"}},
+			"tools":   {Tunnels: []string{"debug"}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		infos []tunnel.Info
+		want  []string
+	}{
+		{
+			"all connected",
+			[]tunnel.Info{
+				{Name: "web", Status: tunnel.StatusConnected},
+				{Name: "db", Status: tunnel.StatusConnected},
+				{Name: "metrics", Status: tunnel.StatusIdle},
+			},
+			nil,
+		},
+		{
+			"autostart tunnel failed",
+			[]tunnel.Info{
+				{Name: "web", Status: tunnel.StatusError, Error: "SSH connection lost"},
+				{Name: "db", Status: tunnel.StatusConnected},
+				{Name: "metrics", Status: tunnel.StatusReconnecting},
+			},
+			[]string{"metrics: reconnecting", "web: error: SSH connection lost"},
+		},
+		{
+			"group member not running",
+			[]tunnel.Info{
+				{Name: "web", Status: tunnel.StatusConnected},
+				{Name: "metrics", Status: tunnel.StatusConnected},
+				{Name: "debug", Status: tunnel.StatusError},
+			},
+			[]string{"db: not running"},
+		},
+		{
+			"autostart tunnel paused",
+			[]tunnel.Info{
+				{Name: "web", Status: tunnel.StatusPaused},
+				{Name: "db", Status: tunnel.StatusConnected},
+				{Name: "metrics", Status: tunnel.StatusConnected},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notReady(cfg, tt.infos); !slices.Equal(got, tt.want) {
+				t.Errorf("notReady() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	manager.SetConfigLoader(func() (*config.Config, error) { return cfg, nil })
+	d := &Daemon{manager: manager}
+
+	get := func(handler http.HandlerFunc) (int, string) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		body, _ := io.ReadAll(rec.Body)
+		return rec.Code, string(body)
+	}
+
+	if code, _ := get(d.handleHealthz); code != http.StatusOK {
+		t.Errorf("expected /healthz to return 200, got %d", code)
+	}
+	if code, body := get(d.handleReadyz); code != http.StatusOK {
+		t.Errorf("expected /readyz to return 200 without autostart tunnels, got %d: %s", code, body)
+	}
+
+	cfg.Tunnels["web"] = config.Tunnel{Type: config.TunnelTypeLocal, Autostart: true}
+	if code, body := get(d.handleReadyz); code != http.StatusServiceUnavailable || body != "web: not running\n" {
+		t.Errorf("expected /readyz to return 503 naming the tunnel, got %d: %q", code, body)
+	}
+}
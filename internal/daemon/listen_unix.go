@@ -0,0 +1,41 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+// listen prepares and listens on the daemon's Unix socket
+func listen(socketPath string) (net.Listener, error) {
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Refuse to replace a socket left behind by another user
+	if err := checkOwnership(socketPath, "socket"); err != nil {
+		return nil, err
+	}
+
+	// Remove existing socket file
+	os.Remove(socketPath)
+
+	listener, err := ipc.Listen(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on socket: %w", err)
+	}
+
+	// Set socket permissions
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pjtatlow/bore/internal/ipc"
+)
+
+// listen listens on the daemon's named pipe
+func listen(pipeName string) (net.Listener, error) {
+	listener, err := ipc.Listen(pipeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on pipe: %w", err)
+	}
+	return listener, nil
+}
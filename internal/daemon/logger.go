@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Level is the severity of a log entry
+type Level string
+
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// levelRanks orders levels by severity
+var levelRanks = map[Level]int{LevelDebug: 0, LevelInfo: 1, LevelWarn: 2, LevelError: 3}
+
+// ParseLevel validates a level name such as "warn" (or "warning")
+func ParseLevel(s string) (Level, error) {
+	level := Level(strings.ToLower(s))
+	if level == "warning" {
+		level = LevelWarn
+	}
+	if _, ok := levelRanks[level]; !ok {
+		return "", fmt.Errorf("unknown log level '%s' (use debug, info, warn, or error)", s)
+	}
+	return level, nil
+}
+
+// AtLeast reports whether l is at least as severe as min
+func (l Level) AtLeast(min Level) bool {
+	return levelRanks[l] >= levelRanks[min]
+}
+
+// LogEntry is a single log event. In json mode each entry is written as one line.
+type LogEntry struct {
+	Time   time.Time `json:"time"`
+	Level  Level     `json:"level"`
+	Tunnel string    `json:"tunnel,omitempty"`
+	Host   string    `json:"host,omitempty"`
+	Msg    string    `json:"msg"`
+}
+
+// Text renders the entry in the human-readable text format
+func (e LogEntry) Text() string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format(textTimeLayout))
+	b.WriteString(" [")
+	b.WriteString(strings.ToUpper(string(e.Level)))
+	b.WriteString("] ")
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// textTimeLayout is the timestamp that starts each text-format line
+const textTimeLayout = "2006/01/02 15:04:05"
+
+// ParseLogLine decodes a log line in either format. Text lines only carry the
+// time, level, and message, and are read as info if they have no level.
+// Lines in neither format (e.g. a panic trace) aren't parsed.
+func ParseLogLine(line string) (LogEntry, bool) {
+	if entry, ok := ParseLogEntry(line); ok {
+		return entry, true
+	}
+
+	var entry LogEntry
+	if len(line) < len(textTimeLayout) {
+		return entry, false
+	}
+	t, err := time.ParseInLocation(textTimeLayout, line[:len(textTimeLayout)], time.Local)
+	if err != nil {
+		return entry, false
+	}
+	entry.Time = t
+	entry.Level = LevelInfo
+	entry.Msg = strings.TrimPrefix(line[len(textTimeLayout):], " ")
+
+	// Lines from a plain log.Logger have the timestamp but no level
+	if rest, ok := strings.CutPrefix(entry.Msg, "["); ok {
+		if level, msg, ok := strings.Cut(rest, "] "); ok {
+			if parsed, err := ParseLevel(level); err == nil {
+				entry.Level = parsed
+				entry.Msg = msg
+			}
+		}
+	}
+	return entry, true
+}
+
+// ParseLogEntry decodes a json-format log line
+func ParseLogEntry(line string) (LogEntry, bool) {
+	var entry LogEntry
+	if !strings.HasPrefix(line, "{") {
+		return entry, false
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Msg == "" {
+		return entry, false
+	}
+	return entry, true
+}
+
+// logSink is the shared output for a logger and the loggers derived from it
+type logSink struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string
+}
+
+// Logger is a small leveled logger that writes text or json entries
+type Logger struct {
+	sink   *logSink
+	tunnel string
+	host   string
+}
+
+// NewLogger creates a logger writing entries in the given format
+func NewLogger(out io.Writer, format string) *Logger {
+	if format != LogFormatJSON {
+		format = LogFormatText
+	}
+	return &Logger{sink: &logSink{out: out, format: format}}
+}
+
+// WithTunnel returns a logger that tags entries with a tunnel name
+func (l *Logger) WithTunnel(name string) *Logger {
+	derived := *l
+	derived.tunnel = name
+	return &derived
+}
+
+// WithHost returns a logger that tags entries with an SSH host
+func (l *Logger) WithHost(host string) *Logger {
+	derived := *l
+	derived.host = host
+	return &derived
+}
+
+// Debugf logs a debug message
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(LevelDebug, format, args...)
+}
+
+// Infof logs an informational message
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(LevelInfo, format, args...)
+}
+
+// Warnf logs a warning
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(LevelWarn, format, args...)
+}
+
+// Errorf logs an error
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(LevelError, format, args...)
+}
+
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	entry := LogEntry{
+		Time:   time.Now(),
+		Level:  level,
+		Tunnel: l.tunnel,
+		Host:   l.host,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+
+	var line []byte
+	if l.sink.format == LogFormatJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = append(data, '\n')
+	} else {
+		line = []byte(entry.Text() + "\n")
+	}
+
+	l.sink.mu.Lock()
+	defer l.sink.mu.Unlock()
+	l.sink.out.Write(line)
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, LogFormatText)
+
+	logger.WithTunnel("web").Warnf("failed to reconnect: %v", "timeout")
+
+	line := buf.String()
+	if !strings.Contains(line, "[WARN] failed to reconnect: timeout") {
+		t.Errorf("unexpected text log line: %q", line)
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, LogFormatJSON)
+
+	logger.WithTunnel("web").WithHost("bastion").Infof("Started tunnel '%s'", "web")
+
+	entry, ok := ParseLogEntry(strings.TrimSpace(buf.String()))
+	if !ok {
+		t.Fatalf("expected json log line, got %q", buf.String())
+	}
+	if entry.Level != LevelInfo {
+		t.Errorf("expected level info, got %s", entry.Level)
+	}
+	if entry.Tunnel != "web" {
+		t.Errorf("expected tunnel web, got %s", entry.Tunnel)
+	}
+	if entry.Host != "bastion" {
+		t.Errorf("expected host bastion, got %s", entry.Host)
+	}
+	if entry.Msg != "Started tunnel 'web'" {
+		t.Errorf("unexpected msg: %s", entry.Msg)
+	}
+	if entry.Time.IsZero() {
+		t.Error("expected time to be set")
+	}
+}
+
+func TestParseLogEntryText(t *testing.T) {
+	if _, ok := ParseLogEntry("2024/01/01 12:00:00 [INFO] Daemon started"); ok {
+		t.Error("expected text line not to parse as json entry")
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantOK    bool
+		wantLevel Level
+		wantMsg   string
+	}{
+		{"2024/01/01 12:00:00 [WARN] Network unavailable", true, LevelWarn, "Network unavailable"},
+		{"2024/01/01 12:00:00 Daemon started", true, LevelInfo, "Daemon started"},
+		{`{"time":"2024-01-01T12:00:00Z","level":"error","msg":"boom"}`, true, LevelError, "boom"},
+		{"goroutine 1 [running]:", false, "", ""},
+		{"", false, "", ""},
+	}
+
+	for _, tt := range tests {
+		entry, ok := ParseLogLine(tt.line)
+		if ok != tt.wantOK {
+			t.Errorf("ParseLogLine(%q) ok = %v, want %v", tt.line, ok, tt.wantOK)
+			continue
+		}
+		if ok && (entry.Level != tt.wantLevel || entry.Msg != tt.wantMsg) {
+			t.Errorf("ParseLogLine(%q) = %s %q, want %s %q", tt.line, entry.Level, entry.Msg, tt.wantLevel, tt.wantMsg)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("WARNING"); err != nil || level != LevelWarn {
+		t.Errorf("ParseLevel(WARNING) = %q, %v", level, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if !LevelError.AtLeast(LevelWarn) || LevelInfo.AtLeast(LevelWarn) {
+		t.Error("AtLeast ordered levels incorrectly")
+	}
+}
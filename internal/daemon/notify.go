@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// notifier sends best-effort desktop notifications when tunnels fail or recover
+type notifier struct {
+	mu     sync.Mutex
+	failed map[string]bool // tunnels whose failure has already been announced
+
+	config func() (*config.Config, error) // the daemon's current config
+	send   func(title, message string) error
+}
+
+// newNotifier returns a notifier that reads defaults.notifications from cfg
+func newNotifier(cfg func() (*config.Config, error)) *notifier {
+	return &notifier{
+		failed: make(map[string]bool),
+		config: cfg,
+		send:   sendDesktopNotification,
+	}
+}
+
+// onStatusChange is registered with the tunnel manager. It may be called while
+// the manager holds its lock, so it must never block.
+func (n *notifier) onStatusChange(name string, status tunnel.Status, err error) {
+	title, message := n.transition(name, status, err)
+	if title == "" {
+		return
+	}
+	n.notify(title, message)
+}
+
+// notify shows a notification in the background if notifications are enabled
+func (n *notifier) notify(title, message string) {
+	go func() {
+		cfg, err := n.config()
+		if err != nil || !cfg.Defaults.Notifications {
+			return
+		}
+		n.send(title, message)
+	}()
+}
+
+// transition records a status change and returns the notification to show, if any.
+// Only the first failure and the subsequent recovery of a tunnel are announced.
+func (n *notifier) transition(name string, status tunnel.Status, err error) (title, message string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch status {
+	case tunnel.StatusError:
+		if !n.failed[name] {
+			n.failed[name] = true
+			title = fmt.Sprintf("bore: tunnel '%s' failed", name)
+			message = "Tunnel entered error state"
+			if err != nil {
+				message = err.Error()
+			}
+		}
+	case tunnel.StatusConnected:
+		if n.failed[name] {
+			delete(n.failed, name)
+			title = fmt.Sprintf("bore: tunnel '%s' recovered", name)
+			message = "Tunnel is connected again"
+		}
+	}
+
+	return title, message
+}
+
+// sendDesktopNotification shows a notification using the platform's native tooling
+func sendDesktopNotification(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", windowsToastScript(title, message))
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=bore", title, message)
+	}
+
+	return cmd.Run()
+}
+
+// windowsToastScript builds a PowerShell script that shows a toast notification
+func windowsToastScript(title, message string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$x = $t.GetElementsByTagName('text')",
+		"$x.Item(0).AppendChild($t.CreateTextNode(" + quote(title) + ")) > $null",
+		"$x.Item(1).AppendChild($t.CreateTextNode(" + quote(message) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('bore').Show([Windows.UI.Notifications.ToastNotification]::new($t))",
+	}, "; ")
+}
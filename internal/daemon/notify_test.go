@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestNotifierTransitions(t *testing.T) {
+	n := newNotifier(nil)
+
+	// Initial connect is not announced
+	if title, _ := n.transition("web", tunnel.StatusConnected, nil); title != "" {
+		t.Errorf("expected no notification on initial connect, got %q", title)
+	}
+
+	// First failure is announced with the error message
+	title, message := n.transition("web", tunnel.StatusError, errors.New("SSH connection lost"))
+	if title == "" {
+		t.Fatal("expected notification on failure")
+	}
+	if message != "SSH connection lost" {
+		t.Errorf("expected error message, got %q", message)
+	}
+
+	// Repeated failures while reconnecting are not announced again
+	if title, _ := n.transition("web", tunnel.StatusError, errors.New("still down")); title != "" {
+		t.Errorf("expected no repeat notification, got %q", title)
+	}
+
+	// Recovery is announced once
+	if title, _ := n.transition("web", tunnel.StatusConnected, nil); title == "" {
+		t.Error("expected notification on recovery")
+	}
+	if title, _ := n.transition("web", tunnel.StatusConnected, nil); title != "" {
+		t.Errorf("expected no repeat recovery notification, got %q", title)
+	}
+}
+
+func TestNotifierUsesDaemonConfig(t *testing.T) {
+	// The config on disk has notifications on, but the daemon's doesn't yet,
+	// as before a reload
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	if err := os.MkdirAll(filepath.Join(home, ".bore"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".bore", "config.yaml"), []byte("defaults:\n  notifications: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		cfg := config.DefaultConfig()
+		cfg.Defaults.Notifications = enabled
+		n := newNotifier(func() (*config.Config, error) { return cfg, nil })
+		sent := make(chan string, 1)
+		n.send = func(title, message string) error {
+			sent <- title
+			return nil
+		}
+
+		n.notify("bore: tunnel 'web' failed", "SSH connection lost")
+		select {
+		case <-sent:
+			if !enabled {
+				t.Error("expected no notification with notifications off in the daemon's config")
+			}
+		case <-time.After(100 * time.Millisecond):
+			if enabled {
+				t.Error("expected a notification with notifications on in the daemon's config")
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package daemon
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCaller identifies the process on the other end of a Unix socket from
+// its LOCAL_PEERCRED and LOCAL_PEERPID options
+func peerCaller(conn net.Conn) Caller {
+	caller := unknownCaller
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return caller
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return caller
+	}
+	raw.Control(func(fd uintptr) {
+		if cred, err := unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED); err == nil {
+			caller.UID = int(cred.Uid)
+		}
+		if pid, err := unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID); err == nil {
+			caller.PID = pid
+		}
+	})
+	return caller
+}
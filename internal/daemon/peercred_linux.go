@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCaller identifies the process on the other end of a Unix socket from
+// its SO_PEERCRED credentials
+func peerCaller(conn net.Conn) Caller {
+	caller := unknownCaller
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return caller
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return caller
+	}
+	raw.Control(func(fd uintptr) {
+		if cred, err := syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED); err == nil {
+			caller = Caller{UID: int(cred.Uid), PID: int(cred.Pid)}
+		}
+	})
+	return caller
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "net"
+
+// peerCaller always reports an unknown caller; this platform has no peer
+// credentials to read, so access control is left to the socket or pipe itself
+func peerCaller(conn net.Conn) Caller {
+	return unknownCaller
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPeerCaller(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	defer conn.Close()
+
+	caller := peerCaller(conn)
+	if caller.UID != os.Getuid() {
+		t.Errorf("expected peer uid %d, got %d", os.Getuid(), caller.UID)
+	}
+	if caller.PID != os.Getpid() {
+		t.Errorf("expected peer pid %d, got %d", os.Getpid(), caller.PID)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkOwnership returns an error if path exists and belongs to another user.
+// A missing file is not an error.
+func checkOwnership(path, what string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat %s %s: %w", what, path, err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s %s is owned by another user (uid %d); is bore running under a different account?",
+			what, path, stat.Uid)
+	}
+
+	return nil
+}
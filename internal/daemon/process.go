@@ -6,7 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
-	"syscall"
+	"time"
 
 	"github.com/pjtatlow/bore/internal/ipc"
 )
@@ -45,9 +45,7 @@ func Fork() error {
 	cmd.Dir = "/"
 
 	// Detach from parent process
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
-	}
+	detach(cmd)
 
 	if err := cmd.Start(); err != nil {
 		logFile.Close()
@@ -75,7 +73,16 @@ func WritePID() error {
 		return fmt.Errorf("failed to create pid directory: %w", err)
 	}
 
+	if err := checkOwnership(pidPath, "PID file"); err != nil {
+		return err
+	}
+
+	// Don't clobber the PID file of another live daemon
 	pid := os.Getpid()
+	if existing, err := ReadPID(); err == nil && existing != pid && IsProcessRunning(existing) {
+		return fmt.Errorf("daemon already running (PID %d); stop it with 'bore stop' first", existing)
+	}
+
 	return os.WriteFile(pidPath, []byte(strconv.Itoa(pid)), 0600)
 }
 
@@ -94,6 +101,18 @@ func ReadPID() (int, error) {
 	return strconv.Atoi(string(data))
 }
 
+// IsBoreProcess reports whether the process with the given PID is running
+// this program, so a stale PID file naming a process that has since reused the
+// PID is never acted on
+func IsBoreProcess(pid int) bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	name, err := processName(pid)
+	return err == nil && name == filepath.Base(exe)
+}
+
 // RemovePID removes the PID file
 func RemovePID() error {
 	pidPath, err := ipc.PIDPath()
@@ -103,18 +122,6 @@ func RemovePID() error {
 	return os.Remove(pidPath)
 }
 
-// IsProcessRunning checks if a process with the given PID is running
-func IsProcessRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-
-	// On Unix, FindProcess always succeeds, so we need to send signal 0
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
 // StopDaemon sends a stop signal to the daemon
 func StopDaemon() error {
 	pid, err := ReadPID()
@@ -136,11 +143,54 @@ func StopDaemon() error {
 		}
 	}
 
-	// Fall back to SIGTERM
+	// Fall back to terminating the process
+	if !IsBoreProcess(pid) {
+		return fmt.Errorf("PID %d in the PID file isn't a bore daemon", pid)
+	}
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		return err
 	}
 
-	return process.Signal(syscall.SIGTERM)
+	return terminate(process)
+}
+
+// KillDaemon forcibly kills the daemon and removes the PID file and socket it
+// leaves behind once it has exited. Use it only when a graceful stop has
+// failed.
+func KillDaemon() error {
+	pid, err := ReadPID()
+	if err != nil {
+		return fmt.Errorf("daemon not running or PID file not found")
+	}
+
+	if IsProcessRunning(pid) {
+		if !IsBoreProcess(pid) {
+			return fmt.Errorf("PID %d in the PID file isn't a bore daemon; not killing it", pid)
+		}
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			return err
+		}
+		if err := process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill daemon (PID %d): %w", pid, err)
+		}
+
+		// Kill is asynchronous; give the process a moment to exit. Its PID
+		// file and socket stay until it has, so nothing starts a second daemon
+		// alongside it.
+		for i := 0; i < 40 && IsProcessRunning(pid); i++ {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if IsProcessRunning(pid) {
+			return fmt.Errorf("daemon (PID %d) is still running after being killed", pid)
+		}
+	}
+
+	RemovePID()
+	if socketPath, err := ipc.SocketPath(); err == nil {
+		os.Remove(socketPath)
+	}
+
+	return nil
 }
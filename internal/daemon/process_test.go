@@ -0,0 +1,192 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePIDRefusesLiveDaemon(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	pidPath := filepath.Join(home, ".bore", "bore.pid")
+	if err := os.MkdirAll(filepath.Dir(pidPath), 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	// Our parent process is alive, so it stands in for another daemon
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getppid())), 0600); err != nil {
+		t.Fatalf("failed to write pid file: %v", err)
+	}
+
+	err := WritePID()
+	if err == nil {
+		t.Fatal("expected error when PID file belongs to a live process")
+	}
+	if !strings.Contains(err.Error(), "bore stop") {
+		t.Errorf("expected hint to run 'bore stop', got %v", err)
+	}
+}
+
+func TestWritePIDReplacesStalePID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	pidPath := filepath.Join(home, ".bore", "bore.pid")
+	if err := os.MkdirAll(filepath.Dir(pidPath), 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(pidPath, []byte("999999999"), 0600); err != nil {
+		t.Fatalf("failed to write pid file: %v", err)
+	}
+
+	if err := WritePID(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pid, err := ReadPID()
+	if err != nil {
+		t.Fatalf("failed to read pid: %v", err)
+	}
+	if pid != os.Getpid() {
+		t.Errorf("expected pid %d, got %d", os.Getpid(), pid)
+	}
+}
+
+func TestCheckOwnership(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := checkOwnership(filepath.Join(dir, "missing"), "socket"); err != nil {
+		t.Errorf("expected no error for missing file, got %v", err)
+	}
+
+	path := filepath.Join(dir, "owned")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := checkOwnership(path, "socket"); err != nil {
+		t.Errorf("expected no error for own file, got %v", err)
+	}
+}
+
+// TestHelperProcess isn't a real test: the tests below run the test binary
+// with BORE_TEST_HELPER set to stand in for a daemon
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("BORE_TEST_HELPER") != "1" {
+		return
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+// startProcess starts a long-running child, the test binary itself if bore
+// is set or sleep otherwise, and returns it along with a channel closed when
+// it exits
+func startProcess(t *testing.T, bore bool) (*exec.Cmd, <-chan struct{}) {
+	t.Helper()
+	cmd := exec.Command("sleep", "60")
+	if bore {
+		cmd = exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+		cmd.Env = append(os.Environ(), "BORE_TEST_HELPER=1")
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can't start %s: %v", cmd.Path, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-exited
+	})
+	return cmd, exited
+}
+
+// writeDaemonFiles writes a PID file naming pid and a socket placeholder,
+// returning their paths
+func writeDaemonFiles(t *testing.T, home string, pid int) (string, string) {
+	t.Helper()
+	boreDir := filepath.Join(home, ".bore")
+	if err := os.MkdirAll(boreDir, 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	pidPath := filepath.Join(boreDir, "bore.pid")
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(pid)), 0600); err != nil {
+		t.Fatalf("failed to write pid file: %v", err)
+	}
+	socketPath := filepath.Join(boreDir, "bore.sock")
+	if err := os.WriteFile(socketPath, nil, 0600); err != nil {
+		t.Fatalf("failed to write socket placeholder: %v", err)
+	}
+	return pidPath, socketPath
+}
+
+func TestIsBoreProcess(t *testing.T) {
+	bore, _ := startProcess(t, true)
+	other, _ := startProcess(t, false)
+
+	if !IsBoreProcess(bore.Process.Pid) {
+		t.Error("expected a process running this program to count as bore")
+	}
+	if IsBoreProcess(other.Process.Pid) {
+		t.Error("expected an unrelated process not to count as bore")
+	}
+}
+
+func TestKillDaemon(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A long-running copy of this program stands in for a wedged daemon
+	cmd, exited := startProcess(t, true)
+	pidPath, socketPath := writeDaemonFiles(t, home, cmd.Process.Pid)
+
+	if err := KillDaemon(); err != nil {
+		t.Fatalf("KillDaemon failed: %v", err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected process to be killed")
+	}
+	if _, err := os.Stat(pidPath); !os.IsNotExist(err) {
+		t.Error("expected PID file to be removed")
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("expected socket to be removed")
+	}
+}
+
+func TestKillDaemonSparesReusedPID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A stale PID file naming a process that isn't bore
+	cmd, exited := startProcess(t, false)
+	pidPath, socketPath := writeDaemonFiles(t, home, cmd.Process.Pid)
+
+	if err := KillDaemon(); err == nil {
+		t.Fatal("expected KillDaemon to refuse a process that isn't bore")
+	}
+
+	select {
+	case <-exited:
+		t.Fatal("expected the unrelated process to be left running")
+	case <-time.After(100 * time.Millisecond):
+	}
+	for _, path := range []string{pidPath, socketPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be left alone, got %v", path, err)
+		}
+	}
+}
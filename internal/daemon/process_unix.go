@@ -0,0 +1,35 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts cmd in a new session so it outlives the parent
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+}
+
+// IsProcessRunning checks if a process with the given PID is running
+func IsProcessRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// On Unix, FindProcess always succeeds, so we need to send signal 0.
+	// EPERM means the process exists but belongs to another user.
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminate asks the process to shut down gracefully
+func terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// detach starts cmd without a console in its own process group so it
+// outlives the parent
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: createNewProcessGroup | detachedProcess,
+		HideWindow:    true,
+	}
+}
+
+// IsProcessRunning checks if a process with the given PID is running
+func IsProcessRunning(pid int) bool {
+	// On Windows, FindProcess opens a handle and fails if the process is gone
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// terminate kills the process; Windows has no SIGTERM to deliver
+func terminate(process *os.Process) error {
+	return process.Kill()
+}
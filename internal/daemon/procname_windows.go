@@ -0,0 +1,23 @@
+//go:build windows
+
+package daemon
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// processName returns the image name of the program a process is running
+func processName(pid int) (string, error) {
+	out, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return "", err
+	}
+	record, err := csv.NewReader(strings.NewReader(string(out))).Read()
+	if err != nil || len(record) == 0 {
+		return "", fmt.Errorf("no process with PID %d", pid)
+	}
+	return record[0], nil
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ipc"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// reloadAction is what a config reload does with one running tunnel
+type reloadAction int
+
+const (
+	reloadKeep reloadAction = iota
+	reloadRestart
+	reloadStop
+)
+
+// reloadActionFor compares a running tunnel's config with its definition in
+// the new config (ok is false if it was removed). Only changes to what the
+// tunnel forwards need a restart; settings like alert_bytes are read live.
+func reloadActionFor(running config.Tunnel, override tunnel.RemoteOverride, next config.Tunnel, ok bool) reloadAction {
+	if !ok {
+		return reloadStop
+	}
+	want, err := override.Apply(next)
+	if err != nil {
+		// Restarting reports why the override no longer applies
+		return reloadRestart
+	}
+	if running.Type != want.Type ||
+		running.LocalHost != want.LocalHost || running.LocalPorts() != want.LocalPorts() ||
+		running.RemoteHost != want.RemoteHost || running.RemotePorts() != want.RemotePorts() ||
+		running.RemoteSocket != want.RemoteSocket || running.RemoteBind != want.RemoteBind ||
+		running.Verify != want.Verify ||
+		running.Lazy != want.Lazy || running.IdleTimeout != want.IdleTimeout ||
+		!reflect.DeepEqual(running.NoDelay, want.NoDelay) {
+		return reloadRestart
+	}
+	return reloadKeep
+}
+
+// reloadConfig applies the config file to the running tunnels: tunnels whose
+// definition changed are restarted via the same host, removed tunnels are
+// stopped, and the rest are left alone. An invalid config changes nothing.
+func (d *Daemon) reloadConfig() (*ipc.ReloadResponse, error) {
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+
+	cfg, err := d.manager.LoadConfig()
+	if cfg == nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config is invalid, keeping current tunnels: %w", err)
+	}
+
+	infos := d.manager.GetAllTunnelInfo()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	result := &ipc.ReloadResponse{Diff: config.Diff(d.appliedConfig(), cfg)}
+	fail := func(name string, err error) {
+		if result.Failed == nil {
+			result.Failed = make(map[string]string)
+		}
+		result.Failed[name] = err.Error()
+		d.logger.WithTunnel(name).Errorf("Failed to reload tunnel '%s': %v", name, err)
+	}
+
+	for _, info := range infos {
+		name := info.Name
+		next, ok := cfg.GetTunnel(name)
+		override := d.manager.GetTunnelOverride(name)
+
+		switch reloadActionFor(info.Config, override, next, ok) {
+		case reloadStop:
+			if err := d.manager.StopTunnel(name); err != nil {
+				fail(name, err)
+				continue
+			}
+			d.state.RemoveTunnel(name)
+			result.Stopped = append(result.Stopped, name)
+
+		case reloadRestart:
+			// A paused tunnel picks up the new config when it is resumed
+			if info.Status == tunnel.StatusPaused {
+				result.Unchanged = append(result.Unchanged, name)
+				continue
+			}
+			// Restart via the host it was started with, not a fallback
+			hosts := d.manager.GetTunnelHosts(name)
+			if err := d.manager.StopTunnel(name); err != nil {
+				fail(name, err)
+				continue
+			}
+			if err := d.manager.StartTunnelWithOverride(d.ctx, name, hosts[0], override, hosts[1:]...); err != nil {
+				d.state.RemoveTunnel(name)
+				fail(name, err)
+				continue
+			}
+			result.Restarted = append(result.Restarted, name)
+
+		default:
+			result.Unchanged = append(result.Unchanged, name)
+		}
+	}
+
+	d.state.Save()
+	d.setAppliedConfig(cfg)
+	d.logger.Infof("Reloaded config: %d restarted, %d stopped, %d unchanged, %d failed",
+		len(result.Restarted), len(result.Stopped), len(result.Unchanged), len(result.Failed))
+	d.logDiff(result.Diff)
+
+	return result, nil
+}
+
+// setAppliedConfig records the config just applied to the tunnels
+func (d *Daemon) setAppliedConfig(cfg *config.Config) {
+	d.appliedMu.Lock()
+	defer d.appliedMu.Unlock()
+	d.applied = cfg
+}
+
+// appliedConfig returns the config last applied to the tunnels, or nil if
+// none has loaded
+func (d *Daemon) appliedConfig() *config.Config {
+	d.appliedMu.Lock()
+	defer d.appliedMu.Unlock()
+	return d.applied
+}
+
+// appliedConfigHash returns the hash of the config last applied to the tunnels
+func (d *Daemon) appliedConfigHash() string {
+	if cfg := d.appliedConfig(); cfg != nil {
+		return cfg.Hash
+	}
+	return ""
+}
+
+func (d *Daemon) handleReloadConfig(caller Caller) (resp ipc.Response) {
+	defer func() { d.auditRequest(AuditEntry{Event: AuditConfigReload}, caller, resp) }()
+
+	result, err := d.reloadConfig()
+	if err != nil {
+		return ipc.Response{Success: false, Error: err.Error(), ErrorCode: ipc.ErrCodeConfig}
+	}
+	return ipc.Response{Success: true, Data: result}
+}
+
+// logDiff records what a reload changed in the config, so the log shows
+// exactly what each reload applied
+func (d *Daemon) logDiff(diff *config.ConfigDiff) {
+	if len(diff.TunnelsAdded) > 0 {
+		d.logger.Infof("Config added tunnels: %s", strings.Join(diff.TunnelsAdded, ", "))
+	}
+	if len(diff.TunnelsRemoved) > 0 {
+		d.logger.Infof("Config removed tunnels: %s", strings.Join(diff.TunnelsRemoved, ", "))
+	}
+	for _, name := range sortedKeys(diff.TunnelsChanged) {
+		d.logger.WithTunnel(name).Infof("Config changed tunnel '%s': %s", name, strings.Join(diff.TunnelsChanged[name], ", "))
+	}
+	if len(diff.GroupsAdded) > 0 {
+		d.logger.Infof("Config added groups: %s", strings.Join(diff.GroupsAdded, ", "))
+	}
+	if len(diff.GroupsRemoved) > 0 {
+		d.logger.Infof("Config removed groups: %s", strings.Join(diff.GroupsRemoved, ", "))
+	}
+	for _, name := range sortedKeys(diff.GroupsChanged) {
+		d.logger.Infof("Config changed group '%s': %s", name, strings.Join(diff.GroupsChanged[name], ", "))
+	}
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestReloadActionFor(t *testing.T) {
+	running := config.Tunnel{
+		Type:       config.TunnelTypeLocal,
+		LocalHost:  "localhost",
+		LocalPort:  5432,
+		RemoteHost: "db.internal",
+		RemotePort: 5432,
+	}
+	with := func(fn func(*config.Tunnel)) config.Tunnel {
+		t := running
+		fn(&t)
+		return t
+	}
+	retargeted := with(func(t *config.Tunnel) { t.RemoteHost = "db-replica.internal" })
+
+	tests := []struct {
+		name     string
+		running  config.Tunnel
+		override tunnel.RemoteOverride
+		next     config.Tunnel
+		exists   bool
+		want     reloadAction
+	}{
+		{"unchanged", running, tunnel.RemoteOverride{}, running, true, reloadKeep},
+		{"removed", running, tunnel.RemoteOverride{}, config.Tunnel{}, false, reloadStop},
+		{"port changed", running, tunnel.RemoteOverride{}, with(func(t *config.Tunnel) { t.LocalPort = 6543 }), true, reloadRestart},
+		{"remote changed", running, tunnel.RemoteOverride{}, retargeted, true, reloadRestart},
+		{"remote bind changed", with(func(t *config.Tunnel) { t.Type = config.TunnelTypeRemote }), tunnel.RemoteOverride{}, with(func(t *config.Tunnel) { t.Type = config.TunnelTypeRemote; t.RemoteBind = "0.0.0.0" }), true, reloadRestart},
+		{"made lazy", running, tunnel.RemoteOverride{}, with(func(t *config.Tunnel) { t.Lazy = true }), true, reloadRestart},
+		{"nodelay changed", running, tunnel.RemoteOverride{}, with(func(t *config.Tunnel) { off := false; t.NoDelay = &off }), true, reloadRestart},
+		{"live setting changed", running, tunnel.RemoteOverride{}, with(func(t *config.Tunnel) { t.AlertBytes = 1024 }), true, reloadKeep},
+		{"override still applies", retargeted, tunnel.RemoteOverride{Host: "db-replica.internal"}, running, true, reloadKeep},
+		{"override no longer valid", retargeted, tunnel.RemoteOverride{Host: "db-replica.internal"}, with(func(t *config.Tunnel) { t.Type = config.TunnelTypeRemote }), true, reloadRestart},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reloadActionFor(tt.running, tt.override, tt.next, tt.exists); got != tt.want {
+				t.Errorf("reloadActionFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
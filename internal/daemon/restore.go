@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// restoreAction is what happens to a saved tunnel or group that failed to
+// start when the daemon restored its state
+type restoreAction int
+
+const (
+	restoreRetry restoreAction = iota // keep it saved and retry with backoff
+	restoreKeep                       // keep it saved but don't retry
+	restorePrune                      // remove it from the state file
+)
+
+// restoreActionFor decides what to do with a saved entry that failed to
+// start. Entries missing from the config won't start however often they're
+// retried, so they are pruned if prune is set and otherwise kept for when
+// the config defines them again; any other failure is retried.
+func restoreActionFor(err error, prune bool) restoreAction {
+	if errors.Is(err, tunnel.ErrTunnelNotFound) || errors.Is(err, tunnel.ErrGroupNotFound) {
+		if prune {
+			return restorePrune
+		}
+		return restoreKeep
+	}
+	return restoreRetry
+}
+
+// pruneState reports whether defaults.prune_state is set
+func (d *Daemon) pruneState() bool {
+	cfg, err := d.manager.Config()
+	return err == nil && cfg.Defaults.PruneState
+}
+
+// savedEntry is a tunnel or group from the state file being restored
+type savedEntry struct {
+	kind   string // "tunnel" or "group"
+	name   string
+	host   string
+	logger *Logger
+	start  func() error
+	saved  func() bool // whether the state file still lists it on this host
+	remove func()      // removes it from the state file
+}
+
+// savedTunnel returns the saved entry for a tunnel
+func (d *Daemon) savedTunnel(name, host string) savedEntry {
+	return savedEntry{
+		kind:   "tunnel",
+		name:   name,
+		host:   host,
+		logger: d.logger.WithTunnel(name).WithHost(host),
+		start:  func() error { return d.manager.StartTunnel(d.ctx, name, host) },
+		saved:  func() bool { return d.state.HasTunnel(name, host) },
+		remove: func() { d.state.RemoveTunnel(name) },
+	}
+}
+
+// savedGroup returns the saved entry for a group
+func (d *Daemon) savedGroup(name, host string) savedEntry {
+	return savedEntry{
+		kind:   "group",
+		name:   name,
+		host:   host,
+		logger: d.logger.WithHost(host),
+		start:  func() error { return d.manager.StartGroup(d.ctx, name, host) },
+		saved:  func() bool { return d.state.HasGroup(name, host) },
+		remove: func() { d.state.RemoveGroup(name) },
+	}
+}
+
+// restoreEntry starts a saved tunnel or group, handling a failure according
+// to restoreActionFor
+func (d *Daemon) restoreEntry(e savedEntry) {
+	err := e.start()
+	if err == nil {
+		e.logger.Infof("Restored %s '%s' via host '%s'", e.kind, e.name, e.host)
+		return
+	}
+	e.logger.Errorf("Failed to restore %s '%s': %v", e.kind, e.name, err)
+	d.restoreFailed(e, err)
+}
+
+// restoreFailed prunes, keeps, or retries a saved entry that failed to start
+func (d *Daemon) restoreFailed(e savedEntry, err error) {
+	switch restoreActionFor(err, d.pruneState()) {
+	case restorePrune:
+		e.remove()
+		if err := d.state.Save(); err != nil {
+			e.logger.Warnf("failed to save state: %v", err)
+		}
+		e.logger.Infof("Removed %s '%s' from saved state since it is no longer in the config", e.kind, e.name)
+	case restoreKeep:
+		e.logger.Infof("Keeping %s '%s' in saved state until it is defined in the config again", e.kind, e.name)
+	case restoreRetry:
+		d.retryRestore(e)
+	}
+}
+
+// retryRestore keeps trying to start a saved entry with backoff until it
+// starts, fails permanently, or is removed from the state file (e.g. by
+// 'bore down')
+func (d *Daemon) retryRestore(e savedEntry) {
+	cfg, err := d.manager.Config()
+	if err != nil {
+		e.logger.Errorf("Failed to load config for restore: %v", err)
+		return
+	}
+	enabled := cfg.Defaults.Reconnect.Enabled
+	if e.kind == "tunnel" {
+		enabled = cfg.ReconnectEnabled(e.name)
+	}
+	if !enabled {
+		e.logger.Infof("Reconnect is disabled for %s '%s', leaving it down", e.kind, e.name)
+		return
+	}
+
+	backoff := newBackoff(cfg.Defaults.Reconnect)
+
+	// Groups and tunnels may share names, so keep their loops apart
+	key := e.name
+	if e.kind == "group" {
+		key = "group:" + e.name
+	}
+	if !d.beginReconnect(key) {
+		return
+	}
+
+	go func() {
+		defer d.endReconnect(key)
+
+		for {
+			wait := backoff.Next()
+			e.logger.Infof("Retrying %s '%s' in %v", e.kind, e.name, wait)
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			if !e.saved() {
+				e.logger.Infof("%s '%s' is no longer saved, abandoning restore", e.kind, e.name)
+				return
+			}
+
+			// Wait for network if unavailable
+			if !d.networkMonitor.IsAvailable() {
+				d.networkMonitor.WaitForNetwork(d.ctx)
+				backoff.Reset()
+			}
+
+			err := e.start()
+			if err == nil {
+				e.logger.Infof("Restored %s '%s' via host '%s'", e.kind, e.name, e.host)
+				return
+			}
+			e.logger.Warnf("Failed to restore %s '%s': %v", e.kind, e.name, err)
+
+			if restoreActionFor(err, d.pruneState()) != restoreRetry {
+				// The config changed under us; settle it like a failure at startup
+				d.restoreFailed(e, err)
+				return
+			}
+		}
+	}()
+}
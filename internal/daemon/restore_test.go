@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/state"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestRestoreActionFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		prune bool
+		want  restoreAction
+	}{
+		{"host unreachable", tunnel.ErrHostUnreachable, false, restoreRetry},
+		{"host unreachable with prune", tunnel.ErrHostUnreachable, true, restoreRetry},
+		{"port conflict", tunnel.ErrPortConflict, true, restoreRetry},
+		{"tunnel removed from config", tunnel.ErrTunnelNotFound, false, restoreKeep},
+		{"tunnel removed with prune", tunnel.ErrTunnelNotFound, true, restorePrune},
+		{"group removed with prune", tunnel.ErrGroupNotFound, true, restorePrune},
+		{"other error", errors.New("boom"), true, restoreRetry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restoreActionFor(tt.err, tt.prune); got != tt.want {
+				t.Errorf("restoreActionFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreStateMissingEntries(t *testing.T) {
+	for _, prune := range []bool{false, true} {
+		t.Run(map[bool]string{false: "keep", true: "prune"}[prune], func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("BORE_HOME", t.TempDir())
+
+			saved, err := state.NewState()
+			if err != nil {
+				t.Fatalf("NewState failed: %v", err)
+			}
+			saved.AddTunnel("gone", "bastion")
+			saved.AddGroup("old", "bastion")
+			if err := saved.Save(); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			manager, err := tunnel.NewManager()
+			if err != nil {
+				t.Fatalf("NewManager failed: %v", err)
+			}
+			cfg := config.DefaultConfig()
+			cfg.Defaults.PruneState = prune
+			manager.SetConfigLoader(func() (*config.Config, error) { return cfg, nil })
+
+			st, err := state.NewState()
+			if err != nil {
+				t.Fatalf("NewState failed: %v", err)
+			}
+			d := &Daemon{
+				ctx:          context.Background(),
+				manager:      manager,
+				state:        st,
+				logger:       NewLogger(io.Discard, LogFormatText),
+				reconnecting: make(map[string]bool),
+			}
+			if err := d.restoreState(); err != nil {
+				t.Fatalf("restoreState failed: %v", err)
+			}
+
+			// Nothing in the config, so nothing should be left retrying
+			if len(d.reconnecting) != 0 {
+				t.Error("expected no restore retries for entries missing from the config")
+			}
+
+			reloaded, err := state.NewState()
+			if err != nil {
+				t.Fatalf("NewState failed: %v", err)
+			}
+			if err := reloaded.Load(); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			kept := reloaded.HasTunnel("gone", "bastion") && reloaded.HasGroup("old", "bastion")
+			if kept == prune {
+				t.Errorf("expected entries kept=%v with prune_state=%v, got tunnels %v groups %v",
+					!prune, prune, reloaded.GetActiveTunnels(), reloaded.GetActiveGroups())
+			}
+		})
+	}
+}
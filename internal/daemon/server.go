@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/pjtatlow/bore/internal/ipc"
 )
 
+// requestReadTimeout bounds how long a client has to send its request
+const requestReadTimeout = 10 * time.Second
+
 // Server handles IPC requests from clients
 type Server struct {
 	mu       sync.RWMutex
@@ -23,7 +26,42 @@ type Server struct {
 
 // RequestHandler processes IPC requests
 type RequestHandler interface {
-	HandleRequest(req ipc.Request) ipc.Response
+	HandleRequest(req ipc.Request, caller Caller) ipc.Response
+}
+
+// Caller identifies the client that sent a request, from the socket's peer
+// credentials. Fields are -1 when the platform can't report them.
+type Caller struct {
+	UID int
+	PID int
+}
+
+// unknownCaller is a caller whose credentials couldn't be read
+var unknownCaller = Caller{UID: -1, PID: -1}
+
+// allowed reports whether the caller may send requests to a daemon run by
+// owner. Callers that can't be identified are left to the socket's own
+// permissions.
+func (c Caller) allowed(owner int) bool {
+	return c.UID < 0 || owner < 0 || c.UID == owner
+}
+
+// uidPtr returns the caller's UID for an audit entry, or nil if unknown
+func (c Caller) uidPtr() *int {
+	return knownID(c.UID)
+}
+
+// pidPtr returns the caller's PID for an audit entry, or nil if unknown
+func (c Caller) pidPtr() *int {
+	return knownID(c.PID)
+}
+
+// knownID returns a pointer to id, or nil if it's negative
+func knownID(id int) *int {
+	if id < 0 {
+		return nil
+	}
+	return &id
 }
 
 // NewServer creates a new IPC server
@@ -40,23 +78,9 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
-		return fmt.Errorf("failed to create socket directory: %w", err)
-	}
-
-	// Remove existing socket file
-	os.Remove(socketPath)
-
-	listener, err := net.Listen("unix", socketPath)
+	listener, err := listen(socketPath)
 	if err != nil {
-		return fmt.Errorf("failed to listen on socket: %w", err)
-	}
-
-	// Set socket permissions
-	if err := os.Chmod(socketPath, 0600); err != nil {
-		listener.Close()
-		return fmt.Errorf("failed to set socket permissions: %w", err)
+		return err
 	}
 
 	s.mu.Lock()
@@ -86,23 +110,30 @@ func (s *Server) acceptLoop() {
 	}
 }
 
-// handleConnection processes a single client connection
+// handleConnection processes a single client connection. Each connection runs
+// in its own goroutine so a slow request doesn't hold up other clients.
 func (s *Server) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
 	decoder := json.NewDecoder(conn)
 	encoder := json.NewEncoder(conn)
 
+	// Don't let a client that connects but never sends a request pin this
+	// goroutine; the handler itself may legitimately take longer
+	conn.SetReadDeadline(time.Now().Add(requestReadTimeout))
+
 	var req ipc.Request
 	if err := decoder.Decode(&req); err != nil {
 		encoder.Encode(ipc.Response{
-			Success: false,
-			Error:   fmt.Sprintf("failed to decode request: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to decode request: %v", err),
+			ErrorCode: ipc.ErrCodeInvalidRequest,
 		})
 		return
 	}
 
-	resp := s.handler.HandleRequest(req)
+	resp := s.handler.HandleRequest(req, peerCaller(conn))
+	resp.Version = ipc.ProtocolVersion
 	encoder.Encode(resp)
 }
 
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals ask the daemon to reload its config
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package daemon
+
+import "os"
+
+// reloadSignals is empty on Windows, which has no SIGHUP; use
+// `bore config reload` instead
+var reloadSignals []os.Signal
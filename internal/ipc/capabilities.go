@@ -0,0 +1,104 @@
+package ipc
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pjtatlow/bore/internal/version"
+)
+
+// CapabilitiesResponse describes what a daemon supports, so third-party
+// clients can discover the API without reading bore's source
+type CapabilitiesResponse struct {
+	ProtocolVersion int                  `json:"protocol_version"`
+	Version         *version.Info        `json:"version,omitempty"`
+	Requests        []RequestDescription `json:"requests"`
+}
+
+// RequestDescription documents one request type. Data and Response list the
+// JSON fields of the request's "data" and the response's "data", if any.
+type RequestDescription struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description"`
+	Data        []FieldDescription `json:"data,omitempty"`
+	Response    []FieldDescription `json:"response,omitempty"`
+}
+
+// FieldDescription documents one JSON field
+type FieldDescription struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Capabilities returns the description of every request this build handles
+func Capabilities() CapabilitiesResponse {
+	info := version.Get()
+	return CapabilitiesResponse{
+		ProtocolVersion: ProtocolVersion,
+		Version:         &info,
+		Requests: []RequestDescription{
+			{Type: ReqPing, Description: "Check that the daemon is up"},
+			{Type: ReqCapabilities, Description: "Describe the supported requests", Response: fieldsOf(CapabilitiesResponse{})},
+			{Type: ReqStatus, Description: "Daemon, tunnel, group, and network status", Response: fieldsOf(StatusResponse{})},
+			{Type: ReqHostStatus, Description: "SSH host connections and the tunnels using them", Response: fieldsOf(HostStatusResponse{})},
+			{Type: ReqTunnelUp, Description: "Start a tunnel via a host", Data: fieldsOf(TunnelRequest{}), Response: fieldsOf(TunnelUpResponse{})},
+			{Type: ReqTunnelDown, Description: "Stop a tunnel", Data: fieldsOf(TunnelRequest{})},
+			{Type: ReqTunnelRestart, Description: "Reconnect a running tunnel via its current host", Data: fieldsOf(TunnelRequest{}), Response: fieldsOf(TunnelUpResponse{})},
+			{Type: ReqTunnelPause, Description: "Stop a tunnel forwarding but keep its host and stats", Data: fieldsOf(TunnelRequest{})},
+			{Type: ReqTunnelResume, Description: "Start a paused tunnel via its host", Data: fieldsOf(TunnelRequest{}), Response: fieldsOf(TunnelUpResponse{})},
+			{Type: ReqTunnelKill, Description: "Close a tunnel's open connections, keeping it running", Data: fieldsOf(TunnelRequest{}), Response: fieldsOf(TunnelKillResponse{})},
+			{Type: ReqGroupEnable, Description: "Start every tunnel in a group", Data: fieldsOf(GroupRequest{})},
+			{Type: ReqGroupDisable, Description: "Stop every tunnel in a group", Data: fieldsOf(GroupRequest{})},
+			{Type: ReqReloadConfig, Description: "Apply the config file to running tunnels", Response: fieldsOf(ReloadResponse{})},
+			{Type: ReqStop, Description: "Stop all tunnels and shut the daemon down"},
+		},
+	}
+}
+
+// fieldsOf lists the JSON fields of a struct
+func fieldsOf(v interface{}) []FieldDescription {
+	t := reflect.TypeOf(v)
+	fields := make([]FieldDescription, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, FieldDescription{
+			Name:     name,
+			Type:     typeName(f.Type),
+			Optional: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// typeName describes a Go type in JSON terms, naming nested objects by their
+// Go type so they can be looked up in other descriptions
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "int"
+	case reflect.Float64, reflect.Float32:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return "time"
+		}
+		return t.Name()
+	default:
+		return t.Kind().String()
+	}
+}
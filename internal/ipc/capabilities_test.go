@@ -0,0 +1,68 @@
+package ipc
+
+import "testing"
+
+func TestCapabilitiesListsEveryRequest(t *testing.T) {
+	all := []string{
+		ReqStatus, ReqStop, ReqTunnelUp, ReqTunnelDown, ReqTunnelRestart, ReqTunnelPause,
+		ReqTunnelResume, ReqTunnelKill, ReqGroupEnable, ReqGroupDisable, ReqPing, ReqHostStatus,
+		ReqReloadConfig, ReqCapabilities,
+	}
+
+	caps := Capabilities()
+	if caps.ProtocolVersion != ProtocolVersion {
+		t.Errorf("expected protocol version %d, got %d", ProtocolVersion, caps.ProtocolVersion)
+	}
+
+	seen := make(map[string]int)
+	for _, req := range caps.Requests {
+		seen[req.Type]++
+		if req.Description == "" {
+			t.Errorf("request %q has no description", req.Type)
+		}
+	}
+	for _, reqType := range all {
+		if seen[reqType] != 1 {
+			t.Errorf("expected request %q to be described once, got %d", reqType, seen[reqType])
+		}
+	}
+	if len(seen) != len(all) {
+		t.Errorf("expected %d request types, got %d", len(all), len(seen))
+	}
+}
+
+func TestFieldsOf(t *testing.T) {
+	fields := make(map[string]FieldDescription)
+	for _, f := range fieldsOf(TunnelStatus{}) {
+		fields[f.Name] = f
+	}
+
+	tests := []struct {
+		name     string
+		wantType string
+		optional bool
+	}{
+		{"name", "string", false},
+		{"local_port", "int", false},
+		{"status", "string", false},
+		{"send_rate", "number", true},
+		{"next_retry", "time", true},
+	}
+
+	for _, tt := range tests {
+		f, ok := fields[tt.name]
+		if !ok {
+			t.Errorf("field %q missing", tt.name)
+			continue
+		}
+		if f.Type != tt.wantType || f.Optional != tt.optional {
+			t.Errorf("field %q = %s (optional %v), want %s (optional %v)", tt.name, f.Type, f.Optional, tt.wantType, tt.optional)
+		}
+	}
+
+	for _, f := range fieldsOf(StatusResponse{}) {
+		if f.Name == "tunnels" && f.Type != "[]TunnelStatus" {
+			t.Errorf("expected tunnels to be []TunnelStatus, got %s", f.Type)
+		}
+	}
+}
@@ -2,75 +2,97 @@ package ipc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
-	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
 )
 
-// Client communicates with the daemon via Unix socket
+const (
+	// DefaultTimeout is the default deadline for a request/response round trip
+	DefaultTimeout = 30 * time.Second
+
+	// dialTimeout bounds how long we wait to connect to the daemon socket
+	dialTimeout = 5 * time.Second
+)
+
+// Client communicates with the daemon via a Unix socket, or a named pipe on Windows
 type Client struct {
 	socketPath string
+	timeout    time.Duration
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithTimeout sets the deadline for each request/response round trip.
+// Non-positive values keep the default.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
 }
 
 // NewClient creates a new IPC client
-func NewClient() (*Client, error) {
+func NewClient(opts ...ClientOption) (*Client, error) {
 	socketPath, err := SocketPath()
 	if err != nil {
 		return nil, err
 	}
-	return &Client{socketPath: socketPath}, nil
-}
-
-// SocketPath returns the path to the Unix socket
-func SocketPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+	c := &Client{socketPath: socketPath, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(c)
 	}
-	return filepath.Join(home, ".bore", "bore.sock"), nil
+	return c, nil
 }
 
 // PIDPath returns the path to the PID file
 func PIDPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, ".bore", "bore.pid"), nil
+	return borePath("bore.pid")
 }
 
 // LogPath returns the path to the log file
 func LogPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, ".bore", "bore.log"), nil
+	return borePath("bore.log")
+}
+
+// AuditPath returns the path to the audit log
+func AuditPath() (string, error) {
+	return borePath("audit.log")
 }
 
 // StatePath returns the path to the state file
 func StatePath() (string, error) {
-	home, err := os.UserHomeDir()
+	return borePath("state.json")
+}
+
+// borePath returns the path to a file in the bore directory
+func borePath(name string) (string, error) {
+	dir, err := config.ConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".bore", "state.json"), nil
+	return filepath.Join(dir, name), nil
 }
 
 // Send sends a request and returns the response
 func (c *Client) Send(req Request) (*Response, error) {
-	conn, err := net.DialTimeout("unix", c.socketPath, 5*time.Second)
+	conn, err := dial(c.socketPath, dialTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
 	}
 	defer conn.Close()
 
 	// Set deadline for the entire operation
-	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	conn.SetDeadline(time.Now().Add(c.timeout))
 
 	// Send request
+	req.Version = ProtocolVersion
 	encoder := json.NewEncoder(conn)
 	if err := encoder.Encode(req); err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
@@ -80,9 +102,18 @@ func (c *Client) Send(req Request) (*Response, error) {
 	decoder := json.NewDecoder(conn)
 	var resp Response
 	if err := decoder.Decode(&resp); err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return nil, fmt.Errorf("timed out after %s waiting for daemon (use --timeout to wait longer)", c.timeout)
+		}
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	// A daemon from another build may have silently misread the request
+	if perr := ProtocolMismatch(req.Type, ProtocolVersion, resp.Version); perr != nil {
+		return nil, perr
+	}
+
 	return &resp, nil
 }
 
@@ -92,8 +123,8 @@ func (c *Client) Ping() error {
 	if err != nil {
 		return err
 	}
-	if !resp.Success {
-		return fmt.Errorf("ping failed: %s", resp.Error)
+	if err := resp.Err(); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
 	}
 	return nil
 }
@@ -104,8 +135,8 @@ func (c *Client) Status() (*StatusResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	if !resp.Success {
-		return nil, fmt.Errorf("status failed: %s", resp.Error)
+	if err := resp.Err(); err != nil {
+		return nil, fmt.Errorf("status failed: %w", err)
 	}
 
 	// Decode the data into StatusResponse
@@ -121,6 +152,28 @@ func (c *Client) Status() (*StatusResponse, error) {
 	return &status, nil
 }
 
+// HostStatus gets the status of SSH host connections
+func (c *Client) HostStatus() (*HostStatusResponse, error) {
+	resp, err := c.Send(Request{Type: ReqHostStatus})
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, fmt.Errorf("host status failed: %w", err)
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var status HostStatusResponse
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
 // Stop tells the daemon to shut down
 func (c *Client) Stop() error {
 	resp, err := c.Send(Request{Type: ReqStop})
@@ -128,25 +181,37 @@ func (c *Client) Stop() error {
 		// Connection closed is expected when daemon stops
 		return nil
 	}
-	if !resp.Success {
-		return fmt.Errorf("stop failed: %s", resp.Error)
+	if err := resp.Err(); err != nil {
+		return fmt.Errorf("stop failed: %w", err)
 	}
 	return nil
 }
 
-// TunnelUp starts a tunnel
-func (c *Client) TunnelUp(name, host string) error {
+// TunnelUp starts a tunnel. An empty host lets the daemon fall back to the
+// tunnel's configured default host. A tunnel already running via another host
+// is only moved if force is set.
+func (c *Client) TunnelUp(req TunnelRequest) (*TunnelUpResponse, error) {
 	resp, err := c.Send(Request{
 		Type: ReqTunnelUp,
-		Data: TunnelRequest{Name: name, Host: host},
+		Data: req,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !resp.Success {
-		return fmt.Errorf("%s", resp.Error)
+	if err := resp.Err(); err != nil {
+		return nil, err
 	}
-	return nil
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var up TunnelUpResponse
+	if err := json.Unmarshal(data, &up); err != nil {
+		return nil, err
+	}
+
+	return &up, nil
 }
 
 // TunnelDown stops a tunnel
@@ -158,13 +223,100 @@ func (c *Client) TunnelDown(name string) error {
 	if err != nil {
 		return err
 	}
-	if !resp.Success {
-		return fmt.Errorf("%s", resp.Error)
+	return resp.Err()
+}
+
+// TunnelRestart tears down a running tunnel and reconnects it via the same
+// host, returning that host
+func (c *Client) TunnelRestart(name string) (string, error) {
+	resp, err := c.Send(Request{
+		Type: ReqTunnelRestart,
+		Data: TunnelRequest{Name: name},
+	})
+	if err != nil {
+		return "", err
 	}
-	return nil
+	if err := resp.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return "", err
+	}
+	var up TunnelUpResponse
+	if err := json.Unmarshal(data, &up); err != nil {
+		return "", err
+	}
+
+	return up.Host, nil
+}
+
+// TunnelPause stops a tunnel forwarding while keeping its host and stats
+func (c *Client) TunnelPause(name string) error {
+	resp, err := c.Send(Request{
+		Type: ReqTunnelPause,
+		Data: TunnelRequest{Name: name},
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// TunnelKill closes a tunnel's open connections without stopping it,
+// returning how many were closed
+func (c *Client) TunnelKill(name string) (int, error) {
+	resp, err := c.Send(Request{
+		Type: ReqTunnelKill,
+		Data: TunnelRequest{Name: name},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := resp.Err(); err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return 0, err
+	}
+	var kill TunnelKillResponse
+	if err := json.Unmarshal(data, &kill); err != nil {
+		return 0, err
+	}
+
+	return kill.Dropped, nil
 }
 
-// GroupEnable enables a tunnel group
+// TunnelResume starts a paused tunnel again, returning the host it uses
+func (c *Client) TunnelResume(name string) (string, error) {
+	resp, err := c.Send(Request{
+		Type: ReqTunnelResume,
+		Data: TunnelRequest{Name: name},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := resp.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return "", err
+	}
+	var up TunnelUpResponse
+	if err := json.Unmarshal(data, &up); err != nil {
+		return "", err
+	}
+
+	return up.Host, nil
+}
+
+// GroupEnable enables a tunnel group. An empty host lets the daemon fall back
+// to the group's configured default host.
 func (c *Client) GroupEnable(name, host string) error {
 	resp, err := c.Send(Request{
 		Type: ReqGroupEnable,
@@ -173,10 +325,7 @@ func (c *Client) GroupEnable(name, host string) error {
 	if err != nil {
 		return err
 	}
-	if !resp.Success {
-		return fmt.Errorf("%s", resp.Error)
-	}
-	return nil
+	return resp.Err()
 }
 
 // GroupDisable disables a tunnel group
@@ -188,10 +337,52 @@ func (c *Client) GroupDisable(name string) error {
 	if err != nil {
 		return err
 	}
-	if !resp.Success {
-		return fmt.Errorf("%s", resp.Error)
+	return resp.Err()
+}
+
+// ReloadConfig asks the daemon to apply the current config file to its
+// running tunnels
+func (c *Client) ReloadConfig() (*ReloadResponse, error) {
+	resp, err := c.Send(Request{Type: ReqReloadConfig})
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, err
 	}
-	return nil
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var reload ReloadResponse
+	if err := json.Unmarshal(data, &reload); err != nil {
+		return nil, err
+	}
+
+	return &reload, nil
+}
+
+// Capabilities asks the daemon which requests it supports
+func (c *Client) Capabilities() (*CapabilitiesResponse, error) {
+	resp, err := c.Send(Request{Type: ReqCapabilities})
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var caps CapabilitiesResponse
+	if err := json.Unmarshal(data, &caps); err != nil {
+		return nil, err
+	}
+
+	return &caps, nil
 }
 
 // IsDaemonRunning checks if the daemon is running by trying to ping it
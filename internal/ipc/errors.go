@@ -0,0 +1,64 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error is a failed daemon response, keeping the human-readable message
+// alongside a machine-readable code
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrDaemonNotRunning is returned by the CLI when the daemon can't be reached
+var ErrDaemonNotRunning = &Error{
+	Code:    ErrCodeDaemonNotRunning,
+	Message: "daemon is not running (start with 'bore start')",
+}
+
+// ProtocolMismatch returns an error if a client and daemon speaking the given
+// protocol versions can't safely exchange a request of type reqType, or nil if
+// they can. Ping, stop, status, and capabilities work across versions so that
+// an outdated daemon can still be inspected and restarted.
+func ProtocolMismatch(reqType string, client, daemon int) *Error {
+	if client == daemon {
+		return nil
+	}
+	switch reqType {
+	case ReqPing, ReqStop, ReqStatus, ReqCapabilities:
+		return nil
+	}
+
+	msg := fmt.Sprintf("daemon is running an older version of bore (protocol %d, client %d); restart it with 'bore stop && bore start'", daemon, client)
+	if daemon > client {
+		msg = fmt.Sprintf("daemon is running a newer version of bore (protocol %d, client %d); use the newer bore binary or restart the daemon with this one", daemon, client)
+	}
+	return &Error{Code: ErrCodeVersionMismatch, Message: msg}
+}
+
+// Err returns the response's error, or nil if it succeeded
+func (r *Response) Err() error {
+	if r.Success {
+		return nil
+	}
+	code := r.ErrorCode
+	if code == "" {
+		code = ErrCodeInternal
+	}
+	return &Error{Code: code, Message: r.Error}
+}
+
+// ErrorCodeOf returns the code of the first *Error in err's chain, or "" if none
+func ErrorCodeOf(err error) ErrorCode {
+	var ipcErr *Error
+	if errors.As(err, &ipcErr) {
+		return ipcErr.Code
+	}
+	return ""
+}
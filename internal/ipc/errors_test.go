@@ -0,0 +1,67 @@
+package ipc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResponseErr(t *testing.T) {
+	if err := (&Response{Success: true}).Err(); err != nil {
+		t.Fatalf("expected nil error for success, got %v", err)
+	}
+
+	resp := &Response{Success: false, Error: "port conflict: 8080 already used by tunnel 'web'", ErrorCode: ErrCodePortConflict}
+	err := fmt.Errorf("failed to start: %w", resp.Err())
+	if got := ErrorCodeOf(err); got != ErrCodePortConflict {
+		t.Errorf("expected code %s, got %s", ErrCodePortConflict, got)
+	}
+	if got, want := err.Error(), "failed to start: port conflict: 8080 already used by tunnel 'web'"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// Responses from daemons that predate error codes are treated as internal
+	legacy := (&Response{Success: false, Error: "boom"}).Err()
+	if got := ErrorCodeOf(legacy); got != ErrCodeInternal {
+		t.Errorf("expected code %s, got %s", ErrCodeInternal, got)
+	}
+}
+
+func TestProtocolMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		reqType string
+		client  int
+		daemon  int
+		want    string // substring of the message, or "" for no error
+	}{
+		{"same version", ReqTunnelUp, ProtocolVersion, ProtocolVersion, ""},
+		{"older daemon", ReqTunnelUp, ProtocolVersion, 0, "older version"},
+		{"newer daemon", ReqGroupEnable, 1, 2, "newer version"},
+		{"ping across versions", ReqPing, ProtocolVersion, 0, ""},
+		{"stop across versions", ReqStop, ProtocolVersion, 0, ""},
+		{"status across versions", ReqStatus, 0, ProtocolVersion, ""},
+		{"capabilities across versions", ReqCapabilities, 0, ProtocolVersion, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProtocolMismatch(tt.reqType, tt.client, tt.daemon)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected mismatch error")
+			}
+			if err.Code != ErrCodeVersionMismatch {
+				t.Errorf("expected code %s, got %s", ErrCodeVersionMismatch, err.Code)
+			}
+			if !strings.Contains(err.Message, tt.want) {
+				t.Errorf("expected message containing %q, got %q", tt.want, err.Message)
+			}
+		})
+	}
+}
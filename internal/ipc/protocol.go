@@ -1,56 +1,117 @@
 package ipc
 
-import "github.com/pjtatlow/bore/internal/tunnel"
+import (
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+	"github.com/pjtatlow/bore/internal/version"
+)
+
+// ProtocolVersion is the IPC protocol spoken by this build. Bump it whenever a
+// request or response changes in a way an older peer would misread.
+const ProtocolVersion = 3
 
 // Request represents a client request to the daemon
 type Request struct {
-	Type string      `json:"type"`
-	Data interface{} `json:"data,omitempty"`
+	Type    string      `json:"type"`
+	Version int         `json:"version,omitempty"` // client's ProtocolVersion; 0 from clients that predate it
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // Response represents a daemon response to the client
 type Response struct {
-	Success bool        `json:"success"`
-	Error   string      `json:"error,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success   bool        `json:"success"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode ErrorCode   `json:"error_code,omitempty"`
+	Version   int         `json:"version,omitempty"` // daemon's ProtocolVersion; 0 from daemons that predate it
+	Data      interface{} `json:"data,omitempty"`
 }
 
+// ErrorCode classifies a failed response so clients can react to it
+type ErrorCode string
+
+// Error codes
+const (
+	ErrCodeInternal         ErrorCode = "internal"
+	ErrCodeInvalidRequest   ErrorCode = "invalid_request"
+	ErrCodeConfig           ErrorCode = "config_error"
+	ErrCodeHostRequired     ErrorCode = "host_required"
+	ErrCodeTunnelNotFound   ErrorCode = "tunnel_not_found"
+	ErrCodeGroupNotFound    ErrorCode = "group_not_found"
+	ErrCodeNotRunning       ErrorCode = "not_running"
+	ErrCodePortConflict     ErrorCode = "port_conflict"
+	ErrCodeHostUnreachable  ErrorCode = "host_unreachable"
+	ErrCodeDaemonNotRunning ErrorCode = "daemon_not_running"
+	ErrCodeVersionMismatch  ErrorCode = "version_mismatch"
+	ErrCodeAlreadyRunning   ErrorCode = "already_running"
+	ErrCodePermissionDenied ErrorCode = "permission_denied"
+)
+
 // Request types
 const (
-	ReqStatus       = "status"
-	ReqStop         = "stop"
-	ReqTunnelUp     = "tunnel_up"
-	ReqTunnelDown   = "tunnel_down"
-	ReqGroupEnable  = "group_enable"
-	ReqGroupDisable = "group_disable"
-	ReqPing         = "ping"
+	ReqStatus        = "status"
+	ReqStop          = "stop"
+	ReqTunnelUp      = "tunnel_up"
+	ReqTunnelDown    = "tunnel_down"
+	ReqTunnelRestart = "tunnel_restart"
+	ReqTunnelPause   = "tunnel_pause"
+	ReqTunnelResume  = "tunnel_resume"
+	ReqTunnelKill    = "tunnel_kill"
+	ReqGroupEnable   = "group_enable"
+	ReqGroupDisable  = "group_disable"
+	ReqPing          = "ping"
+	ReqHostStatus    = "host_status"
+	ReqReloadConfig  = "reload_config"
+	ReqCapabilities  = "capabilities"
 )
 
 // StatusResponse contains daemon and tunnel status
 type StatusResponse struct {
-	Running  bool               `json:"running"`
-	PID      int                `json:"pid"`
-	Uptime   string             `json:"uptime"`
-	Tunnels  []TunnelStatus     `json:"tunnels"`
-	Groups   []GroupStatus      `json:"groups"`
-	Network  NetworkStatusInfo  `json:"network"`
+	Running         bool              `json:"running"`
+	PID             int               `json:"pid"`
+	Uptime          string            `json:"uptime"`
+	Version         *version.Info     `json:"version,omitempty"` // build of the running daemon; nil from daemons that predate it
+	ProtocolVersion int               `json:"protocol_version,omitempty"`
+	Tunnels         []TunnelStatus    `json:"tunnels"`
+	Groups          []GroupStatus     `json:"groups"`
+	Network         NetworkStatusInfo `json:"network"`
+	ConfigError     string            `json:"config_error,omitempty"`   // why the config file fails to load or validate
+	ConfigHash      string            `json:"config_hash,omitempty"`    // hash of the config file last applied at start or reload
+	ConfigChanged   bool              `json:"config_changed,omitempty"` // set by the client when the file on disk no longer matches ConfigHash
 }
 
 // TunnelStatus contains status info for a single tunnel
 type TunnelStatus struct {
-	Name           string              `json:"name"`
-	Type           string              `json:"type"`
-	Host           string              `json:"host"`
-	LocalPort      int                 `json:"local_port"`
-	RemoteHost     string              `json:"remote_host"`
-	RemotePort     int                 `json:"remote_port"`
-	Status         tunnel.Status       `json:"status"`
-	Error          string              `json:"error,omitempty"`
-	BytesSent      int64               `json:"bytes_sent"`
-	BytesReceived  int64               `json:"bytes_received"`
-	Connections    int64               `json:"connections"`
-	ReconnectCount int                 `json:"reconnect_count"`
-	Uptime         string              `json:"uptime,omitempty"`
+	Name             string        `json:"name"`
+	Type             string        `json:"type"`
+	Host             string        `json:"host"`
+	Endpoint         string        `json:"endpoint,omitempty"`     // user@hostname:port the host resolved to
+	PrimaryHost      string        `json:"primary_host,omitempty"` // set when Host is a fallback for this host
+	LocalHost        string        `json:"local_host"`
+	LocalPort        int           `json:"local_port"`
+	RemoteHost       string        `json:"remote_host"`
+	RemotePort       int           `json:"remote_port"`
+	RemoteSocket     string        `json:"remote_socket,omitempty"`
+	RemoteBind       string        `json:"remote_bind,omitempty"`       // remote tunnels: address the server listens on
+	LocalPortRange   string        `json:"local_port_range,omitempty"`  // set for tunnels forwarding a port range
+	RemotePortRange  string        `json:"remote_port_range,omitempty"` // set for tunnels forwarding a port range
+	Status           tunnel.Status `json:"status"`
+	Error            string        `json:"error,omitempty"`
+	BytesSent        int64         `json:"bytes_sent"`
+	BytesReceived    int64         `json:"bytes_received"`
+	Connections      int64         `json:"connections"`
+	ActiveConns      int64         `json:"active_connections"`
+	PeakConns        int64         `json:"peak_connections"` // most connections open at once
+	AcceptErrors     int64         `json:"accept_errors,omitempty"`
+	ReconnectCount   int           `json:"reconnect_count"`
+	RecentReconnects int           `json:"reconnects_last_hour"`
+	LastReconnect    *time.Time    `json:"last_reconnect,omitempty"`
+	Uptime           string        `json:"uptime,omitempty"`
+	SendRate         *float64      `json:"send_rate,omitempty"`  // bytes/sec since the previous status call
+	RecvRate         *float64      `json:"recv_rate,omitempty"`  // bytes/sec since the previous status call
+	RTTMillis        *float64      `json:"rtt_ms,omitempty"`     // keepalive round-trip time to the tunnel's host
+	NextRetry        *time.Time    `json:"next_retry,omitempty"` // when the next reconnect attempt is scheduled
 }
 
 // GroupStatus contains status info for a tunnel group
@@ -61,6 +122,20 @@ type GroupStatus struct {
 	Tunnels     []string `json:"tunnels"`
 }
 
+// HostStatusResponse contains status info for SSH host connections
+type HostStatusResponse struct {
+	Hosts []HostStatus `json:"hosts"`
+}
+
+// HostStatus contains status info for a single SSH host connection
+type HostStatus struct {
+	Name          string   `json:"name"`
+	Connected     bool     `json:"connected"`
+	Tunnels       []string `json:"tunnels"`
+	LastConnected string   `json:"last_connected,omitempty"`
+	RTTMillis     *float64 `json:"rtt_ms,omitempty"` // keepalive round-trip time
+}
+
 // NetworkStatusInfo contains network monitoring status
 type NetworkStatusInfo struct {
 	Status string `json:"status"`
@@ -68,8 +143,37 @@ type NetworkStatusInfo struct {
 
 // TunnelRequest is used for tunnel up/down requests
 type TunnelRequest struct {
-	Name string `json:"name"`
-	Host string `json:"host,omitempty"`
+	Name  string `json:"name"`
+	Host  string `json:"host,omitempty"`
+	Force bool   `json:"force,omitempty"` // tunnel up: move a tunnel already running via another host
+
+	// Tunnel up: point the tunnel at a different remote target for this run
+	RemoteHost string `json:"remote_host,omitempty"`
+	RemotePort int    `json:"remote_port,omitempty"`
+}
+
+// TunnelUpResponse reports where a tunnel is running after a tunnel up request
+type TunnelUpResponse struct {
+	Host           string `json:"host"`
+	AlreadyRunning bool   `json:"already_running,omitempty"` // it was already up via Host, so nothing changed
+	PreviousHost   string `json:"previous_host,omitempty"`   // the host it was moved from with Force
+}
+
+// TunnelKillResponse reports how many connections a kill dropped
+type TunnelKillResponse struct {
+	Dropped int `json:"dropped"`
+}
+
+// ReloadResponse reports what a config reload did to the running tunnels
+type ReloadResponse struct {
+	Restarted []string          `json:"restarted,omitempty"` // definition changed, restarted via the same host
+	Stopped   []string          `json:"stopped,omitempty"`   // removed from the config
+	Unchanged []string          `json:"unchanged,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"` // tunnel name to error
+
+	// Diff is what changed between the config the daemon last applied and
+	// this one, whether or not any running tunnel used it
+	Diff *config.ConfigDiff `json:"diff,omitempty"`
 }
 
 // GroupRequest is used for group enable/disable requests
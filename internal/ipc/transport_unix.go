@@ -0,0 +1,23 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"net"
+	"time"
+)
+
+// SocketPath returns the path to the Unix socket
+func SocketPath() (string, error) {
+	return borePath("bore.sock")
+}
+
+// Listen listens for IPC connections on the Unix socket at path
+func Listen(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// dial connects to the Unix socket at path
+func dial(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
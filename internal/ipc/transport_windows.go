@@ -0,0 +1,30 @@
+//go:build windows
+
+package ipc
+
+import (
+	"net"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pipeName is the named pipe the daemon listens on
+const pipeName = `\\.\pipe\bore`
+
+// SocketPath returns the name of the daemon's named pipe
+func SocketPath() (string, error) {
+	return pipeName, nil
+}
+
+// Listen listens for IPC connections on the named pipe at path. The default
+// pipe security only grants write access to the creating user, SYSTEM, and
+// administrators.
+func Listen(path string) (net.Listener, error) {
+	return winio.ListenPipe(path, nil)
+}
+
+// dial connects to the named pipe at path
+func dial(path string, timeout time.Duration) (net.Conn, error) {
+	return winio.DialPipe(path, &timeout)
+}
@@ -0,0 +1,39 @@
+// Package lib holds the state behind pkg/bore's Manager. pkg/bore declares
+// its Manager as this type, so the daemon, which can import internal
+// packages, can wrap its own tunnel manager in one and use the public API
+// for what it covers.
+package lib
+
+import (
+	"context"
+
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// Manager is a tunnel manager and the context its tunnels are started with
+type Manager struct {
+	tunnels *tunnel.Manager
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// New wraps tunnels. Its context lives until Cancel is called.
+func New(tunnels *tunnel.Manager) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{tunnels: tunnels, ctx: ctx, cancel: cancel}
+}
+
+// Tunnels returns the wrapped tunnel manager
+func (m *Manager) Tunnels() *tunnel.Manager {
+	return m.tunnels
+}
+
+// Context returns the context tunnels are started with
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Cancel cancels the context returned by Context
+func (m *Manager) Cancel() {
+	m.cancel()
+}
@@ -5,12 +5,19 @@ import (
 	"time"
 )
 
-// Backoff implements exponential backoff with jitter
+// Backoff strategies, as named in the config
+const (
+	StrategyExponential = "exponential" // grow by the multiplier each attempt, with jitter
+	StrategyConstant    = "constant"    // wait the initial interval every time, without jitter
+)
+
+// Backoff implements exponential backoff with jitter, or a constant interval
 type Backoff struct {
 	initial    time.Duration
 	max        time.Duration
 	multiplier float64
 	current    time.Duration
+	constant   bool
 }
 
 // NewBackoff creates a new Backoff instance
@@ -23,8 +30,22 @@ func NewBackoff(initial, max time.Duration, multiplier float64) *Backoff {
 	}
 }
 
+// NewConstantBackoff creates a Backoff that always waits interval
+func NewConstantBackoff(interval time.Duration) *Backoff {
+	return &Backoff{
+		initial:  interval,
+		max:      interval,
+		current:  interval,
+		constant: true,
+	}
+}
+
 // Next returns the next backoff duration and advances the backoff
 func (b *Backoff) Next() time.Duration {
+	if b.constant {
+		return b.initial
+	}
+
 	duration := b.current
 
 	// Add jitter (0-25%)
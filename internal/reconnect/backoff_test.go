@@ -78,3 +78,19 @@ func TestBackoffCapping(t *testing.T) {
 		t.Errorf("expected current to be capped at 5s, got %v", b.Current())
 	}
 }
+
+func TestConstantBackoff(t *testing.T) {
+	b := NewConstantBackoff(5 * time.Second)
+
+	// Every attempt waits exactly the interval, with no jitter or growth
+	for i := 0; i < 5; i++ {
+		if d := b.Next(); d != 5*time.Second {
+			t.Fatalf("attempt %d: expected 5s, got %v", i+1, d)
+		}
+	}
+
+	b.Reset()
+	if b.Current() != 5*time.Second {
+		t.Errorf("expected current 5s after reset, got %v", b.Current())
+	}
+}
@@ -19,37 +19,132 @@ const (
 	NetworkUnavailable
 )
 
+// probeTimeout bounds each TCP dial when probing target hosts
+const probeTimeout = 3 * time.Second
+
+// DefaultPollInterval is how often the network is checked where it has to
+// be polled
+const DefaultPollInterval = 5 * time.Second
+
+// dnsCheckHost is looked up to tell whether the internet is reachable
+const dnsCheckHost = "dns.google"
+
+// wakeCheckInterval is how often the clock is checked for a jump that shows
+// the system was asleep
+const wakeCheckInterval = 2 * time.Second
+
+// wakeThreshold is how much later than expected a clock check must run to
+// count as waking from sleep
+const wakeThreshold = 5 * time.Second
+
 // Monitor watches for network status changes
 type Monitor struct {
-	mu        sync.RWMutex
-	status    NetworkStatus
-	onChange  func(NetworkStatus)
-	stopCh    chan struct{}
-	stopOnce  sync.Once
-	useNative bool
-	ctx       context.Context
-	cancel    context.CancelFunc
+	mu           sync.RWMutex
+	status       NetworkStatus
+	onChange     func(NetworkStatus)
+	onWake       func(slept time.Duration)
+	stopCh       chan struct{}
+	stopOnce     sync.Once
+	useNative    bool
+	probeTargets func() []string
+	pollInterval time.Duration
+	dnsTimeout   time.Duration
+	lookupHost   func(ctx context.Context, host string) ([]string, error)
+	ctx          context.Context
+	cancel       context.CancelFunc
 }
 
 // NewMonitor creates a new network monitor
 func NewMonitor() *Monitor {
 	return &Monitor{
-		status:    NetworkUnknown,
-		useNative: runtime.GOOS == "darwin" || runtime.GOOS == "windows",
+		status:       NetworkUnknown,
+		useNative:    runtime.GOOS == "darwin" || runtime.GOOS == "windows",
+		pollInterval: DefaultPollInterval,
+		lookupHost:   net.DefaultResolver.LookupHost,
 	}
 }
 
+// SetPollInterval sets how often the network is checked when polling, which
+// is used on Linux or when probing targets. A zero interval keeps the default.
+// It must be called before Start.
+func (m *Monitor) SetPollInterval(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d > 0 {
+		m.pollInterval = d
+	} else {
+		m.pollInterval = DefaultPollInterval
+	}
+}
+
+// SetDNSTimeout bounds the DNS lookup of each poll, after which the network
+// counts as unavailable. A zero timeout uses the poll interval, so a hung
+// resolver can't hold up the next check. It must be called before Start.
+func (m *Monitor) SetDNSTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dnsTimeout = max(d, 0)
+}
+
 // Start begins monitoring network status
 func (m *Monitor) Start(ctx context.Context) error {
 	m.ctx, m.cancel = context.WithCancel(ctx)
 	m.stopCh = make(chan struct{})
 
-	if m.useNative {
+	go m.watchClock()
+
+	if m.useNative && m.probeTargets == nil {
 		return m.startNative()
 	}
 	return m.startFallback()
 }
 
+// watchClock reports a wake from sleep whenever a check runs much later than
+// scheduled, since timers don't fire while the system sleeps
+func (m *Monitor) watchClock() {
+	ticker := time.NewTicker(wakeCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		if slept := sleptFor(last, time.Now(), wakeCheckInterval); slept > 0 {
+			m.notifyWake(slept)
+		}
+		last = time.Now()
+	}
+}
+
+// sleptFor returns how long the system appears to have slept between two
+// clock checks interval apart, or 0 if the second ran about on time. Both
+// the wall and monotonic clocks are compared, as platforms differ in which
+// of them stops during sleep.
+func sleptFor(last, now time.Time, interval time.Duration) time.Duration {
+	elapsed := max(now.Sub(last), now.Round(0).Sub(last.Round(0)))
+	if late := elapsed - interval; late > wakeThreshold {
+		return late
+	}
+	return 0
+}
+
+// notifyWake calls the wake callback, if any
+func (m *Monitor) notifyWake(slept time.Duration) {
+	m.mu.RLock()
+	callback := m.onWake
+	m.mu.RUnlock()
+
+	if callback != nil {
+		callback(slept)
+	}
+}
+
 // startNative uses netstatus for macOS/Windows
 func (m *Monitor) startNative() error {
 	monitor := netstatus.StartMonitor(m.ctx)
@@ -71,15 +166,34 @@ func (m *Monitor) startNative() error {
 		if changed && callback != nil {
 			callback(newStatus)
 		}
+		// The OS re-reports an unchanged network on wake, when connections
+		// made before sleeping may have died without either side noticing
+		if !changed && status.Available {
+			m.notifyWake(0)
+		}
 	})
 
 	return nil
 }
 
-// startFallback uses DNS polling for Linux
+// SetProbeTargets makes the monitor poll the "host:port" addresses returned by fn
+// and report the network as available only if at least one accepts a TCP
+// connection. When fn returns no addresses the usual internet check is used.
+// It must be called before Start.
+func (m *Monitor) SetProbeTargets(fn func() []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probeTargets = fn
+}
+
+// startFallback uses polling for Linux, or on any platform when probing targets
 func (m *Monitor) startFallback() error {
+	m.mu.RLock()
+	interval := m.pollInterval
+	m.mu.RUnlock()
+
 	go func() {
-		ticker := time.NewTicker(5 * time.Second)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		// Check immediately
@@ -100,13 +214,33 @@ func (m *Monitor) startFallback() error {
 	return nil
 }
 
-// checkNetwork checks network availability via DNS
+// checkNetwork checks network availability via the probe targets or DNS
 func (m *Monitor) checkNetwork() {
-	_, err := net.LookupHost("dns.google")
+	m.mu.RLock()
+	probeTargets := m.probeTargets
+	dnsTimeout := m.dnsTimeout
+	if dnsTimeout == 0 {
+		dnsTimeout = m.pollInterval
+	}
+	m.mu.RUnlock()
+
+	var available bool
+	var targets []string
+	if probeTargets != nil {
+		targets = probeTargets()
+	}
+	if len(targets) > 0 {
+		available = anyReachable(m.ctx, targets, probeTimeout)
+	} else {
+		ctx, cancel := context.WithTimeout(m.ctx, dnsTimeout)
+		_, err := m.lookupHost(ctx, dnsCheckHost)
+		cancel()
+		available = err == nil
+	}
 
 	m.mu.Lock()
 	var newStatus NetworkStatus
-	if err == nil {
+	if available {
 		newStatus = NetworkAvailable
 	} else {
 		newStatus = NetworkUnavailable
@@ -121,6 +255,34 @@ func (m *Monitor) checkNetwork() {
 	}
 }
 
+// anyReachable dials every address concurrently and reports whether at least
+// one accepted a TCP connection within timeout
+func anyReachable(ctx context.Context, addrs []string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make(chan bool, len(addrs))
+	for _, addr := range addrs {
+		go func(addr string) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				results <- false
+				return
+			}
+			conn.Close()
+			results <- true
+		}(addr)
+	}
+
+	for range addrs {
+		if <-results {
+			return true
+		}
+	}
+	return false
+}
+
 // Stop stops the monitor
 func (m *Monitor) Stop() {
 	m.stopOnce.Do(func() {
@@ -145,6 +307,15 @@ func (m *Monitor) SetOnChange(fn func(NetworkStatus)) {
 	m.onChange = fn
 }
 
+// SetOnWake sets the callback for the system waking from sleep. slept is
+// roughly how long it slept, or 0 if the OS reported the network again
+// without saying for how long. The callback must not block.
+func (m *Monitor) SetOnWake(fn func(slept time.Duration)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onWake = fn
+}
+
 // IsAvailable returns true if network is available
 func (m *Monitor) IsAvailable() bool {
 	return m.Status() == NetworkAvailable
@@ -0,0 +1,128 @@
+package reconnect
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAnyReachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	up := ln.Addr().String()
+
+	// Grab a free port and release it so nothing is listening there
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	down := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name  string
+		addrs []string
+		want  bool
+	}{
+		{"reachable", []string{up}, true},
+		{"unreachable", []string{down}, false},
+		{"one of several reachable", []string{down, up}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anyReachable(context.Background(), tt.addrs, time.Second); got != tt.want {
+				t.Errorf("anyReachable(%v) = %v, want %v", tt.addrs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitorProbeTargets(t *testing.T) {
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	down := closed.Addr().String()
+	closed.Close()
+
+	m := NewMonitor()
+	m.SetProbeTargets(func() []string { return []string{down} })
+	m.ctx = context.Background()
+	m.checkNetwork()
+
+	if got := m.Status(); got != NetworkUnavailable {
+		t.Errorf("expected network unavailable when no target is reachable, got %v", got)
+	}
+}
+
+func TestMonitorDNSTimeout(t *testing.T) {
+	m := NewMonitor()
+	m.SetDNSTimeout(50 * time.Millisecond)
+	m.ctx = context.Background()
+	// A hung resolver only returns once the lookup's context gives up
+	m.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	m.checkNetwork()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the check to give up after the DNS timeout, took %s", elapsed)
+	}
+	if got := m.Status(); got != NetworkUnavailable {
+		t.Errorf("expected network unavailable after a timed out lookup, got %v", got)
+	}
+}
+
+func TestMonitorDNSTimeoutDefaultsToPollInterval(t *testing.T) {
+	m := NewMonitor()
+	m.SetPollInterval(2 * time.Second)
+	m.ctx = context.Background()
+	var timeout time.Duration
+	m.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		deadline, _ := ctx.Deadline()
+		timeout = time.Until(deadline)
+		return []string{"8.8.8.8"}, nil
+	}
+
+	m.checkNetwork()
+	if timeout <= time.Second || timeout > 2*time.Second {
+		t.Errorf("expected a lookup timeout of the 2s poll interval, got %s", timeout)
+	}
+	if got := m.Status(); got != NetworkAvailable {
+		t.Errorf("expected network available after a successful lookup, got %v", got)
+	}
+}
+
+func TestSleptFor(t *testing.T) {
+	// Times without a monotonic reading compare by the wall clock alone
+	last := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{"on time", 2 * time.Second, 0},
+		{"a little late", 4 * time.Second, 0},
+		{"woke from sleep", 10 * time.Minute, 10*time.Minute - 2*time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sleptFor(last, last.Add(tt.elapsed), 2*time.Second); got != tt.want {
+				t.Errorf("sleptFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Readings from time.Now carry both clocks, which agree while awake
+	now := time.Now()
+	if got := sleptFor(now, now.Add(2*time.Second), 2*time.Second); got != 0 {
+		t.Errorf("expected no sleep when both clocks agree, got %s", got)
+	}
+}
@@ -5,28 +5,47 @@ import (
 	"net"
 	"os"
 
+	"github.com/pjtatlow/bore/internal/config"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 )
 
-// AuthMethods returns SSH authentication methods in priority order:
-// 1. SSH Agent
-// 2. Key file (if provided)
-func AuthMethods(identityFile string) ([]ssh.AuthMethod, error) {
+// AuthMethods returns SSH authentication methods for a host in priority order:
+// 1. SSH Agent (host.IdentityAgent, or SSH_AUTH_SOCK)
+// 2. Key file (if provided), with its certificate if one is available
+// 3. Default key files
+//
+// host.CertFile is optional. When empty, a sibling "<identity_file>-cert.pub" is used if present.
+// With host.IdentitiesOnly and an identity file, only that key is offered.
+// checkKey, if set, is called before each key file is read; a key it
+// returns an error for is skipped like one that can't be read.
+func AuthMethods(host config.Host, checkKey func(path string) error) ([]ssh.AuthMethod, error) {
 	var methods []ssh.AuthMethod
 
+	identitiesOnly := host.IdentitiesOnly && host.IdentityFile != ""
+
 	// Try SSH Agent first
-	if agentAuth, err := agentAuthMethod(); err == nil {
-		methods = append(methods, agentAuth)
+	if !identitiesOnly {
+		if agentAuth, err := agentAuthMethod(host.IdentityAgent); err == nil {
+			methods = append(methods, agentAuth)
+		}
 	}
 
 	// Try key file if provided
-	if identityFile != "" {
-		if keyAuth, err := keyFileAuthMethod(identityFile); err == nil {
+	if host.IdentityFile != "" {
+		keyAuth, err := keyFileAuthMethod(host.IdentityFile, host.CertFile, checkKey)
+		if err == nil {
 			methods = append(methods, keyAuth)
+		} else if host.CertFile != "" || identitiesOnly {
+			// A pinned key or explicit certificate that can't be used is a config error
+			return nil, err
 		}
 	}
 
+	if identitiesOnly {
+		return methods, nil
+	}
+
 	// Try default key locations
 	defaultKeys := []string{
 		expandPath("~/.ssh/id_ed25519"),
@@ -34,7 +53,7 @@ func AuthMethods(identityFile string) ([]ssh.AuthMethod, error) {
 		expandPath("~/.ssh/id_ecdsa"),
 	}
 	for _, keyPath := range defaultKeys {
-		if keyAuth, err := keyFileAuthMethod(keyPath); err == nil {
+		if keyAuth, err := keyFileAuthMethod(keyPath, "", checkKey); err == nil {
 			methods = append(methods, keyAuth)
 		}
 	}
@@ -46,9 +65,16 @@ func AuthMethods(identityFile string) ([]ssh.AuthMethod, error) {
 	return methods, nil
 }
 
-// agentAuthMethod returns an AuthMethod that uses the SSH agent
-func agentAuthMethod() (ssh.AuthMethod, error) {
-	socket := os.Getenv("SSH_AUTH_SOCK")
+// agentAuthMethod returns an AuthMethod that uses the SSH agent. identityAgent
+// overrides SSH_AUTH_SOCK; "none" disables the agent.
+func agentAuthMethod(identityAgent string) (ssh.AuthMethod, error) {
+	socket := identityAgent
+	switch socket {
+	case "none":
+		return nil, fmt.Errorf("SSH agent disabled")
+	case "", "SSH_AUTH_SOCK":
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
 	if socket == "" {
 		return nil, fmt.Errorf("SSH_AUTH_SOCK not set")
 	}
@@ -58,12 +84,29 @@ func agentAuthMethod() (ssh.AuthMethod, error) {
 		return nil, fmt.Errorf("failed to connect to SSH agent: %w", err)
 	}
 
+	// Certificates loaded into the agent are returned as cert signers here,
+	// so they are offered to the server as-is
 	agentClient := agent.NewClient(conn)
 	return ssh.PublicKeysCallback(agentClient.Signers), nil
 }
 
 // keyFileAuthMethod returns an AuthMethod that uses a private key file
-func keyFileAuthMethod(path string) (ssh.AuthMethod, error) {
+func keyFileAuthMethod(path, certPath string, checkKey func(path string) error) (ssh.AuthMethod, error) {
+	if checkKey != nil {
+		if err := checkKey(path); err != nil {
+			return nil, err
+		}
+	}
+	signers, err := keyFileSigners(path, certPath)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.PublicKeys(signers...), nil
+}
+
+// keyFileSigners loads a private key and, if available, its certificate.
+// The certificate signer comes first so the server sees the cert before the bare key.
+func keyFileSigners(path, certPath string) ([]ssh.Signer, error) {
 	key, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read key file: %w", err)
@@ -76,7 +119,49 @@ func keyFileAuthMethod(path string) (ssh.AuthMethod, error) {
 		return nil, fmt.Errorf("failed to parse key file: %w", err)
 	}
 
-	return ssh.PublicKeys(signer), nil
+	explicit := certPath != ""
+	if !explicit {
+		certPath = path + "-cert.pub"
+		if _, err := os.Stat(certPath); err != nil {
+			return []ssh.Signer{signer}, nil
+		}
+	}
+
+	certSigner, err := certSignerFor(signer, certPath)
+	if err != nil {
+		if explicit {
+			return nil, err
+		}
+		// A broken sibling cert shouldn't prevent plain key auth
+		return []ssh.Signer{signer}, nil
+	}
+
+	return []ssh.Signer{certSigner, signer}, nil
+}
+
+// certSignerFor wraps a signer with the SSH certificate stored at certPath
+func certSignerFor(signer ssh.Signer, certPath string) (ssh.Signer, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate file: %w", err)
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an SSH certificate", certPath)
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate does not match key: %w", err)
+	}
+
+	return certSigner, nil
 }
 
 // expandPath expands ~ to the home directory
@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestKey writes a new ed25519 private key and returns its path and signer
+func writeTestKey(t *testing.T, dir string) (string, ssh.Signer) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	path := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return path, signer
+}
+
+// writeTestCert signs the key with a throwaway CA and writes the certificate
+func writeTestCert(t *testing.T, path string, key ssh.Signer) {
+	t.Helper()
+
+	_, caPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	ca, err := ssh.NewSignerFromKey(caPriv)
+	if err != nil {
+		t.Fatalf("failed to create CA signer: %v", err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             key.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"admin"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("failed to sign cert: %v", err)
+	}
+
+	if err := os.WriteFile(path, ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+}
+
+func TestKeyFileSignersWithoutCert(t *testing.T) {
+	keyPath, _ := writeTestKey(t, t.TempDir())
+
+	signers, err := keyFileSigners(keyPath, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signers) != 1 {
+		t.Fatalf("expected 1 signer, got %d", len(signers))
+	}
+	if _, ok := signers[0].PublicKey().(*ssh.Certificate); ok {
+		t.Error("expected plain key signer")
+	}
+}
+
+func TestKeyFileSignersSiblingCert(t *testing.T) {
+	keyPath, signer := writeTestKey(t, t.TempDir())
+	writeTestCert(t, keyPath+"-cert.pub", signer)
+
+	signers, err := keyFileSigners(keyPath, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("expected 2 signers, got %d", len(signers))
+	}
+	if _, ok := signers[0].PublicKey().(*ssh.Certificate); !ok {
+		t.Error("expected certificate signer to be offered first")
+	}
+}
+
+func TestKeyFileSignersExplicitCert(t *testing.T) {
+	dir := t.TempDir()
+	keyPath, signer := writeTestKey(t, dir)
+	certPath := filepath.Join(dir, "custom-cert.pub")
+	writeTestCert(t, certPath, signer)
+
+	signers, err := keyFileSigners(keyPath, certPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := signers[0].PublicKey().(*ssh.Certificate); !ok {
+		t.Error("expected certificate signer to be offered first")
+	}
+
+	// A missing explicit cert is an error
+	if _, err := keyFileSigners(keyPath, filepath.Join(dir, "missing-cert.pub")); err == nil {
+		t.Error("expected error for missing explicit certificate")
+	}
+}
+
+func TestAuthMethodsIdentitiesOnly(t *testing.T) {
+	// Put a default key under HOME so we can tell whether it gets scanned
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatalf("failed to create .ssh dir: %v", err)
+	}
+	writeTestKey(t, sshDir)
+
+	pinnedKey, _ := writeTestKey(t, t.TempDir())
+
+	methods, err := AuthMethods(config.Host{IdentityFile: pinnedKey, IdentityAgent: "none"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 2 {
+		t.Errorf("expected pinned and default keys, got %d methods", len(methods))
+	}
+
+	methods, err = AuthMethods(config.Host{IdentityFile: pinnedKey, IdentitiesOnly: true}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 {
+		t.Errorf("expected only the pinned key, got %d methods", len(methods))
+	}
+
+	// A pinned key that can't be loaded is an error rather than a silent fallback
+	if _, err := AuthMethods(config.Host{IdentityFile: pinnedKey + "-missing", IdentitiesOnly: true}, nil); err == nil {
+		t.Error("expected error for missing pinned key")
+	}
+}
+
+func TestAgentAuthMethodDisabled(t *testing.T) {
+	if _, err := agentAuthMethod("none"); err == nil {
+		t.Error("expected agent to be disabled")
+	}
+}
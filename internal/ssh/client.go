@@ -2,8 +2,10 @@ package ssh
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,16 +20,52 @@ type Client struct {
 	host   config.Host
 	cfg    *config.Config
 
-	keepAliveStop chan struct{}
-	onDisconnect  func(error)
+	hostKeyCallback ssh.HostKeyCallback
+	warnLogger      WarnLogger
+	keepAliveStop   chan struct{}
+	onDisconnect    func(error)
+	connectedAt     time.Time
+	rtt             time.Duration
 }
 
+// Option configures a Client
+type Option func(*Client)
+
+// WithHostKeyCallback sets how server host keys are verified, for both the
+// target host and any ProxyJump host. The default checks known_hosts.
+func WithHostKeyCallback(cb ssh.HostKeyCallback) Option {
+	return func(c *Client) {
+		c.hostKeyCallback = cb
+	}
+}
+
+// WarnLogger receives warnings about the client's setup, such as a key file
+// with loose permissions
+type WarnLogger interface {
+	Warnf(format string, args ...interface{})
+}
+
+// WithWarnLogger sets where warnings about the client's setup are logged
+func WithWarnLogger(l WarnLogger) Option {
+	return func(c *Client) {
+		c.warnLogger = l
+	}
+}
+
+// warnedKeys remembers key permission warnings already logged, so a host
+// that keeps reconnecting doesn't repeat them
+var warnedKeys sync.Map
+
 // NewClient creates a new SSH client wrapper
-func NewClient(host config.Host, cfg *config.Config) *Client {
-	return &Client{
+func NewClient(host config.Host, cfg *config.Config, opts ...Option) *Client {
+	c := &Client{
 		host: host,
 		cfg:  cfg,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Connect establishes the SSH connection
@@ -35,11 +73,19 @@ func (c *Client) Connect(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	authMethods, err := AuthMethods(c.host.IdentityFile)
+	authMethods, err := AuthMethods(c.host, c.checkKey)
 	if err != nil {
 		return fmt.Errorf("failed to get auth methods: %w", err)
 	}
 
+	hostKeyCallback := c.hostKeyCallback
+	if hostKeyCallback == nil {
+		hostKeyCallback, err = KnownHostsCallback()
+		if err != nil {
+			return fmt.Errorf("failed to set up host key verification: %w", err)
+		}
+	}
+
 	user := c.host.User
 	if user == "" {
 		user = "root"
@@ -48,11 +94,11 @@ func (c *Client) Connect(ctx context.Context) error {
 	sshConfig := &ssh.ClientConfig{
 		User:            user,
 		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // TODO: implement proper host key verification
-		Timeout:         30 * time.Second,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         c.connectTimeout(),
 	}
 
-	addr := fmt.Sprintf("%s:%d", c.host.Hostname, c.host.Port)
+	addr := hostAddr(c.host)
 
 	// Handle ProxyJump if configured
 	var conn net.Conn
@@ -73,18 +119,47 @@ func (c *Client) Connect(ctx context.Context) error {
 	}
 
 	c.client = ssh.NewClient(sshConn, chans, reqs)
+	c.connectedAt = time.Now()
 
-	// Start keepalive
+	// Start keepalive, unless it's disabled for this host
 	c.keepAliveStop = make(chan struct{})
-	go c.keepAlive()
+	if interval := c.cfg.KeepAliveInterval(c.host); interval > 0 {
+		go c.keepAlive(c.keepAliveStop, interval)
+	}
 
 	return nil
 }
 
+// checkKey warns about a key file other users can read, or refuses it when
+// defaults.strict_key_permissions is set
+func (c *Client) checkKey(path string) error {
+	err := CheckKeyPermissions(path)
+	if err == nil {
+		return nil
+	}
+	strict := c.cfg != nil && c.cfg.Defaults.StrictKeyPermissions
+	if _, warned := warnedKeys.LoadOrStore(err.Error(), true); !warned && c.warnLogger != nil {
+		if strict {
+			c.warnLogger.Warnf("Not using %v", err)
+		} else {
+			c.warnLogger.Warnf("%v", err)
+		}
+	}
+	if strict {
+		return err
+	}
+	return nil
+}
+
+// hostAddr returns the host:port to dial for a host, bracketing IPv6 literals
+func hostAddr(host config.Host) string {
+	return net.JoinHostPort(host.Hostname, strconv.Itoa(host.Port))
+}
+
 // dialDirect connects directly to the target host
 func (c *Client) dialDirect(ctx context.Context, addr string) (net.Conn, error) {
 	dialer := &net.Dialer{
-		Timeout: 30 * time.Second,
+		Timeout: c.connectTimeout(),
 	}
 	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
@@ -102,7 +177,7 @@ func (c *Client) dialViaProxy(ctx context.Context, targetAddr string, sshConfig
 	}
 
 	proxyHost := config.ResolveHost(c.host.ProxyJump, config.Host{}, sshReader)
-	proxyAddr := fmt.Sprintf("%s:%d", proxyHost.Hostname, proxyHost.Port)
+	proxyAddr := hostAddr(proxyHost)
 
 	// Connect to proxy
 	proxyConn, err := c.dialDirect(ctx, proxyAddr)
@@ -114,8 +189,8 @@ func (c *Client) dialViaProxy(ctx context.Context, targetAddr string, sshConfig
 	proxySSHConfig := &ssh.ClientConfig{
 		User:            proxyHost.User,
 		Auth:            sshConfig.Auth,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         30 * time.Second,
+		HostKeyCallback: sshConfig.HostKeyCallback,
+		Timeout:         c.connectTimeout(),
 	}
 	if proxySSHConfig.User == "" {
 		proxySSHConfig.User = sshConfig.User
@@ -139,19 +214,32 @@ func (c *Client) dialViaProxy(ctx context.Context, targetAddr string, sshConfig
 	return conn, nil
 }
 
-// keepAlive sends periodic keepalive requests
-func (c *Client) keepAlive() {
-	interval := c.cfg.Defaults.KeepAlive.Interval
-	if interval <= 0 {
-		interval = 30 * time.Second
+// connectTimeout returns the dial and handshake timeout for this host
+func (c *Client) connectTimeout() time.Duration {
+	if c.host.ConnectTimeout > 0 {
+		return c.host.ConnectTimeout
+	}
+	return 30 * time.Second
+}
+
+// keepAlive sends keepalive requests every interval until stop is closed.
+// A keepalive that goes unanswered for a whole interval counts as missed,
+// and the connection is reported lost after max_missed in a row. An error
+// from the transport means the connection is already gone, so it is
+// reported straight away.
+func (c *Client) keepAlive(stop <-chan struct{}, interval time.Duration) {
+	maxMissed := c.cfg.Defaults.KeepAlive.MaxMissed
+	if maxMissed <= 0 {
+		maxMissed = 1
 	}
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	missed := 0
 	for {
 		select {
-		case <-c.keepAliveStop:
+		case <-stop:
 			return
 		case <-ticker.C:
 			c.mu.RLock()
@@ -162,14 +250,89 @@ func (c *Client) keepAlive() {
 				return
 			}
 
-			_, _, err := client.SendRequest("keepalive@golang.com", true, nil)
-			if err != nil {
+			start := time.Now()
+			err := c.pingWithin(client, interval, stop)
+			if errors.Is(err, errStopped) {
+				return
+			}
+			if err == nil {
+				c.recordRTT(time.Since(start))
+				missed = 0
+				continue
+			}
+			if !errors.Is(err, errPingTimeout) {
 				if c.onDisconnect != nil {
-					c.onDisconnect(err)
+					c.onDisconnect(fmt.Errorf("keepalive failed: %w", err))
 				}
 				return
 			}
+
+			// Tolerate occasional lost replies on lossy links
+			missed++
+			if missed < maxMissed {
+				continue
+			}
+
+			if c.onDisconnect != nil {
+				c.onDisconnect(fmt.Errorf("%d consecutive keepalives went unanswered", missed))
+			}
+			return
+		}
+	}
+}
+
+var (
+	// errPingTimeout is returned by pingWithin when no answer arrives in time
+	errPingTimeout = errors.New("keepalive timed out")
+
+	// errStopped is returned by pingWithin when it is told to stop waiting
+	errStopped = errors.New("keepalive stopped")
+)
+
+// pingWithin pings the server, giving up after timeout or once stop is
+// closed. A ping given up on is left to finish in the background, which it
+// does once the server answers or the connection closes.
+func (c *Client) pingWithin(client *ssh.Client, timeout time.Duration, stop <-chan struct{}) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.ping(client)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return errPingTimeout
+	case <-stop:
+		return errStopped
+	}
+}
+
+// ping checks the server is still there using the host's keepalive method.
+// Any answer counts, including a refusal: a server that rejects the request
+// or channel is alive, so only a transport error is returned.
+func (c *Client) ping(client *ssh.Client) error {
+	switch c.cfg.KeepAliveMethod(c.host) {
+	case config.KeepAliveSession:
+		ch, reqs, err := client.OpenChannel("session", nil)
+		var rejected *ssh.OpenChannelError
+		if errors.As(err, &rejected) {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
+		go ssh.DiscardRequests(reqs)
+		ch.Close()
+		return nil
+	case config.KeepAliveOpenSSH:
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		return err
+	default:
+		_, _, err := client.SendRequest("keepalive@golang.com", true, nil)
+		return err
 	}
 }
 
@@ -232,6 +395,13 @@ func (c *Client) IsConnected() bool {
 	return c.client != nil
 }
 
+// ConnectedAt returns when the SSH connection was established
+func (c *Client) ConnectedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connectedAt
+}
+
 // CheckHealth performs an immediate keepalive check with a timeout and returns any error.
 // If the check fails, the onDisconnect callback is called.
 func (c *Client) CheckHealth(timeout time.Duration) error {
@@ -243,27 +413,32 @@ func (c *Client) CheckHealth(timeout time.Duration) error {
 		return fmt.Errorf("not connected")
 	}
 
-	// Run keepalive with timeout
-	errCh := make(chan error, 1)
-	go func() {
-		_, _, err := client.SendRequest("keepalive@golang.com", true, nil)
-		errCh <- err
-	}()
-
-	select {
-	case err := <-errCh:
-		if err != nil {
-			if c.onDisconnect != nil {
-				c.onDisconnect(err)
-			}
-			return err
+	// Run keepalive with timeout, timing the round trip
+	start := time.Now()
+	if err := c.pingWithin(client, timeout, nil); err != nil {
+		if errors.Is(err, errPingTimeout) {
+			err = fmt.Errorf("health check timed out")
 		}
-		return nil
-	case <-time.After(timeout):
-		err := fmt.Errorf("health check timed out")
 		if c.onDisconnect != nil {
 			c.onDisconnect(err)
 		}
 		return err
 	}
+	c.recordRTT(time.Since(start))
+	return nil
+}
+
+// RTT returns the round-trip time of the most recent successful keepalive,
+// or 0 if none has completed yet
+func (c *Client) RTT() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rtt
+}
+
+// recordRTT stores the round-trip time of a successful keepalive
+func (c *Client) recordRTT(rtt time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rtt = rtt
 }
@@ -0,0 +1,234 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestHostAddr(t *testing.T) {
+	tests := []struct {
+		host config.Host
+		want string
+	}{
+		{config.Host{Hostname: "bastion.example.com", Port: 22}, "bastion.example.com:22"},
+		{config.Host{Hostname: "10.0.0.5", Port: 2222}, "10.0.0.5:2222"},
+		{config.Host{Hostname: "::1", Port: 22}, "[::1]:22"},
+		{config.Host{Hostname: "fe80::1%eth0", Port: 22}, "[fe80::1%eth0]:22"},
+	}
+
+	for _, tt := range tests {
+		if got := hostAddr(tt.host); got != tt.want {
+			t.Errorf("hostAddr(%q, %d) = %q, want %q", tt.host.Hostname, tt.host.Port, got, tt.want)
+		}
+	}
+}
+
+// newRefusingServer connects a client to a local SSH server that
+// refuses every global request and channel, reporting each one it refuses
+func newRefusingServer(t *testing.T) (*ssh.Client, <-chan string) {
+	t.Helper()
+	_, signer := writeTestKey(t, t.TempDir())
+	serverCfg := &ssh.ServerConfig{NoClientAuth: true}
+	serverCfg.AddHostKey(signer)
+
+	// A TCP pair rather than net.Pipe, since both sides send their version
+	// before reading and net.Pipe has no buffer
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	seen := make(chan string, 10)
+	go func() {
+		serverConn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		_, chans, reqs, err := ssh.NewServerConn(serverConn, serverCfg)
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range reqs {
+				seen <- req.Type
+				req.Reply(false, nil)
+			}
+		}()
+		for ch := range chans {
+			seen <- ch.ChannelType()
+			ch.Reject(ssh.Prohibited, "no sessions")
+		}
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(clientConn, ln.Addr().String(), &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+	t.Cleanup(func() { client.Close() })
+	return client, seen
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		want   string
+	}{
+		{"default", "", "keepalive@golang.com"},
+		{"golang", config.KeepAliveGolang, "keepalive@golang.com"},
+		{"openssh", config.KeepAliveOpenSSH, "keepalive@openssh.com"},
+		{"session", config.KeepAliveSession, "session"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, seen := newRefusingServer(t)
+			c := NewClient(config.Host{KeepAliveMethod: tt.method}, config.DefaultConfig())
+
+			// A refusal still shows the server is alive
+			if err := c.ping(client); err != nil {
+				t.Fatalf("expected a refused keepalive to succeed, got %v", err)
+			}
+			if got := <-seen; got != tt.want {
+				t.Errorf("expected the server to see %q, got %q", tt.want, got)
+			}
+
+			client.Close()
+			if err := c.ping(client); err == nil {
+				t.Error("expected an error once the connection is closed")
+			}
+		})
+	}
+}
+
+// newSwallowingServer connects a client to a local SSH server that never
+// answers the first swallow session channels opened, as if the replies were
+// lost, and refuses the rest. It also returns the server's end of the
+// connection, so a test can drop it.
+func newSwallowingServer(t *testing.T, swallow int) (*ssh.Client, net.Conn) {
+	t.Helper()
+	_, signer := writeTestKey(t, t.TempDir())
+	serverCfg := &ssh.ServerConfig{NoClientAuth: true}
+	serverCfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		serverConn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- serverConn
+		_, chans, reqs, err := ssh.NewServerConn(serverConn, serverCfg)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for ch := range chans {
+			if swallow > 0 {
+				swallow--
+				continue
+			}
+			ch.Reject(ssh.Prohibited, "no sessions")
+		}
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(clientConn, ln.Addr().String(), &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+	t.Cleanup(func() { client.Close() })
+	return client, <-accepted
+}
+
+// startKeepAlive runs keepalives on client every interval, returning where
+// a lost connection is reported
+func startKeepAlive(t *testing.T, client *ssh.Client, method string, maxMissed int, interval time.Duration) <-chan error {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Defaults.KeepAlive.MaxMissed = maxMissed
+	c := NewClient(config.Host{KeepAliveMethod: method}, cfg)
+	c.client = client
+	lost := make(chan error, 1)
+	c.SetOnDisconnect(func(err error) { lost <- err })
+
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	go c.keepAlive(stop, interval)
+	return lost
+}
+
+func TestKeepAliveToleratesLostReplies(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	tests := []struct {
+		name     string
+		swallow  int
+		wantLost bool
+	}{
+		{"fewer than max_missed", 2, false},
+		{"max_missed in a row", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newSwallowingServer(t, tt.swallow)
+			lost := startKeepAlive(t, client, config.KeepAliveSession, 3, interval)
+
+			select {
+			case err := <-lost:
+				if !tt.wantLost {
+					t.Fatalf("expected the connection to stay up, got %v", err)
+				}
+				if !strings.Contains(err.Error(), "3 consecutive keepalives went unanswered") {
+					t.Errorf("expected unanswered keepalives to be reported, got %v", err)
+				}
+			case <-time.After(10 * interval):
+				if tt.wantLost {
+					t.Fatal("expected the connection to be reported lost")
+				}
+			}
+		})
+	}
+}
+
+func TestKeepAliveReportsClosedTransportAtOnce(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	client, serverConn := newSwallowingServer(t, 0)
+
+	// With this many misses allowed, only a transport error is reported in time
+	lost := startKeepAlive(t, client, "", 100, interval)
+	serverConn.Close()
+
+	select {
+	case err := <-lost:
+		if !strings.HasPrefix(err.Error(), "keepalive failed") || errors.Is(err, errPingTimeout) {
+			t.Errorf("expected the transport error to be reported, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the closed connection to be reported on the first failed keepalive")
+	}
+}
@@ -0,0 +1,56 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// systemKnownHostsFile is consulted in addition to the user's known_hosts
+const systemKnownHostsFile = "/etc/ssh/ssh_known_hosts"
+
+// KnownHostsCallback returns a host key callback that verifies hosts against
+// ~/.ssh/known_hosts and the system-wide known hosts file
+func KnownHostsCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return knownHostsCallback(filepath.Join(home, ".ssh", "known_hosts"), systemKnownHostsFile)
+}
+
+// knownHostsCallback builds a callback from whichever of the given files
+// exist, with errors that explain how to trust an unknown host
+func knownHostsCallback(paths ...string) (ssh.HostKeyCallback, error) {
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("no known_hosts file found (connect once with ssh to trust the host)")
+	}
+
+	callback, err := knownhosts.New(existing...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return fmt.Errorf("host key for %s is not in known_hosts (connect once with ssh to trust it): %w", hostname, err)
+			}
+			return fmt.Errorf("host key for %s does not match known_hosts, possible man-in-the-middle attack: %w", hostname, err)
+		}
+		return err
+	}, nil
+}
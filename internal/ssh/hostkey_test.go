@@ -0,0 +1,81 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestKnownHostsCallback(t *testing.T) {
+	dir := t.TempDir()
+	_, trusted := writeTestKey(t, dir)
+	_, other := writeTestKey(t, t.TempDir())
+
+	knownHosts := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("bastion.example.com:22")}, trusted.PublicKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known_hosts: %v", err)
+	}
+
+	callback, err := knownHostsCallback(knownHosts, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatalf("knownHostsCallback failed: %v", err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	tests := []struct {
+		name    string
+		host    string
+		key     ssh.PublicKey
+		wantErr string
+	}{
+		{"known host", "bastion.example.com:22", trusted.PublicKey(), ""},
+		{"unknown host", "other.example.com:22", trusted.PublicKey(), "not in known_hosts"},
+		{"changed key", "bastion.example.com:22", other.PublicKey(), "does not match known_hosts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := callback(tt.host, remote, tt.key)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected key to be accepted, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestKnownHostsCallbackNoFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := knownHostsCallback(filepath.Join(dir, "known_hosts")); err == nil {
+		t.Fatal("expected error when no known_hosts file exists")
+	}
+}
+
+func TestWithHostKeyCallback(t *testing.T) {
+	called := false
+	cb := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		called = true
+		return nil
+	}
+
+	c := NewClient(config.Host{}, nil, WithHostKeyCallback(cb))
+	if c.hostKeyCallback == nil {
+		t.Fatal("expected host key callback to be set")
+	}
+	c.hostKeyCallback("", nil, nil)
+	if !called {
+		t.Error("expected injected callback to be used")
+	}
+}
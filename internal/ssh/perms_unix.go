@@ -0,0 +1,26 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"fmt"
+	"os"
+)
+
+// CheckKeyPermissions returns an error if the private key at path can be
+// read by its group or other users, which OpenSSH refuses. A missing file is
+// not an error.
+func CheckKeyPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat key file %s: %w", path, err)
+	}
+
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return fmt.Errorf("key file %s is accessible by other users (mode %04o); run 'chmod 600 %s'", path, mode, path)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+func TestCheckKeyPermissions(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		mode    os.FileMode
+		wantErr bool
+	}{
+		{0600, false},
+		{0400, false},
+		{0640, true},
+		{0644, true},
+		{0604, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%04o", tt.mode), func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("id_%04o", tt.mode))
+			if err := os.WriteFile(path, []byte("key"), tt.mode); err != nil {
+				t.Fatalf("failed to write key: %v", err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatalf("failed to chmod key: %v", err)
+			}
+
+			err := CheckKeyPermissions(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckKeyPermissions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "chmod 600 "+path) {
+				t.Errorf("expected the error to suggest chmod 600, got %q", err)
+			}
+		})
+	}
+
+	if err := CheckKeyPermissions(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("expected no error for a missing key, got %v", err)
+	}
+}
+
+type recordingWarnLogger struct {
+	warnings []string
+}
+
+func (r *recordingWarnLogger) Warnf(format string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, args...))
+}
+
+func TestClientCheckKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, []byte("key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatalf("failed to chmod key: %v", err)
+	}
+
+	logger := &recordingWarnLogger{}
+	cfg := config.DefaultConfig()
+	c := NewClient(config.Host{}, cfg, WithWarnLogger(logger))
+
+	// Loose permissions are only a warning by default, logged once
+	for i := 0; i < 2; i++ {
+		if err := c.checkKey(path); err != nil {
+			t.Fatalf("expected the key to be allowed, got %v", err)
+		}
+	}
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], path) {
+		t.Errorf("expected one warning naming the key, got %q", logger.warnings)
+	}
+
+	cfg.Defaults.StrictKeyPermissions = true
+	if err := c.checkKey(path); err == nil {
+		t.Error("expected the key to be refused with strict_key_permissions")
+	}
+}
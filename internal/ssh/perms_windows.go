@@ -0,0 +1,9 @@
+//go:build windows
+
+package ssh
+
+// CheckKeyPermissions is a no-op on Windows, where access to key files is
+// governed by ACLs rather than Unix permission bits
+func CheckKeyPermissions(path string) error {
+	return nil
+}
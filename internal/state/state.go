@@ -135,6 +135,34 @@ func (s *State) RemoveGroup(name string) {
 	}
 }
 
+// HasTunnel reports whether a tunnel is in the active list via host, or via
+// any host if host is empty
+func (s *State) HasTunnel(name, host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.ActiveTunnels {
+		if t.Name == name && (host == "" || t.Host == host) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGroup reports whether a group is in the active list via host, or via
+// any host if host is empty
+func (s *State) HasGroup(name, host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range s.ActiveGroups {
+		if g.Name == name && (host == "" || g.Host == host) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetActiveTunnels returns a copy of active tunnel states
 func (s *State) GetActiveTunnels() []TunnelState {
 	s.mu.RLock()
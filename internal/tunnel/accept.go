@@ -0,0 +1,124 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	// acceptErrorLimit is how many Accept errors within acceptErrorWindow
+	// mark a listener as broken
+	acceptErrorLimit  = 10
+	acceptErrorWindow = time.Second
+
+	// acceptBackoffMin and acceptBackoffMax bound the pause after a
+	// temporary Accept error, doubling while the errors continue
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+
+	// acceptLogInterval limits how often Accept errors are logged
+	acceptLogInterval = 10 * time.Second
+)
+
+// acceptGuard detects a listener that keeps failing Accept in quick
+// succession, so an accept loop can give up instead of spinning forever
+type acceptGuard struct {
+	failures   int
+	first      time.Time
+	delay      time.Duration
+	lastLog    time.Time
+	suppressed int
+}
+
+// fail records an Accept error and reports whether the listener should be
+// considered broken
+func (g *acceptGuard) fail(err error, now time.Time) bool {
+	// A listener closed out from under us will never recover
+	if errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	if g.failures == 0 || now.Sub(g.first) > acceptErrorWindow {
+		g.failures = 0
+		g.first = now
+	}
+	g.failures++
+	return g.failures >= acceptErrorLimit
+}
+
+// backoff returns how long to pause after a temporary Accept error
+func (g *acceptGuard) backoff() time.Duration {
+	if g.delay == 0 {
+		g.delay = acceptBackoffMin
+	} else {
+		g.delay = min(g.delay*2, acceptBackoffMax)
+	}
+	return g.delay
+}
+
+// shouldLog reports whether an Accept error should be logged now, and how
+// many errors were skipped since the last one that was
+func (g *acceptGuard) shouldLog(now time.Time) (bool, int) {
+	if !g.lastLog.IsZero() && now.Sub(g.lastLog) < acceptLogInterval {
+		g.suppressed++
+		return false, 0
+	}
+	suppressed := g.suppressed
+	g.lastLog = now
+	g.suppressed = 0
+	return true, suppressed
+}
+
+// reset clears the failure count after a successful Accept
+func (g *acceptGuard) reset() {
+	g.failures = 0
+	g.delay = 0
+}
+
+// isTemporaryAcceptError reports whether an Accept error is likely to clear
+// up on its own, such as running out of file descriptors
+func isTemporaryAcceptError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EMFILE, syscall.ENFILE, syscall.ENOBUFS, syscall.ENOMEM} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// handleAcceptError records a failed Accept and reports whether the accept
+// loop should stop. Temporary errors pause the loop instead of counting
+// toward the guard, so fd exhaustion doesn't turn into a busy loop.
+func (t *baseTunnel) handleAcceptError(ctx context.Context, guard *acceptGuard, err error) bool {
+	now := time.Now()
+	t.stats.IncrementAcceptErrors()
+
+	if ok, suppressed := guard.shouldLog(now); ok {
+		if suppressed > 0 {
+			t.logWarn("Tunnel '%s' failed to accept a connection: %v (%d similar errors suppressed)", t.name, err, suppressed)
+		} else {
+			t.logWarn("Tunnel '%s' failed to accept a connection: %v", t.name, err)
+		}
+	}
+
+	if isTemporaryAcceptError(err) {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-time.After(guard.backoff()):
+			return false
+		}
+	}
+
+	// Stop and report the tunnel as failed rather than spinning on a broken
+	// listener; the daemon will rebuild it
+	if guard.fail(err, now) {
+		t.SetStatus(StatusError, wrapf(ErrAcceptFailed, err, "listener stopped accepting connections"))
+		return true
+	}
+	return false
+}
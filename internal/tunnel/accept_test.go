@@ -0,0 +1,103 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestAcceptGuard(t *testing.T) {
+	errTemp := errors.New("too many open files")
+	start := time.Now()
+
+	var g acceptGuard
+	for i := 1; i < acceptErrorLimit; i++ {
+		if g.fail(errTemp, start) {
+			t.Fatalf("guard tripped after %d errors, limit is %d", i, acceptErrorLimit)
+		}
+	}
+	if !g.fail(errTemp, start) {
+		t.Fatal("expected guard to trip at the limit")
+	}
+
+	// Errors spread out beyond the window don't accumulate
+	g = acceptGuard{}
+	for i := 0; i < acceptErrorLimit*2; i++ {
+		if g.fail(errTemp, start.Add(time.Duration(i)*2*acceptErrorWindow)) {
+			t.Fatal("expected spaced-out errors not to trip the guard")
+		}
+	}
+
+	// A successful Accept resets the count
+	g = acceptGuard{}
+	for i := 1; i < acceptErrorLimit; i++ {
+		g.fail(errTemp, start)
+	}
+	g.reset()
+	if g.fail(errTemp, start) {
+		t.Fatal("expected reset to clear failures")
+	}
+
+	// A closed listener trips immediately
+	g = acceptGuard{}
+	if !g.fail(net.ErrClosed, start) {
+		t.Fatal("expected net.ErrClosed to trip the guard")
+	}
+}
+
+func TestAcceptGuardBackoff(t *testing.T) {
+	var g acceptGuard
+	want := acceptBackoffMin
+	for i := 0; i < 20; i++ {
+		if got := g.backoff(); got != want {
+			t.Fatalf("backoff #%d = %v, want %v", i, got, want)
+		}
+		want = min(want*2, acceptBackoffMax)
+	}
+
+	g.reset()
+	if got := g.backoff(); got != acceptBackoffMin {
+		t.Errorf("expected reset to restart backoff, got %v", got)
+	}
+}
+
+func TestAcceptGuardShouldLog(t *testing.T) {
+	start := time.Now()
+	var g acceptGuard
+
+	if ok, _ := g.shouldLog(start); !ok {
+		t.Fatal("expected the first error to be logged")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := g.shouldLog(start.Add(time.Second)); ok {
+			t.Fatal("expected errors within the interval to be suppressed")
+		}
+	}
+	ok, suppressed := g.shouldLog(start.Add(acceptLogInterval))
+	if !ok || suppressed != 3 {
+		t.Errorf("shouldLog after interval = %v, %d; want true, 3", ok, suppressed)
+	}
+}
+
+func TestIsTemporaryAcceptError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("accept: %w", syscall.EMFILE), true},
+		{&net.OpError{Op: "accept", Err: os.NewSyscallError("accept", syscall.ENFILE)}, true},
+		{os.ErrDeadlineExceeded, true},
+		{net.ErrClosed, false},
+		{errors.New("EOF"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isTemporaryAcceptError(tt.err); got != tt.want {
+			t.Errorf("isTemporaryAcceptError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tunnel
+
+import (
+	"fmt"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// configState is the manager's cached copy of the config file, so operations
+// don't reread it and a single operation sees one consistent version
+type configState struct {
+	load     func() (*config.Config, error) // reads the config; config.Load if nil
+	loaded   bool
+	current  *config.Config // config operations use; nil if it never loaded
+	lastGood *config.Config // last config that parsed and validated cleanly
+	err      error          // why the file last failed to load or validate, if it did
+}
+
+// LoadConfig rereads and validates the config file and refreshes the cached
+// config. A config that parses but fails validation is cached and returned
+// along with the validation error; one that doesn't parse leaves the last
+// good config in place.
+func (m *Manager) LoadConfig() (*config.Config, error) {
+	m.configMu.Lock()
+	load := m.config.load
+	m.configMu.Unlock()
+	if load == nil {
+		load = config.Load
+	}
+
+	cfg, err := load()
+	if err != nil {
+		err = fmt.Errorf("failed to load config: %w", err)
+		m.configMu.Lock()
+		m.config.loaded = true
+		m.config.current = m.config.lastGood
+		m.config.err = err
+		m.configMu.Unlock()
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	m.bandwidth.SetRate(cfg.Defaults.MaxRate)
+	m.configMu.Lock()
+	m.config.loaded = true
+	m.config.current = cfg
+	m.config.err = err
+	if err == nil {
+		m.config.lastGood = cfg
+	}
+	m.configMu.Unlock()
+	return cfg, err
+}
+
+// SetConfigLoader replaces how LoadConfig reads the config, which is from
+// the config file by default, e.g. to run tunnels from a config built in code
+func (m *Manager) SetConfigLoader(load func() (*config.Config, error)) {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	m.config.load = load
+}
+
+// Config returns the cached config, loading it on first use. If the file
+// didn't parse when it was last loaded, this is the last config that did.
+// Validation errors don't block operations; they're reported by ConfigError.
+func (m *Manager) Config() (*config.Config, error) {
+	m.configMu.Lock()
+	loaded := m.config.loaded
+	m.configMu.Unlock()
+	if !loaded {
+		m.LoadConfig()
+	}
+
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	if m.config.current == nil {
+		return nil, m.config.err
+	}
+	return m.config.current, nil
+}
+
+// ConfigError returns why the config file last failed to load or validate,
+// or nil if it was fine
+func (m *Manager) ConfigError() error {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	return m.config.err
+}
@@ -0,0 +1,106 @@
+package tunnel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+const (
+	brokenConfig = "tunnels: [not, a, map\n"
+	webConfig    = `
+tunnels:
+  web:
+    type: local
+    local_port: 8080
+    remote_port: 80
+`
+)
+
+func TestManagerConfigFallsBackToLastGood(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	path := filepath.Join(home, ".bore", "config.yaml")
+	m := &Manager{bandwidth: NewRateLimiter(0)}
+
+	// Nothing to fall back to before the config has ever loaded
+	writeTestFile(t, path, brokenConfig)
+	if _, err := m.Config(); err == nil {
+		t.Fatal("expected an error for a config that doesn't parse")
+	}
+	if m.ConfigError() == nil {
+		t.Error("expected the parse error to be reported")
+	}
+
+	writeTestFile(t, path, webConfig)
+	if _, err := m.LoadConfig(); err != nil {
+		t.Fatalf("failed to load a good config: %v", err)
+	}
+	if err := m.ConfigError(); err != nil {
+		t.Errorf("expected no config error, got %v", err)
+	}
+
+	// A broken edit keeps the last good config in use
+	writeTestFile(t, path, brokenConfig)
+	if _, err := m.LoadConfig(); err == nil {
+		t.Fatal("expected an error reloading a config that doesn't parse")
+	}
+	cfg, err := m.Config()
+	if err != nil {
+		t.Fatalf("expected the last good config, got %v", err)
+	}
+	if _, ok := cfg.GetTunnel("web"); !ok {
+		t.Error("expected the last good config to include tunnel 'web'")
+	}
+	if m.ConfigError() == nil {
+		t.Error("expected the parse error to be reported while falling back")
+	}
+
+	// A config that parses but is invalid is used as is, with its error reported
+	writeTestFile(t, path, `
+tunnels:
+  api:
+    type: local
+    local_port: 70000
+    remote_port: 80
+`)
+	if _, err := m.LoadConfig(); err == nil {
+		t.Error("expected the validation error to be returned")
+	}
+	cfg, err = m.Config()
+	if err != nil {
+		t.Fatalf("expected an invalid config to still be used, got %v", err)
+	}
+	if _, ok := cfg.GetTunnel("api"); !ok {
+		t.Error("expected the current config, not the last good one")
+	}
+	if m.ConfigError() == nil {
+		t.Error("expected the validation error to be reported")
+	}
+}
+
+func TestManagerConfigIsCached(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	path := filepath.Join(home, ".bore", "config.yaml")
+	m := &Manager{bandwidth: NewRateLimiter(0)}
+
+	writeTestFile(t, path, webConfig)
+	first, err := m.Config()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	// Edits aren't seen until the config is reloaded
+	writeTestFile(t, path, brokenConfig)
+	cached, err := m.Config()
+	if err != nil || cached != first {
+		t.Fatalf("expected the cached config, got %p (%v)", cached, err)
+	}
+	if m.ConfigError() != nil {
+		t.Error("expected no config error before a reload")
+	}
+}
@@ -0,0 +1,52 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by the Manager or reported through tunnel status,
+// for use with errors.Is
+var (
+	ErrTunnelNotFound  = errors.New("tunnel not found")
+	ErrGroupNotFound   = errors.New("group not found")
+	ErrNotRunning      = errors.New("tunnel not running")
+	ErrPortConflict    = errors.New("port conflict")
+	ErrHostUnreachable = errors.New("host unreachable")
+	ErrAcceptFailed    = errors.New("listener stopped accepting connections")
+	ErrForwardDenied   = errors.New("remote forwarding denied by server")
+	ErrRemotePortBusy  = errors.New("remote port still bound")
+	ErrPaused          = errors.New("tunnel paused")
+)
+
+// kindError carries a human-readable message while matching a sentinel
+// error and, optionally, an underlying cause
+type kindError struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func (e *kindError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%s: %v", e.msg, e.err)
+	}
+	return e.msg
+}
+
+func (e *kindError) Unwrap() []error {
+	if e.err != nil {
+		return []error{e.kind, e.err}
+	}
+	return []error{e.kind}
+}
+
+// errorf returns an error matching kind with a formatted message
+func errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// wrapf returns an error matching kind that wraps err, formatted as "msg: err"
+func wrapf(kind error, err error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...), err: err}
+}
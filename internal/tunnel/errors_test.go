@@ -0,0 +1,31 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := wrapf(ErrHostUnreachable, cause, "failed to connect to host '%s'", "bastion")
+
+	if got, want := err.Error(), "failed to connect to host 'bastion': connection refused"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if !errors.Is(err, ErrHostUnreachable) {
+		t.Error("expected error to match ErrHostUnreachable")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected error to match its cause")
+	}
+
+	// Sentinels survive further wrapping
+	wrapped := fmt.Errorf("failed to start tunnel '%s': %w", "db", errorf(ErrTunnelNotFound, "tunnel '%s' not found", "db"))
+	if !errors.Is(wrapped, ErrTunnelNotFound) {
+		t.Error("expected wrapped error to match ErrTunnelNotFound")
+	}
+	if errors.Is(wrapped, ErrPortConflict) {
+		t.Error("expected wrapped error not to match ErrPortConflict")
+	}
+}
@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// lazyConn holds a lazy tunnel's SSH client. It connects when the tunnel's
+// first connection arrives and lets go of the client once the tunnel has had
+// no open connections for idleTimeout.
+//
+// connect and release are called without mu held, so they may take the
+// manager's lock; the manager may call stop, reset, and inUse with its lock
+// held.
+type lazyConn struct {
+	connect     func(ctx context.Context) (SSHClient, error)
+	release     func()
+	setStatus   func(Status, error)
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	client  SSHClient     // nil while idle
+	pending chan struct{} // closed when the connect in flight finishes
+	err     error         // why the last connect failed
+	active  int           // forwarded connections open
+	idle    *time.Timer   // releases the client once it fires
+	stopped bool
+}
+
+// acquire returns the SSH client, connecting first if there is none. Every
+// call must be followed by done, even if it fails.
+func (l *lazyConn) acquire(ctx context.Context) (SSHClient, error) {
+	l.mu.Lock()
+	l.active++
+	if l.idle != nil {
+		l.idle.Stop()
+		l.idle = nil
+	}
+	if l.client != nil {
+		client := l.client
+		l.mu.Unlock()
+		return client, nil
+	}
+	// Connect outside the caller, so a tunnel being stopped doesn't wait on
+	// the manager's lock through it
+	if l.pending == nil {
+		l.pending = make(chan struct{})
+		l.setStatus(StatusConnecting, nil)
+		go l.connectOnce(ctx, l.pending)
+	}
+	pending := l.pending
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-pending:
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.client == nil {
+		return nil, l.err
+	}
+	return l.client, nil
+}
+
+// connectOnce connects on behalf of every acquire waiting on pending
+func (l *lazyConn) connectOnce(ctx context.Context, pending chan struct{}) {
+	client, err := l.connect(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = nil
+	close(pending)
+	if l.stopped {
+		return
+	}
+	if err != nil {
+		l.err = err
+		l.setStatus(StatusError, err)
+		return
+	}
+	l.client = client
+	l.setStatus(StatusConnected, nil)
+	if l.active == 0 {
+		l.startIdleTimer()
+	}
+}
+
+// done records that a connection from acquire has closed
+func (l *lazyConn) done() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.active == 0 && l.client != nil && !l.stopped {
+		l.startIdleTimer()
+	}
+}
+
+// startIdleTimer arms the idle timeout. Must be called with mu held.
+func (l *lazyConn) startIdleTimer() {
+	l.idle = time.AfterFunc(l.idleTimeout, l.goIdle)
+}
+
+// goIdle drops the client if the tunnel is still unused
+func (l *lazyConn) goIdle() {
+	l.mu.Lock()
+	if l.active > 0 || l.client == nil || l.stopped {
+		l.mu.Unlock()
+		return
+	}
+	l.client = nil
+	l.idle = nil
+	l.setStatus(StatusIdle, nil)
+	l.mu.Unlock()
+
+	l.release()
+}
+
+// inUse reports whether the tunnel holds a client or is connecting one
+func (l *lazyConn) inUse() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.client != nil || l.pending != nil
+}
+
+// reset forgets a client whose connection was lost, so the next connection
+// reconnects
+func (l *lazyConn) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.client = nil
+	if l.idle != nil {
+		l.idle.Stop()
+		l.idle = nil
+	}
+	if !l.stopped {
+		l.setStatus(StatusIdle, nil)
+	}
+}
+
+// stop disarms the idle timer when the tunnel stops
+func (l *lazyConn) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopped = true
+	l.client = nil
+	if l.idle != nil {
+		l.idle.Stop()
+		l.idle = nil
+	}
+}
@@ -0,0 +1,181 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// newTestLazyConn returns a lazyConn whose connects wait for connected to be
+// closed, recording the statuses it reports
+func newTestLazyConn(idleTimeout time.Duration, connected chan struct{}, connectErr error) (*lazyConn, *int32, chan struct{}, func() []Status) {
+	var connects int32
+	released := make(chan struct{}, 10)
+	var mu sync.Mutex
+	var statuses []Status
+	l := &lazyConn{
+		idleTimeout: idleTimeout,
+		connect: func(ctx context.Context) (SSHClient, error) {
+			atomic.AddInt32(&connects, 1)
+			<-connected
+			if connectErr != nil {
+				return nil, connectErr
+			}
+			return &fakeSSHClient{}, nil
+		},
+		release: func() { released <- struct{}{} },
+		setStatus: func(s Status, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, s)
+		},
+	}
+	return l, &connects, released, func() []Status {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Status(nil), statuses...)
+	}
+}
+
+func TestLazyConnConnectsOnce(t *testing.T) {
+	connected := make(chan struct{})
+	l, connects, _, statuses := newTestLazyConn(time.Minute, connected, nil)
+	defer l.stop()
+
+	// Connections arriving together share one connect
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := l.acquire(context.Background())
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(connected)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("acquire failed: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(connects); n != 1 {
+		t.Errorf("expected 1 connect, got %d", n)
+	}
+	if got := statuses(); len(got) != 2 || got[0] != StatusConnecting || got[1] != StatusConnected {
+		t.Errorf("expected connecting then connected, got %v", got)
+	}
+}
+
+func TestLazyConnGoesIdle(t *testing.T) {
+	connected := make(chan struct{})
+	close(connected)
+	l, connects, released, statuses := newTestLazyConn(20*time.Millisecond, connected, nil)
+	defer l.stop()
+
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	// Stays connected while a connection is open
+	time.Sleep(50 * time.Millisecond)
+	if !l.inUse() {
+		t.Fatal("expected the client to be kept while a connection is open")
+	}
+
+	l.done()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("expected the client to be released after the idle timeout")
+	}
+	if l.inUse() {
+		t.Error("expected no client once idle")
+	}
+	if got := statuses(); got[len(got)-1] != StatusIdle {
+		t.Errorf("expected status idle, got %v", got)
+	}
+
+	// The next connection reconnects
+	if _, err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	l.done()
+	if n := atomic.LoadInt32(connects); n != 2 {
+		t.Errorf("expected 2 connects, got %d", n)
+	}
+}
+
+func TestLazyConnConnectError(t *testing.T) {
+	connected := make(chan struct{})
+	close(connected)
+	l, _, _, statuses := newTestLazyConn(time.Minute, connected, ErrHostUnreachable)
+	defer l.stop()
+
+	_, err := l.acquire(context.Background())
+	l.done()
+	if !errors.Is(err, ErrHostUnreachable) {
+		t.Errorf("expected ErrHostUnreachable, got %v", err)
+	}
+	if got := statuses(); got[len(got)-1] != StatusError {
+		t.Errorf("expected status error, got %v", got)
+	}
+}
+
+func TestLocalTunnelLazy(t *testing.T) {
+	cfg := config.Tunnel{
+		Type:       config.TunnelTypeLocal,
+		LocalHost:  "127.0.0.1",
+		RemoteHost: "db.internal",
+		RemotePort: 5432,
+		Lazy:       true,
+	}
+	client := &fakeSSHClient{}
+	dialed := make(chan struct{}, 1)
+	tun := NewLocalTunnel("db", cfg, nil)
+	tun.lazy = &lazyConn{
+		idleTimeout: time.Minute,
+		setStatus:   tun.SetStatus,
+		connect: func(ctx context.Context) (SSHClient, error) {
+			dialed <- struct{}{}
+			return client, nil
+		},
+		release: func() {},
+	}
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+
+	if got := tun.Status(); got != StatusIdle {
+		t.Fatalf("expected status idle before any connection, got %s", got)
+	}
+
+	conn, err := net.Dial("tcp", tun.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to the tunnel: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case <-dialed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the first connection to connect the tunnel")
+	}
+	deadline := time.Now().Add(time.Second)
+	for tun.Status() != StatusConnected && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := tun.Status(); got != StatusConnected {
+		t.Errorf("expected status connected, got %s", got)
+	}
+}
@@ -2,14 +2,20 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/pjtatlow/bore/internal/config"
 )
 
+// verifyTimeout bounds the readiness dial made when a tunnel has verify set
+const verifyTimeout = 5 * time.Second
+
 // LocalTunnel implements local port forwarding (-L)
 // It listens locally and forwards connections to a remote host via SSH
 type LocalTunnel struct {
@@ -19,6 +25,8 @@ type LocalTunnel struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+
+	lazy *lazyConn // set for lazy tunnels, which connect on demand instead of using sshClient
 }
 
 // SSHClient defines the interface for SSH client operations
@@ -39,16 +47,30 @@ func (t *LocalTunnel) Start(ctx context.Context) error {
 	t.ctx, t.cancel = context.WithCancel(ctx)
 	t.SetStatus(StatusConnecting, nil)
 
-	localAddr := fmt.Sprintf("%s:%d", t.config.LocalHost, t.config.LocalPort)
+	localAddr := t.localAddr()
 
 	listener, err := net.Listen("tcp", localAddr)
 	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			err = fmt.Errorf("%w%s", err, heldBy(t.config.LocalPort))
+		}
 		t.SetStatus(StatusError, err)
 		return fmt.Errorf("failed to listen on %s: %w", localAddr, err)
 	}
 	t.listener = listener
 
-	t.SetStatus(StatusConnected, nil)
+	// Optionally confirm the remote end is reachable so the status reflects
+	// reality. The listener stays up either way, and the dial runs in the
+	// background since the manager starts tunnels with its lock held.
+	switch {
+	case t.lazy != nil:
+		t.SetStatus(StatusIdle, nil)
+	case t.config.Verify:
+		t.wg.Add(1)
+		go t.checkReady()
+	default:
+		t.SetStatus(StatusConnected, nil)
+	}
 
 	t.wg.Add(1)
 	go t.acceptLoop()
@@ -56,59 +78,150 @@ func (t *LocalTunnel) Start(ctx context.Context) error {
 	return nil
 }
 
+// checkReady runs the readiness dial and reports the result as the tunnel's
+// status, unless it was stopped or a forwarded connection settled it first
+func (t *LocalTunnel) checkReady() {
+	defer t.wg.Done()
+	err := t.verify()
+	if t.ctx.Err() != nil || t.Status() != StatusConnecting {
+		return
+	}
+	if err != nil {
+		t.SetStatus(StatusError, err)
+	} else {
+		t.SetStatus(StatusConnected, nil)
+	}
+}
+
+// verify dials the remote address once through SSH to check it is reachable
+func (t *LocalTunnel) verify() error {
+	remoteAddr := t.remoteAddr()
+
+	done := make(chan dialResult, 1)
+	go func() {
+		conn, err := t.sshClient.Dial(t.remoteNetwork(), remoteAddr)
+		done <- dialResult{conn, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return fmt.Errorf("remote %s unreachable: %w", remoteAddr, r.err)
+		}
+		r.conn.Close()
+		return nil
+	case <-time.After(verifyTimeout):
+		go closeWhenDialed(done)
+		return fmt.Errorf("remote %s unreachable: timed out after %s", remoteAddr, verifyTimeout)
+	case <-t.ctx.Done():
+		go closeWhenDialed(done)
+		return t.ctx.Err()
+	}
+}
+
+// dialResult is the outcome of a dial made in the background
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// closeWhenDialed closes the connection from a dial that was given up on, if
+// it eventually succeeds
+func closeWhenDialed(done <-chan dialResult) {
+	if r := <-done; r.err == nil {
+		r.conn.Close()
+	}
+}
+
 // acceptLoop accepts incoming connections
 func (t *LocalTunnel) acceptLoop() {
 	defer t.wg.Done()
 
+	var guard acceptGuard
 	for {
 		conn, err := t.listener.Accept()
 		if err != nil {
+			// A listener closed by Stop isn't an error
 			select {
 			case <-t.ctx.Done():
 				return
 			default:
-				continue
 			}
+
+			if t.handleAcceptError(t.ctx, &guard, err) {
+				return
+			}
+			continue
 		}
+		guard.reset()
 
 		t.stats.IncrementConnections()
+		connID := newConnID()
+		t.logConn("Accepted connection %s from %s", connID, conn.RemoteAddr())
+
 		t.wg.Add(1)
-		go t.handleConnection(conn)
+		go t.handleConnection(conn, connID)
 	}
 }
 
 // handleConnection handles a single forwarded connection
-func (t *LocalTunnel) handleConnection(localConn net.Conn) {
+func (t *LocalTunnel) handleConnection(localConn net.Conn, connID string) {
 	defer t.wg.Done()
+	defer t.stats.OpenConnection()()
 	defer localConn.Close()
+	connKey, untrack := t.trackConn(localConn)
+	defer untrack()
+	t.tuneConn(localConn, connID)
 
-	remoteAddr := fmt.Sprintf("%s:%d", t.config.RemoteHost, t.config.RemotePort)
+	remoteAddr := t.remoteAddr()
 
-	remoteConn, err := t.sshClient.Dial("tcp", remoteAddr)
+	client := t.sshClient
+	if t.lazy != nil {
+		lazyClient, err := t.lazy.acquire(t.ctx)
+		defer t.lazy.done()
+		if err != nil {
+			t.logConn("Connection %s failed to connect to the host: %v", connID, err)
+			return
+		}
+		client = lazyClient
+	}
+
+	remoteConn, err := client.Dial(t.remoteNetwork(), remoteAddr)
 	if err != nil {
+		t.logConn("Connection %s failed to dial %s: %v", connID, remoteAddr, err)
 		return
 	}
 	defer remoteConn.Close()
+	if !t.trackConnEnd(connKey, remoteConn) {
+		t.logConn("Connection %s was dropped", connID)
+		return
+	}
+
+	// A successful dial proves a failed readiness check is stale
+	if t.config.Verify && t.Status() == StatusError {
+		t.SetStatus(StatusConnected, nil)
+	}
 
 	// Bidirectional copy
 	var wg sync.WaitGroup
+	var sent, received int64
 	wg.Add(2)
 
 	// Local -> Remote
 	go func() {
 		defer wg.Done()
-		n, _ := io.Copy(remoteConn, localConn)
-		t.stats.AddSent(n)
+		sent, _ = io.Copy(&countingWriter{w: limitWriter(t.ctx, remoteConn, t.limiters), add: t.stats.AddSent}, localConn)
 	}()
 
 	// Remote -> Local
 	go func() {
 		defer wg.Done()
-		n, _ := io.Copy(localConn, remoteConn)
-		t.stats.AddReceived(n)
+		received, _ = io.Copy(&countingWriter{w: limitWriter(t.ctx, localConn, t.limiters), add: t.stats.AddReceived}, remoteConn)
 	}()
 
 	wg.Wait()
+	t.logConn("Closed connection %s from %s (sent %d bytes, received %d bytes)",
+		connID, localConn.RemoteAddr(), sent, received)
 }
 
 // Stop stops the tunnel
@@ -116,6 +229,9 @@ func (t *LocalTunnel) Stop() error {
 	if t.cancel != nil {
 		t.cancel()
 	}
+	if t.lazy != nil {
+		t.lazy.stop()
+	}
 
 	if t.listener != nil {
 		t.listener.Close()
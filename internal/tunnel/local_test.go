@@ -0,0 +1,407 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+type fakeSSHClient struct {
+	err     error
+	network string // last network passed to Dial
+	dialed  string // last address passed to Dial
+}
+
+func (f *fakeSSHClient) Dial(network, addr string) (net.Conn, error) {
+	f.network, f.dialed = network, addr
+	if f.err != nil {
+		return nil, f.err
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+// blockingSSHClient's dials hang until release is closed
+type blockingSSHClient struct {
+	release chan struct{}
+}
+
+func (b *blockingSSHClient) Dial(network, addr string) (net.Conn, error) {
+	<-b.release
+	return nil, errors.New("connection refused")
+}
+
+// waitForStatus waits briefly for tun to reach want, for statuses set in the
+// background
+func waitForStatus(t *testing.T, tun Tunnel, want Status) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for tun.Status() != want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := tun.Status(); got != want {
+		t.Fatalf("expected status %s, got %s", want, got)
+	}
+}
+
+func TestLocalTunnelVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  bool
+		dialErr error
+		want    Status
+	}{
+		{"verify disabled", false, errors.New("connection refused"), StatusConnected},
+		{"remote reachable", true, nil, StatusConnected},
+		{"remote unreachable", true, errors.New("connection refused"), StatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Tunnel{
+				Type:       config.TunnelTypeLocal,
+				LocalHost:  "127.0.0.1",
+				LocalPort:  0,
+				RemoteHost: "db.internal",
+				RemotePort: 5432,
+				Verify:     tt.verify,
+			}
+			tun := NewLocalTunnel("db", cfg, &fakeSSHClient{err: tt.dialErr})
+			if err := tun.Start(context.Background()); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			defer tun.Stop()
+
+			waitForStatus(t, tun, tt.want)
+		})
+	}
+}
+
+func TestLocalTunnelVerifyDoesNotBlockStart(t *testing.T) {
+	cfg := config.Tunnel{
+		Type:       config.TunnelTypeLocal,
+		LocalHost:  "127.0.0.1",
+		RemoteHost: "db.internal",
+		RemotePort: 5432,
+		Verify:     true,
+	}
+	client := &blockingSSHClient{release: make(chan struct{})}
+	defer close(client.release)
+	tun := NewLocalTunnel("db", cfg, client)
+
+	start := time.Now()
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Start waited %s for the readiness dial", elapsed)
+	}
+	if got := tun.Status(); got != StatusConnecting {
+		t.Errorf("expected status connecting while verifying, got %s", got)
+	}
+
+	// Stopping gives up on the dial rather than waiting out verifyTimeout
+	start = time.Now()
+	tun.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop waited %s for the readiness dial", elapsed)
+	}
+	if got := tun.Status(); got != StatusStopped {
+		t.Errorf("expected status stopped, got %s", got)
+	}
+}
+
+func TestTunnelAddrsIPv6(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.Tunnel
+		wantLocal  string
+		wantRemote string
+	}{
+		{
+			"hostnames",
+			config.Tunnel{LocalHost: "localhost", LocalPort: 8080, RemoteHost: "db.internal", RemotePort: 5432},
+			"localhost:8080", "db.internal:5432",
+		},
+		{
+			"ipv6 literals",
+			config.Tunnel{LocalHost: "::1", LocalPort: 8080, RemoteHost: "fd00::5", RemotePort: 80},
+			"[::1]:8080", "[fd00::5]:80",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBaseTunnel("test", tt.cfg)
+			if got := b.localAddr(); got != tt.wantLocal {
+				t.Errorf("localAddr() = %q, want %q", got, tt.wantLocal)
+			}
+			if got := b.remoteAddr(); got != tt.wantRemote {
+				t.Errorf("remoteAddr() = %q, want %q", got, tt.wantRemote)
+			}
+		})
+	}
+}
+
+func TestLocalTunnelDialsIPv6Remote(t *testing.T) {
+	cfg := config.Tunnel{
+		Type:       config.TunnelTypeLocal,
+		LocalHost:  "127.0.0.1",
+		RemoteHost: "fd00::5",
+		RemotePort: 5432,
+		Verify:     true,
+	}
+	client := &fakeSSHClient{}
+	tun := NewLocalTunnel("db", cfg, client)
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+	waitForStatus(t, tun, StatusConnected)
+
+	if client.dialed != "[fd00::5]:5432" {
+		t.Errorf("dialed %q, want %q", client.dialed, "[fd00::5]:5432")
+	}
+}
+
+func TestLocalTunnelDialsRemoteSocket(t *testing.T) {
+	cfg := config.Tunnel{
+		Type:         config.TunnelTypeLocal,
+		LocalHost:    "127.0.0.1",
+		RemoteSocket: "/var/run/docker.sock",
+		Verify:       true,
+	}
+	client := &fakeSSHClient{}
+	tun := NewLocalTunnel("docker", cfg, client)
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+	waitForStatus(t, tun, StatusConnected)
+
+	if client.network != "unix" || client.dialed != "/var/run/docker.sock" {
+		t.Errorf("dialed %s %q, want unix %q", client.network, client.dialed, "/var/run/docker.sock")
+	}
+}
+
+// failingListener fails every Accept, like a listener whose fd was closed
+type failingListener struct {
+	err error
+}
+
+func (l *failingListener) Accept() (net.Conn, error) { return nil, l.err }
+func (l *failingListener) Close() error              { return nil }
+func (l *failingListener) Addr() net.Addr            { return &net.TCPAddr{} }
+
+func TestLocalTunnelAcceptFailure(t *testing.T) {
+	tun := NewLocalTunnel("web", config.Tunnel{Type: config.TunnelTypeLocal}, &fakeSSHClient{})
+	tun.ctx, tun.cancel = context.WithCancel(context.Background())
+	defer tun.cancel()
+	tun.listener = &failingListener{err: errors.New("bad file descriptor")}
+	tun.SetStatus(StatusConnected, nil)
+
+	done := make(chan struct{})
+	tun.wg.Add(1)
+	go func() {
+		tun.acceptLoop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("accept loop did not stop on a broken listener")
+	}
+
+	if got := tun.Status(); got != StatusError {
+		t.Errorf("expected status %s, got %s", StatusError, got)
+	}
+	if !errors.Is(tun.lastError, ErrAcceptFailed) {
+		t.Errorf("expected ErrAcceptFailed, got %v", tun.lastError)
+	}
+}
+
+// recordingWarnLogger collects warnings logged by a tunnel
+type recordingWarnLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingWarnLogger) Warnf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestLocalTunnelAcceptTemporaryError(t *testing.T) {
+	tun := NewLocalTunnel("web", config.Tunnel{Type: config.TunnelTypeLocal}, &fakeSSHClient{})
+	tun.ctx, tun.cancel = context.WithCancel(context.Background())
+	logger := &recordingWarnLogger{}
+	tun.warnLogger = logger
+	tun.listener = &failingListener{err: fmt.Errorf("accept tcp: %w", syscall.EMFILE)}
+	tun.SetStatus(StatusConnected, nil)
+
+	done := make(chan struct{})
+	tun.wg.Add(1)
+	go func() {
+		tun.acceptLoop()
+		close(done)
+	}()
+
+	// Well past the point the guard would trip if EMFILE weren't backed off
+	time.Sleep(200 * time.Millisecond)
+	tun.cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("accept loop did not stop after cancel")
+	}
+
+	if got := tun.Status(); got != StatusConnected {
+		t.Errorf("expected status %s, got %s", StatusConnected, got)
+	}
+	errs := tun.stats.Snapshot().AcceptErrors
+	if errs < 2 || errs >= 40 {
+		t.Errorf("expected a handful of backed-off accept errors, got %d", errs)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.messages) != 1 {
+		t.Errorf("expected 1 rate-limited warning, got %d: %v", len(logger.messages), logger.messages)
+	}
+}
+
+// holdingSSHClient dials connections that stay open until the tunnel
+// closes them
+type holdingSSHClient struct {
+	mu      sync.Mutex
+	servers []net.Conn
+}
+
+func (h *holdingSSHClient) Dial(network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	h.mu.Lock()
+	h.servers = append(h.servers, server)
+	h.mu.Unlock()
+	return client, nil
+}
+
+// closeAll closes the server side of every dialed connection
+func (h *holdingSSHClient) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, server := range h.servers {
+		server.Close()
+	}
+}
+
+func TestLocalTunnelDropConnections(t *testing.T) {
+	cfg := config.Tunnel{
+		Type:       config.TunnelTypeLocal,
+		LocalHost:  "127.0.0.1",
+		RemoteHost: "db.internal",
+		RemotePort: 5432,
+	}
+	client := &holdingSSHClient{}
+	tun := NewLocalTunnel("db", cfg, client)
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+	defer client.closeAll()
+	addr := tun.listener.Addr().String()
+
+	// waitForwarding waits until want connections have dialed the remote end
+	waitForwarding := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			client.mu.Lock()
+			dialed := len(client.servers)
+			client.mu.Unlock()
+			tun.connsMu.Lock()
+			tracked := len(tun.conns)
+			tun.connsMu.Unlock()
+			if dialed >= want && tracked == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d forwarded connections, got %d", want, tracked)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	waitForwarding(2)
+
+	if dropped := tun.DropConnections(); dropped != 2 {
+		t.Errorf("expected 2 connections dropped, got %d", dropped)
+	}
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("expected the dropped connection to be closed, got %v", err)
+		}
+	}
+
+	// The listener keeps forwarding new connections
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial after drop failed: %v", err)
+	}
+	defer conn.Close()
+	waitForwarding(1)
+	if got := tun.Status(); got != StatusConnected {
+		t.Errorf("expected the tunnel to stay connected, got %s", got)
+	}
+}
+
+func TestTrackConnKeepsConnectionsApart(t *testing.T) {
+	var tun baseTunnel
+	first, firstPeer := net.Pipe()
+	defer firstPeer.Close()
+	second, secondPeer := net.Pipe()
+	defer secondPeer.Close()
+
+	// Connections tracked at once get their own keys, whatever their log IDs
+	firstKey, untrackFirst := tun.trackConn(first)
+	secondKey, untrackSecond := tun.trackConn(second)
+	defer untrackSecond()
+	if firstKey == secondKey {
+		t.Fatalf("expected distinct tracking keys, got %d twice", firstKey)
+	}
+
+	// The first connection finishing leaves the second tracked
+	untrackFirst()
+	if tun.trackConnEnd(firstKey, first) {
+		t.Error("expected the finished connection to be forgotten")
+	}
+	if !tun.trackConnEnd(secondKey, secondPeer) {
+		t.Fatal("expected the second connection to still be tracked")
+	}
+	if dropped := tun.DropConnections(); dropped != 1 {
+		t.Errorf("expected 1 connection dropped, got %d", dropped)
+	}
+	if _, err := second.Write([]byte{0}); err == nil {
+		t.Error("expected the second connection to be closed")
+	}
+}
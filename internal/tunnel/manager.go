@@ -2,7 +2,13 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,9 +20,35 @@ import (
 type Manager struct {
 	mu          sync.RWMutex
 	tunnels     map[string]Tunnel
-	tunnelHosts map[string]string // tracks which host each tunnel is connected through
-	sshClients  map[string]*ssh.Client
-	sshReader   *config.SSHConfigReader
+	tunnelHosts map[string]string      // tracks which host each tunnel is connected through
+	hostOrders  map[string][]string    // hosts each tunnel may use, most preferred first
+	sshClients  map[string]*ssh.Client // keyed by endpoint, so aliases for the same server share a client
+
+	tunnelEndpoints map[string]string    // tracks which SSH client endpoint each tunnel uses
+	nextRetry       map[string]time.Time // when a reconnecting tunnel's next attempt is scheduled
+	reconnects      map[string]*reconnectHistory
+	overrides       map[string]RemoteOverride // remote targets set by tunnel up, reapplied on reload
+	sshReader       *config.SSHConfigReader
+	rates           *RateTracker
+	bandwidth       *RateLimiter // defaults.max_rate, shared by every tunnel
+
+	configMu sync.Mutex
+	config   configState
+
+	onHostDisconnect func(hostName string, tunnels []string)
+	onStatusChange   StatusChangeFunc
+	connLoggerFor    func(tunnelName string) ConnLogger
+	warnLoggerFor    func(tunnelName string) WarnLogger
+	hostWarnLogger   func(hostName string) WarnLogger
+}
+
+// HostInfo contains runtime information about an SSH host connection
+type HostInfo struct {
+	Name        string
+	Tunnels     []string
+	Connected   bool
+	ConnectedAt time.Time
+	RTT         time.Duration // round-trip time of the last keepalive
 }
 
 // NewManager creates a new tunnel manager
@@ -29,74 +61,347 @@ func NewManager() (*Manager, error) {
 	return &Manager{
 		tunnels:     make(map[string]Tunnel),
 		tunnelHosts: make(map[string]string),
+		hostOrders:  make(map[string][]string),
 		sshClients:  make(map[string]*ssh.Client),
 		sshReader:   sshReader,
+		rates:       NewRateTracker(),
+		bandwidth:   NewRateLimiter(0),
+
+		tunnelEndpoints: make(map[string]string),
+		nextRetry:       make(map[string]time.Time),
+		reconnects:      make(map[string]*reconnectHistory),
+		overrides:       make(map[string]RemoteOverride),
 	}, nil
 }
 
 // StartTunnel starts a tunnel by name using the specified host
 func (m *Manager) StartTunnel(ctx context.Context, name, host string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.StartTunnelWithOverride(ctx, name, host, RemoteOverride{})
+}
 
-	// If already running on same host, nothing to do
-	if _, exists := m.tunnels[name]; exists {
-		if m.tunnelHosts[name] == host {
-			return nil
-		}
-		// Running on different host - stop it first to switch hosts
-		if tunnel, ok := m.tunnels[name]; ok {
-			tunnel.Stop()
-			delete(m.tunnels, name)
-			delete(m.tunnelHosts, name)
-			m.cleanupUnusedClients()
-		}
+// StartTunnelWithOverride starts a tunnel like StartTunnel, but with its remote
+// target replaced by override. A tunnel already running via the same host is
+// restarted if override is set. fallbacks are tried after host, before the
+// tunnel's own fallback hosts.
+func (m *Manager) StartTunnelWithOverride(ctx context.Context, name, host string, override RemoteOverride, fallbacks ...string) error {
+	cfg, err := m.Config()
+	if err != nil {
+		return err
 	}
+	return m.startTunnel(ctx, cfg, name, host, fallbacks, override)
+}
 
-	// Load config fresh
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+// startTunnel starts a tunnel as defined in cfg, which callers starting
+// several tunnels share so they all see the same version of the config. If
+// host can't be reached, fallbacks and then the tunnel's own fallback hosts
+// are tried in order.
+func (m *Manager) startTunnel(ctx context.Context, cfg *config.Config, name, host string, fallbacks []string, override RemoteOverride) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// If already running via the same host, nothing to do, even if it is
+	// using a fallback. A paused or failed tunnel is started afresh.
+	if tunnel, exists := m.tunnels[name]; exists && m.primaryHost(name) == host && override.IsZero() && !tunnel.Status().Halted() {
+		return nil
 	}
 
 	// Get tunnel config
 	tunnelCfg, ok := cfg.GetTunnel(name)
 	if !ok {
-		return fmt.Errorf("tunnel '%s' not found in config", name)
+		return errorf(ErrTunnelNotFound, "tunnel '%s' not found in config", name)
+	}
+	tunnelCfg, err := override.Apply(tunnelCfg)
+	if err != nil {
+		return err
 	}
 
+	// Running on a different host or target - stop it first to switch
+	if tunnel, ok := m.tunnels[name]; ok {
+		tunnel.Stop()
+		delete(m.tunnels, name)
+		delete(m.tunnelHosts, name)
+		delete(m.hostOrders, name)
+		delete(m.tunnelEndpoints, name)
+		m.cleanupUnusedClients()
+	}
+	hosts := hostOrder(host, fallbacks, tunnelCfg.FallbackHosts)
+
 	// Check for port conflicts
-	if err := m.checkPortConflict(tunnelCfg); err != nil {
+	if err := m.checkPortConflict(cfg, tunnelCfg, host); err != nil {
 		return err
 	}
 
-	// Get or create SSH client for this host
-	client, err := m.getOrCreateSSHClient(ctx, host)
-	if err != nil {
-		return fmt.Errorf("failed to connect to host '%s': %w", host, err)
+	// Local tunnels bind a local port; make sure nothing outside bore holds it
+	if tunnelCfg.Type == config.TunnelTypeLocal {
+		if err := CheckTunnelPortsAvailable(tunnelCfg); err != nil {
+			return err
+		}
+	}
+
+	// Get or create SSH client for the first reachable host. Lazy tunnels
+	// connect when their first connection arrives instead.
+	var client *ssh.Client
+	var endpoint string
+	via := host
+	if !tunnelCfg.Lazy {
+		client, endpoint, via, err = m.connectAny(ctx, cfg, hosts)
+		if err != nil {
+			return err
+		}
+		// A fallback is a different server, whose remote ports may be taken
+		if via != host {
+			if err := m.checkPortConflict(cfg, tunnelCfg, via); err != nil {
+				m.cleanupUnusedClients()
+				return err
+			}
+		}
 	}
 
 	// Create tunnel based on type
-	var tunnel Tunnel
-	switch tunnelCfg.Type {
-	case config.TunnelTypeLocal:
-		tunnel = NewLocalTunnel(name, tunnelCfg, client)
-	case config.TunnelTypeRemote:
-		tunnel = NewRemoteTunnel(name, tunnelCfg, client)
-	default:
-		return fmt.Errorf("unknown tunnel type: %s", tunnelCfg.Type)
+	tunnel, err := m.newTunnel(name, tunnelCfg, via, client)
+	if err != nil {
+		return err
 	}
 
 	// Start the tunnel
 	if err := tunnel.Start(ctx); err != nil {
+		m.cleanupUnusedClients()
 		return err
 	}
 
 	m.tunnels[name] = tunnel
-	m.tunnelHosts[name] = host
+	m.tunnelHosts[name] = via
+	m.hostOrders[name] = hosts
+	if endpoint != "" {
+		m.tunnelEndpoints[name] = endpoint
+	}
+	if override.IsZero() {
+		delete(m.overrides, name)
+	} else {
+		m.overrides[name] = override
+	}
 	return nil
 }
 
+// hostOrder returns the hosts to try for a tunnel started via host: host
+// itself, then each list of fallbacks in turn, skipping repeats
+func hostOrder(host string, fallbacks ...[]string) []string {
+	hosts := []string{host}
+	for _, list := range fallbacks {
+		for _, fallback := range list {
+			if fallback != "" && !slices.Contains(hosts, fallback) {
+				hosts = append(hosts, fallback)
+			}
+		}
+	}
+	return hosts
+}
+
+// connectAny connects to the first of hosts that can be reached, returning
+// the client, its endpoint, and the host it was reached through. Must be
+// called with m.mu held.
+func (m *Manager) connectAny(ctx context.Context, cfg *config.Config, hosts []string) (*ssh.Client, string, string, error) {
+	var errs []error
+	for _, host := range hosts {
+		client, endpoint, err := m.getOrCreateSSHClient(ctx, cfg, host)
+		if err == nil {
+			return client, endpoint, host, nil
+		}
+		if len(hosts) == 1 {
+			return nil, "", "", wrapf(ErrHostUnreachable, err, "failed to connect to host '%s'", host)
+		}
+		errs = append(errs, fmt.Errorf("host '%s': %w", host, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", "", wrapf(ErrHostUnreachable, errors.Join(errs...), "failed to connect to host '%s' or its fallbacks", hosts[0])
+}
+
+// primaryHost returns the host a tunnel was started via, which it prefers
+// over its fallbacks. Must be called with m.mu held.
+func (m *Manager) primaryHost(name string) string {
+	if hosts := m.hostOrders[name]; len(hosts) > 0 {
+		return hosts[0]
+	}
+	return m.tunnelHosts[name]
+}
+
+// hostsFor returns the hosts a running tunnel may use, most preferred
+// first. Must be called with m.mu held.
+func (m *Manager) hostsFor(name string) []string {
+	if hosts := m.hostOrders[name]; len(hosts) > 0 {
+		return hosts
+	}
+	return []string{m.tunnelHosts[name]}
+}
+
+// newTunnel creates a tunnel of the configured type and wires up status
+// callbacks. client is nil for lazy tunnels, which connect to host themselves.
+func (m *Manager) newTunnel(name string, tunnelCfg config.Tunnel, host string, client *ssh.Client) (Tunnel, error) {
+	var connLogger ConnLogger
+	if m.connLoggerFor != nil {
+		connLogger = m.connLoggerFor(name)
+	}
+	var warnLogger WarnLogger
+	if m.warnLoggerFor != nil {
+		warnLogger = m.warnLoggerFor(name)
+	}
+
+	var tunnel Tunnel
+	if tunnelCfg.IsRange() {
+		var ports []portTunnel
+		for _, portCfg := range tunnelCfg.Expand() {
+			port, err := m.newPortTunnel(name, portCfg, host, client, connLogger, warnLogger)
+			if err != nil {
+				return nil, err
+			}
+			ports = append(ports, port)
+		}
+		t := NewRangeTunnel(name, tunnelCfg, ports)
+		t.reconnects = m.reconnectHistoryFor(name)
+		tunnel = t
+	} else {
+		t, err := m.newPortTunnel(name, tunnelCfg, host, client, connLogger, warnLogger)
+		if err != nil {
+			return nil, err
+		}
+		tunnel = t
+	}
+
+	if m.onStatusChange != nil {
+		tunnel.SetOnStatusChange(m.onStatusChange)
+	}
+
+	return tunnel, nil
+}
+
+// newPortTunnel creates a local or remote tunnel forwarding a single port
+func (m *Manager) newPortTunnel(name string, tunnelCfg config.Tunnel, host string, client *ssh.Client, connLogger ConnLogger, warnLogger WarnLogger) (portTunnel, error) {
+	switch tunnelCfg.Type {
+	case config.TunnelTypeLocal:
+		var t *LocalTunnel
+		if tunnelCfg.Lazy {
+			t = NewLocalTunnel(name, tunnelCfg, nil)
+			t.lazy = m.newLazyConn(t, host)
+		} else {
+			t = NewLocalTunnel(name, tunnelCfg, client)
+		}
+		t.connLogger = connLogger
+		t.warnLogger = warnLogger
+		t.reconnects = m.reconnectHistoryFor(name)
+		t.limiters = []*RateLimiter{m.bandwidth}
+		return t, nil
+	case config.TunnelTypeRemote:
+		t := NewRemoteTunnel(name, tunnelCfg, client)
+		t.connLogger = connLogger
+		t.warnLogger = warnLogger
+		t.reconnects = m.reconnectHistoryFor(name)
+		t.limiters = []*RateLimiter{m.bandwidth}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unknown tunnel type: %s", tunnelCfg.Type)
+	}
+}
+
+// setRetryBind has a remote tunnel, or every port of a remote range, retry
+// binding ports the server may still hold from the previous session
+func setRetryBind(t Tunnel) {
+	switch t := t.(type) {
+	case *RemoteTunnel:
+		t.retryBind = true
+	case *RangeTunnel:
+		for _, port := range t.ports {
+			setRetryBind(port)
+		}
+	}
+}
+
+// newLazyConn connects a lazy tunnel to host on demand, sharing the SSH
+// client with the manager's other tunnels on the same endpoint
+func (m *Manager) newLazyConn(t *LocalTunnel, host string) *lazyConn {
+	var l *lazyConn
+	l = &lazyConn{
+		idleTimeout: t.config.LazyIdleTimeout(),
+		setStatus:   t.SetStatus,
+		connect: func(ctx context.Context) (SSHClient, error) {
+			cfg, err := m.Config()
+			if err != nil {
+				return nil, err
+			}
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.tunnels[t.name] != Tunnel(t) {
+				return nil, errorf(ErrNotRunning, "tunnel '%s' was stopped", t.name)
+			}
+			hosts := m.hostOrders[t.name]
+			if len(hosts) == 0 {
+				hosts = []string{host}
+			}
+			client, endpoint, via, err := m.connectAny(ctx, cfg, hosts)
+			if err != nil {
+				return nil, err
+			}
+			m.tunnelEndpoints[t.name] = endpoint
+			m.tunnelHosts[t.name] = via
+			return client, nil
+		},
+		release: func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			// A connection may have arrived since the tunnel went idle
+			if m.tunnels[t.name] != Tunnel(t) || l.inUse() {
+				return
+			}
+			delete(m.tunnelEndpoints, t.name)
+			m.cleanupUnusedClients()
+		},
+	}
+	return l
+}
+
+// reconnectHistoryFor returns the reconnect history shared by every instance
+// of a tunnel until it is stopped. Must be called with m.mu held.
+func (m *Manager) reconnectHistoryFor(name string) *reconnectHistory {
+	h, ok := m.reconnects[name]
+	if !ok {
+		h = &reconnectHistory{}
+		m.reconnects[name] = h
+	}
+	return h
+}
+
+// SetOnStatusChange sets a callback to be called when any managed tunnel changes status
+func (m *Manager) SetOnStatusChange(fn StatusChangeFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStatusChange = fn
+}
+
+// SetConnLogger sets a function that returns the connection logger for a tunnel.
+// The function may return nil to disable connection logging.
+func (m *Manager) SetConnLogger(fn func(tunnelName string) ConnLogger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connLoggerFor = fn
+}
+
+// SetWarnLogger sets a function that returns the warning logger for a tunnel
+func (m *Manager) SetWarnLogger(fn func(tunnelName string) WarnLogger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.warnLoggerFor = fn
+}
+
+// SetHostWarnLogger sets a function that returns the warning logger for an
+// SSH host, used for problems such as a key file with loose permissions
+func (m *Manager) SetHostWarnLogger(fn func(hostName string) WarnLogger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hostWarnLogger = fn
+}
+
 // StopTunnel stops a tunnel by name
 func (m *Manager) StopTunnel(name string) error {
 	m.mu.Lock()
@@ -104,15 +409,14 @@ func (m *Manager) StopTunnel(name string) error {
 
 	tunnel, exists := m.tunnels[name]
 	if !exists {
-		return fmt.Errorf("tunnel '%s' is not running", name)
+		return errorf(ErrNotRunning, "tunnel '%s' is not running", name)
 	}
 
 	if err := tunnel.Stop(); err != nil {
 		return err
 	}
 
-	delete(m.tunnels, name)
-	delete(m.tunnelHosts, name)
+	m.forgetTunnel(name)
 
 	// Clean up unused SSH clients
 	m.cleanupUnusedClients()
@@ -120,27 +424,108 @@ func (m *Manager) StopTunnel(name string) error {
 	return nil
 }
 
-// StartGroup starts all tunnels in a group using the specified host
+// stopTunnels stops the named tunnels concurrently, since each may wait for
+// its open connections to finish, then forgets them and closes SSH clients
+// no longer in use. Names that aren't running are reported as ErrNotRunning.
+// Must be called with m.mu held.
+func (m *Manager) stopTunnels(names []string) error {
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		tunnel, ok := m.tunnels[name]
+		if !ok {
+			errs[i] = errorf(ErrNotRunning, "tunnel '%s' is not running", name)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = tunnel.Stop()
+		}()
+	}
+	wg.Wait()
+
+	// Only touch the maps once every Stop has returned
+	for _, name := range names {
+		m.forgetTunnel(name)
+	}
+	m.cleanupUnusedClients()
+
+	return errors.Join(errs...)
+}
+
+// forgetTunnel removes a stopped tunnel from the manager. Must be called with
+// m.mu held.
+func (m *Manager) forgetTunnel(name string) {
+	delete(m.tunnels, name)
+	delete(m.tunnelHosts, name)
+	delete(m.hostOrders, name)
+	delete(m.tunnelEndpoints, name)
+	delete(m.nextRetry, name)
+	delete(m.reconnects, name)
+	delete(m.overrides, name)
+	m.rates.Forget(name)
+}
+
+// StartGroup starts all tunnels in a group using the specified host. Tunnels
+// listed as "tunnel@host" in the group use their own host instead.
 func (m *Manager) StartGroup(ctx context.Context, groupName, host string) error {
-	cfg, err := config.Load()
+	cfg, err := m.Config()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	tunnelNames, err := cfg.GetTunnelsForGroup(groupName)
+	group, ok := cfg.GetGroup(groupName)
+	if !ok {
+		return errorf(ErrGroupNotFound, "group '%s' not found in config", groupName)
+	}
+
+	members, err := cfg.GetGroupMembers(groupName)
 	if err != nil {
 		return err
 	}
 
+	tunnelNames := make([]string, 0, len(members))
+	for _, member := range members {
+		tunnelNames = append(tunnelNames, member.Tunnel)
+	}
+
 	// Check for port conflicts before starting any tunnels
-	if err := m.checkGroupPortConflicts(tunnelNames, cfg); err != nil {
+	if err := m.checkGroupPortConflicts(members, host, cfg); err != nil {
 		return err
 	}
 
-	// Start all tunnels
+	// Start all tunnels, one at a time so a failure rolls back the rest
 	var started []string
-	for _, name := range tunnelNames {
-		if err := m.StartTunnel(ctx, name, host); err != nil {
+	for i, member := range members {
+		name := member.Tunnel
+		if i > 0 && group.Stagger > 0 {
+			select {
+			case <-ctx.Done():
+				for _, startedName := range started {
+					m.StopTunnel(startedName)
+				}
+				return fmt.Errorf("failed to start group '%s': %w", groupName, ctx.Err())
+			case <-time.After(group.Stagger):
+			}
+		}
+		memberHost := host
+		if member.Host != "" {
+			memberHost = member.Host
+		}
+		if memberHost == "" {
+			err := fmt.Errorf("no host for tunnel '%s' (use --host, set host on the group, or list it as '%s@<host>')", name, name)
+			for _, startedName := range started {
+				m.StopTunnel(startedName)
+			}
+			return err
+		}
+		// The group's fallbacks stand in for its host, not a member's own
+		var fallbacks []string
+		if member.Host == "" {
+			fallbacks = group.FallbackHosts
+		}
+		if err := m.startTunnel(ctx, cfg, name, memberHost, fallbacks, RemoteOverride{}); err != nil {
 			// Stop any tunnels we started on failure
 			for _, startedName := range started {
 				m.StopTunnel(startedName)
@@ -153,11 +538,15 @@ func (m *Manager) StartGroup(ctx context.Context, groupName, host string) error
 	return nil
 }
 
-// StopGroup stops all tunnels in a group
+// StopGroup stops all tunnels in a group concurrently
 func (m *Manager) StopGroup(groupName string) error {
-	cfg, err := config.Load()
+	cfg, err := m.Config()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
+	}
+
+	if _, ok := cfg.GetGroup(groupName); !ok {
+		return errorf(ErrGroupNotFound, "group '%s' not found in config", groupName)
 	}
 
 	tunnelNames, err := cfg.GetTunnelsForGroup(groupName)
@@ -165,14 +554,20 @@ func (m *Manager) StopGroup(groupName string) error {
 		return err
 	}
 
-	var lastErr error
+	// A tunnel listed via two hosts is still only one running tunnel
+	seen := make(map[string]bool, len(tunnelNames))
+	unique := tunnelNames[:0]
 	for _, name := range tunnelNames {
-		if err := m.StopTunnel(name); err != nil {
-			lastErr = err
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
 		}
 	}
 
-	return lastErr
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stopTunnels(unique)
 }
 
 // GetTunnelInfo returns info about a specific tunnel
@@ -188,6 +583,21 @@ func (m *Manager) GetTunnelInfo(name string) (Info, bool) {
 	return tunnel.Info(), true
 }
 
+// SampleRate returns a tunnel's throughput since the previous call for that tunnel.
+// It returns false on the first sample.
+func (m *Manager) SampleRate(info Info) (Rate, bool) {
+	return m.rates.Sample(info.Name, info.Stats, time.Now())
+}
+
+// GetTunnelEndpoint returns the user@hostname:port a tunnel's host resolved to
+// when it last connected
+func (m *Manager) GetTunnelEndpoint(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.tunnelEndpoints[name]
+}
+
 // GetTunnelHost returns the host a tunnel is connected through
 func (m *Manager) GetTunnelHost(name string) string {
 	m.mu.RLock()
@@ -196,6 +606,49 @@ func (m *Manager) GetTunnelHost(name string) string {
 	return m.tunnelHosts[name]
 }
 
+// GetTunnelHosts returns the hosts a running tunnel may use, the one it was
+// started via first and then its fallbacks, or nil if it isn't running
+func (m *Manager) GetTunnelHosts(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.tunnels[name]; !ok {
+		return nil
+	}
+	return slices.Clone(m.hostsFor(name))
+}
+
+// GetTunnelOverride returns the remote override a tunnel was started with,
+// if any
+func (m *Manager) GetTunnelOverride(name string) RemoteOverride {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.overrides[name]
+}
+
+// SetNextRetry records when the next reconnect attempt for a tunnel is
+// scheduled. A zero time clears it.
+func (m *Manager) SetNextRetry(name string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at.IsZero() {
+		delete(m.nextRetry, name)
+		return
+	}
+	m.nextRetry[name] = at
+}
+
+// GetNextRetry returns when the next reconnect attempt for a tunnel is
+// scheduled, or false if none is
+func (m *Manager) GetNextRetry(name string) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	at, ok := m.nextRetry[name]
+	return at, ok
+}
+
 // GetAllTunnelInfo returns info about all running tunnels
 func (m *Manager) GetAllTunnelInfo() []Info {
 	m.mu.RLock()
@@ -209,6 +662,54 @@ func (m *Manager) GetAllTunnelInfo() []Info {
 	return infos
 }
 
+// GetHostInfo returns info about every host with an SSH connection or running tunnels
+func (m *Manager) GetHostInfo() []HostInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Hosts are reported by the name tunnels were started with; a client
+	// shared by several aliases appears under each of them
+	hosts := make(map[string]*HostInfo)
+	usedEndpoints := make(map[string]bool)
+	for name, hostName := range m.tunnelHosts {
+		info, ok := hosts[hostName]
+		if !ok {
+			info = &HostInfo{Name: hostName}
+			if client, ok := m.sshClients[m.tunnelEndpoints[name]]; ok {
+				info.Connected = client.IsConnected()
+				info.ConnectedAt = client.ConnectedAt()
+				info.RTT = client.RTT()
+			}
+			hosts[hostName] = info
+		}
+		info.Tunnels = append(info.Tunnels, name)
+		usedEndpoints[m.tunnelEndpoints[name]] = true
+	}
+
+	for endpoint, client := range m.sshClients {
+		if usedEndpoints[endpoint] {
+			continue
+		}
+		hosts[endpoint] = &HostInfo{
+			Name:        endpoint,
+			Connected:   client.IsConnected(),
+			ConnectedAt: client.ConnectedAt(),
+			RTT:         client.RTT(),
+		}
+	}
+
+	infos := make([]HostInfo, 0, len(hosts))
+	for _, info := range hosts {
+		sort.Strings(info.Tunnels)
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
 // ListRunningTunnels returns names of all running tunnels
 func (m *Manager) ListRunningTunnels() []string {
 	m.mu.RLock()
@@ -222,146 +723,405 @@ func (m *Manager) ListRunningTunnels() []string {
 	return names
 }
 
-// StopAll stops all tunnels
+// StopAll stops all tunnels concurrently and closes every SSH client
 func (m *Manager) StopAll() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	var lastErr error
-	for name, tunnel := range m.tunnels {
-		if err := tunnel.Stop(); err != nil {
-			lastErr = err
-		}
-		delete(m.tunnels, name)
-		delete(m.tunnelHosts, name)
+	names := make([]string, 0, len(m.tunnels))
+	for name := range m.tunnels {
+		names = append(names, name)
 	}
+	err := m.stopTunnels(names)
 
-	// Close all SSH clients
+	// Close all SSH clients, including any no tunnel was using
 	for name, client := range m.sshClients {
 		client.Close()
 		delete(m.sshClients, name)
 	}
 
-	return lastErr
+	return err
 }
 
-// getOrCreateSSHClient returns an existing SSH client or creates a new one
-func (m *Manager) getOrCreateSSHClient(ctx context.Context, hostName string) (*ssh.Client, error) {
-	if client, exists := m.sshClients[hostName]; exists {
+// getOrCreateSSHClient returns an existing SSH client for the host's endpoint
+// or creates a new one, along with the endpoint key it is cached under
+func (m *Manager) getOrCreateSSHClient(ctx context.Context, cfg *config.Config, hostName string) (*ssh.Client, string, error) {
+	resolvedHost := m.resolveHost(cfg, hostName)
+	endpoint := endpointKey(resolvedHost)
+
+	if client, exists := m.sshClients[endpoint]; exists {
 		if client.IsConnected() {
-			return client, nil
+			return client, endpoint, nil
 		}
 		// Client disconnected, remove it
 		client.Close()
-		delete(m.sshClients, hostName)
+		delete(m.sshClients, endpoint)
 	}
 
-	// Load config fresh
-	cfg, err := config.Load()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+	client, endpoint := m.newSSHClient(cfg, hostName)
+	if err := client.Connect(ctx); err != nil {
+		return nil, "", err
 	}
+	m.sshClients[endpoint] = client
+	return client, endpoint, nil
+}
 
-	// Resolve host config
-	boreHost, _ := cfg.GetHost(hostName)
-	resolvedHost := config.ResolveHost(hostName, boreHost, m.sshReader)
-
-	// Create new client
-	client := ssh.NewClient(resolvedHost, cfg)
-	if err := client.Connect(ctx); err != nil {
-		return nil, err
+// newSSHClient creates an SSH client for hostName that reports its loss to
+// the manager, along with the endpoint key to cache it under. The client
+// isn't connected yet, so connecting it can happen without m.mu held. Must be
+// called with m.mu held.
+func (m *Manager) newSSHClient(cfg *config.Config, hostName string) (*ssh.Client, string) {
+	resolvedHost := m.resolveHost(cfg, hostName)
+	endpoint := endpointKey(resolvedHost)
+
+	var opts []ssh.Option
+	if m.hostWarnLogger != nil {
+		opts = append(opts, ssh.WithWarnLogger(m.hostWarnLogger(hostName)))
 	}
+	client := ssh.NewClient(resolvedHost, cfg, opts...)
 
 	// Set up disconnect callback to update tunnel statuses
 	client.SetOnDisconnect(func(err error) {
-		m.onSSHDisconnect(hostName, err)
+		m.onSSHDisconnect(endpoint, hostName, client, err)
 	})
+	return client, endpoint
+}
 
-	m.sshClients[hostName] = client
-	return client, nil
+// ProbeAddresses returns the "host:port" address first dialed for each host with
+// running tunnels: its ProxyJump host if it has one, otherwise the host itself
+func (m *Manager) ProbeAddresses() []string {
+	m.mu.RLock()
+	hostNames := make(map[string]bool)
+	for _, hostName := range m.tunnelHosts {
+		hostNames[hostName] = true
+	}
+	m.mu.RUnlock()
+
+	if len(hostNames) == 0 {
+		return nil
+	}
+
+	cfg, err := m.Config()
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var addrs []string
+	for hostName := range hostNames {
+		resolved := m.resolveHost(cfg, hostName)
+		if resolved.ProxyJump != "" {
+			resolved = config.ResolveHost(resolved.ProxyJump, config.Host{}, m.sshReader)
+		}
+		addr := net.JoinHostPort(resolved.Hostname, strconv.Itoa(resolved.Port))
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// resolveHost resolves hostName against cfg and SSH config
+func (m *Manager) resolveHost(cfg *config.Config, hostName string) config.Host {
+	return cfg.ResolveHostName(hostName, m.sshReader)
+}
+
+// endpointKey identifies the server and account a resolved host connects to
+func endpointKey(host config.Host) string {
+	return host.User + "@" + net.JoinHostPort(host.Hostname, strconv.Itoa(host.Port))
 }
 
-// onSSHDisconnect handles SSH connection loss by updating all affected tunnels
-func (m *Manager) onSSHDisconnect(hostName string, err error) {
+// SetOnHostDisconnect sets a callback to be called with the affected tunnels
+// when the SSH connection to a host is lost
+func (m *Manager) SetOnHostDisconnect(fn func(hostName string, tunnels []string)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.onHostDisconnect = fn
+}
+
+// onSSHDisconnect handles SSH connection loss by updating all affected tunnels.
+// hostName is the host the client was first connected for.
+func (m *Manager) onSSHDisconnect(endpoint, hostName string, client *ssh.Client, err error) {
+	m.mu.Lock()
+
+	// Ignore disconnects from clients that have already been replaced
+	if m.sshClients[endpoint] != client {
+		m.mu.Unlock()
+		return
+	}
 
-	// Mark all tunnels using this host as errored
+	// Mark all tunnels using this connection as errored
+	var affected []string
 	for name, tunnel := range m.tunnels {
-		if m.tunnelHosts[name] == hostName {
-			tunnel.SetStatus(StatusError, fmt.Errorf("SSH connection lost: %w", err))
+		if m.tunnelEndpoints[name] != endpoint {
+			continue
+		}
+		// Lazy tunnels go back to idle and reconnect on their next connection
+		if local, ok := tunnel.(*LocalTunnel); ok && local.lazy != nil {
+			local.lazy.reset()
+			delete(m.tunnelEndpoints, name)
+			continue
 		}
+		tunnel.SetStatus(StatusError, fmt.Errorf("SSH connection lost: %w", err))
+		affected = append(affected, name)
 	}
+	sort.Strings(affected)
 
 	// Remove the disconnected client from cache
-	if client, exists := m.sshClients[hostName]; exists {
-		client.Close()
-		delete(m.sshClients, hostName)
+	client.Close()
+	delete(m.sshClients, endpoint)
+
+	callback := m.onHostDisconnect
+	m.mu.Unlock()
+
+	if callback != nil && len(affected) > 0 {
+		callback(hostName, affected)
 	}
 }
 
-// checkPortConflict checks if a tunnel's local port conflicts with running tunnels
-func (m *Manager) checkPortConflict(tunnelCfg config.Tunnel) error {
+// checkPortConflict checks if a tunnel's local port conflicts with running
+// tunnels, or a remote tunnel's forward with one already on the same server.
+// Must be called with m.mu held.
+func (m *Manager) checkPortConflict(cfg *config.Config, tunnelCfg config.Tunnel, host string) error {
+	ports := tunnelCfg.LocalPorts()
 	for name, tunnel := range m.tunnels {
-		if tunnel.Config().LocalPort == tunnelCfg.LocalPort {
-			return fmt.Errorf("port conflict: %d already used by tunnel '%s'",
-				tunnelCfg.LocalPort, name)
+		if running := tunnel.Config().LocalPorts(); running.Overlaps(ports) {
+			return errorf(ErrPortConflict, "port conflict: %d already used by tunnel '%s'",
+				max(running.First, ports.First), name)
+		}
+	}
+
+	forward, ok := m.remoteForwardFor(cfg, tunnelCfg, host)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(m.tunnels))
+	for name := range m.tunnels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		running, ok := m.remoteForwardFor(cfg, m.tunnels[name].Config(), m.tunnelHosts[name])
+		if ok && forward.overlaps(running) {
+			return errorf(ErrPortConflict, "remote port conflict: %d on host '%s' already forwarded by tunnel '%s'",
+				forward.sharedPort(running), host, name)
 		}
 	}
 	return nil
 }
 
-// checkGroupPortConflicts checks for port conflicts when enabling a group
-func (m *Manager) checkGroupPortConflicts(tunnelNames []string, cfg *config.Config) error {
-	// Build map of ports from new tunnels
+// checkGroupPortConflicts checks for port conflicts when enabling a group via
+// host, before any of its tunnels start
+func (m *Manager) checkGroupPortConflicts(members []config.GroupMember, host string, cfg *config.Config) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	running := make(map[string]config.Tunnel, len(m.tunnels))
+	for name, tunnel := range m.tunnels {
+		running[name] = tunnel.Config()
+	}
+
+	tunnelNames := make([]string, 0, len(members))
+	for _, member := range members {
+		tunnelNames = append(tunnelNames, member.Tunnel)
+	}
+	if errs := GroupPortConflicts(tunnelNames, cfg, running); len(errs) > 0 {
+		return errs[0]
+	}
+
+	// Remote forwards collide per server, so they need each tunnel's host
+	runningNames := make([]string, 0, len(running))
+	for name := range running {
+		runningNames = append(runningNames, name)
+	}
+	sort.Strings(runningNames)
+
+	type pendingForward struct {
+		name string
+		remoteForward
+	}
+	var pending []pendingForward
+	for _, member := range members {
+		memberHost := host
+		if member.Host != "" {
+			memberHost = member.Host
+		}
+		if _, ok := running[member.Tunnel]; ok || memberHost == "" {
+			continue
+		}
+		tunnelCfg, _ := cfg.GetTunnel(member.Tunnel)
+		forward, ok := m.remoteForwardFor(cfg, tunnelCfg, memberHost)
+		if !ok {
+			continue
+		}
+
+		for _, name := range runningNames {
+			other, ok := m.remoteForwardFor(cfg, running[name], m.tunnelHosts[name])
+			if ok && forward.overlaps(other) {
+				return errorf(ErrPortConflict, "remote port conflict: %d on host '%s' already forwarded by running tunnel '%s', cannot enable '%s'",
+					forward.sharedPort(other), memberHost, name, member.Tunnel)
+			}
+		}
+		for _, other := range pending {
+			if forward.overlaps(other.remoteForward) {
+				return errorf(ErrPortConflict, "remote port conflict: %d on host '%s' forwarded by both '%s' and '%s' in this group",
+					forward.sharedPort(other.remoteForward), memberHost, other.name, member.Tunnel)
+			}
+		}
+		pending = append(pending, pendingForward{name: member.Tunnel, remoteForward: forward})
+	}
+	return nil
+}
+
+// remoteForward is where a remote tunnel listens: ports and a bind address
+// on the SSH server it connects through
+type remoteForward struct {
+	server string // resolved hostname:port of the SSH server
+	bind   string
+	ports  config.PortRange
+}
+
+// remoteForwardFor returns where a remote tunnel listens when run via host,
+// or false for local tunnels
+func (m *Manager) remoteForwardFor(cfg *config.Config, tunnelCfg config.Tunnel, host string) (remoteForward, bool) {
+	if tunnelCfg.Type != config.TunnelTypeRemote || tunnelCfg.RemotePort == 0 {
+		return remoteForward{}, false
+	}
+	// Aliases and users don't matter; one server has one set of ports
+	resolved := m.resolveHost(cfg, host)
+	return remoteForward{
+		server: strings.ToLower(net.JoinHostPort(resolved.Hostname, strconv.Itoa(resolved.Port))),
+		bind:   tunnelCfg.RemoteBind,
+		ports:  tunnelCfg.RemotePorts(),
+	}, true
+}
+
+// overlaps reports whether two forwards would listen on the same port of
+// the same server
+func (f remoteForward) overlaps(other remoteForward) bool {
+	if f.server != other.server || !f.ports.Overlaps(other.ports) {
+		return false
+	}
+	return bindsOverlap(f.bind, other.bind)
+}
+
+// sharedPort returns the first port two overlapping forwards both use
+func (f remoteForward) sharedPort(other remoteForward) int {
+	return max(f.ports.First, other.ports.First)
+}
+
+// bindsOverlap reports whether two remote_bind addresses share a listener:
+// the same address, or either being a wildcard covering every interface
+func bindsOverlap(a, b string) bool {
+	normalize := func(bind string) string {
+		switch bind {
+		case "", "localhost", "127.0.0.1":
+			return "localhost"
+		case "0.0.0.0", "::", "*":
+			return "*"
+		}
+		return strings.ToLower(bind)
+	}
+	a, b = normalize(a), normalize(b)
+	return a == b || a == "*" || b == "*"
+}
+
+// GroupPortConflicts returns every conflict that starting tunnelNames alongside
+// the running tunnels would cause: unknown tunnels, ports already used by a
+// running tunnel, and ports shared within the group. Tunnels that are already
+// running are skipped.
+func GroupPortConflicts(tunnelNames []string, cfg *config.Config, running map[string]config.Tunnel) []error {
+	runningNames := make([]string, 0, len(running))
+	for name := range running {
+		runningNames = append(runningNames, name)
+	}
+	sort.Strings(runningNames)
+
+	var errs []error
 	newPorts := make(map[int]string)
 	for _, name := range tunnelNames {
 		// Skip if already running
-		if _, running := m.tunnels[name]; running {
+		if _, ok := running[name]; ok {
 			continue
 		}
 
 		tunnelCfg, ok := cfg.GetTunnel(name)
 		if !ok {
-			return fmt.Errorf("tunnel '%s' not found", name)
+			errs = append(errs, errorf(ErrTunnelNotFound, "tunnel '%s' not found", name))
+			continue
 		}
 
 		// Check against running tunnels
-		for runningName, tunnel := range m.tunnels {
-			if tunnel.Config().LocalPort == tunnelCfg.LocalPort {
-				return fmt.Errorf("port conflict: %d already used by running tunnel '%s', cannot enable '%s'",
-					tunnelCfg.LocalPort, runningName, name)
+		ports := tunnelCfg.LocalPorts()
+		for _, runningName := range runningNames {
+			if other := running[runningName].LocalPorts(); other.Overlaps(ports) {
+				errs = append(errs, errorf(ErrPortConflict, "port conflict: %d already used by running tunnel '%s', cannot enable '%s'",
+					max(other.First, ports.First), runningName, name))
+				break
 			}
 		}
 
 		// Check against other tunnels in this group
-		if existingName, exists := newPorts[tunnelCfg.LocalPort]; exists {
-			return fmt.Errorf("port conflict: %d used by both '%s' and '%s' in this group",
-				tunnelCfg.LocalPort, existingName, name)
+		conflict := false
+		for port := ports.First; port <= ports.Last; port++ {
+			if existingName, exists := newPorts[port]; exists {
+				errs = append(errs, errorf(ErrPortConflict, "port conflict: %d used by both '%s' and '%s' in this group",
+					port, existingName, name))
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
 		}
 
-		newPorts[tunnelCfg.LocalPort] = name
+		for port := ports.First; port <= ports.Last; port++ {
+			newPorts[port] = name
+		}
 	}
 
-	return nil
+	return errs
 }
 
 // cleanupUnusedClients removes SSH clients that have no active tunnels
 func (m *Manager) cleanupUnusedClients() {
-	usedHosts := make(map[string]bool)
-	for _, host := range m.tunnelHosts {
-		usedHosts[host] = true
+	usedEndpoints := make(map[string]bool)
+	for _, endpoint := range m.tunnelEndpoints {
+		usedEndpoints[endpoint] = true
 	}
 
-	for hostName, client := range m.sshClients {
-		if !usedHosts[hostName] {
+	for endpoint, client := range m.sshClients {
+		if !usedEndpoints[endpoint] {
 			client.Close()
-			delete(m.sshClients, hostName)
+			delete(m.sshClients, endpoint)
 		}
 	}
 }
 
-// CheckHealth performs a health check on all SSH connections concurrently and updates tunnel statuses
+// GetHostRTT returns the last measured keepalive round-trip time for a host,
+// or false if there is no connection or no measurement yet
+func (m *Manager) GetHostRTT(hostName string) (time.Duration, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for name, host := range m.tunnelHosts {
+		if host != hostName {
+			continue
+		}
+		client, ok := m.sshClients[m.tunnelEndpoints[name]]
+		if !ok {
+			return 0, false
+		}
+		rtt := client.RTT()
+		return rtt, rtt > 0
+	}
+	return 0, false
+}
+
+// CheckHealth performs a health check on all SSH connections concurrently,
+// updating tunnel statuses and each host's round-trip time
 func (m *Manager) CheckHealth() {
 	m.mu.RLock()
 	// Get list of hosts and clients to check
@@ -392,9 +1152,19 @@ func (m *Manager) ReconnectTunnel(ctx context.Context, name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.reconnectTunnel(ctx, name, StatusReconnecting)
+}
+
+// reconnectTunnel rebuilds a tunnel on a fresh or shared SSH connection,
+// starting it in status: StatusReconnecting to count it as a reconnect, or
+// StatusConnecting for a planned restart. Must be called with m.mu held.
+func (m *Manager) reconnectTunnel(ctx context.Context, name string, status Status) error {
 	tunnel, exists := m.tunnels[name]
 	if !exists {
-		return fmt.Errorf("tunnel '%s' not found", name)
+		return errorf(ErrNotRunning, "tunnel '%s' not found", name)
+	}
+	if tunnel.Status() == StatusPaused {
+		return errorf(ErrPaused, "tunnel '%s' is paused", name)
 	}
 
 	host, hasHost := m.tunnelHosts[name]
@@ -407,25 +1177,50 @@ func (m *Manager) ReconnectTunnel(ctx context.Context, name string) error {
 	// Stop the old tunnel
 	tunnel.Stop()
 
-	// Get fresh SSH client (reconnect if needed)
-	delete(m.sshClients, host)
-	client, err := m.getOrCreateSSHClient(ctx, host)
-	if err != nil {
-		tunnel.SetStatus(StatusError, err)
-		return err
+	// Get SSH client, reconnecting if needed. Other tunnels on the same host
+	// may have already re-established the shared connection. Lazy tunnels
+	// start idle again and connect on their next connection.
+	var client *ssh.Client
+	if tunnelCfg.Lazy {
+		delete(m.tunnelEndpoints, name)
+		host = m.primaryHost(name)
+		m.tunnelHosts[name] = host
+	} else {
+		cfg, err := m.Config()
+		if err != nil {
+			tunnel.SetStatus(StatusError, err)
+			return err
+		}
+		// Go back to the most preferred host that can be reached, so a
+		// tunnel on a fallback returns to its primary once it recovers
+		var endpoint string
+		client, endpoint, host, err = m.connectAny(ctx, cfg, m.hostsFor(name))
+		if err != nil {
+			tunnel.SetStatus(StatusError, err)
+			return err
+		}
+		m.tunnelEndpoints[name] = endpoint
+		m.tunnelHosts[name] = host
 	}
+	m.cleanupUnusedClients()
 
-	// Create new tunnel
-	var newTunnel Tunnel
-	switch tunnelCfg.Type {
-	case config.TunnelTypeLocal:
-		newTunnel = NewLocalTunnel(name, tunnelCfg, client)
-	case config.TunnelTypeRemote:
-		newTunnel = NewRemoteTunnel(name, tunnelCfg, client)
+	return m.replaceTunnel(ctx, name, tunnel, host, client, status)
+}
+
+// replaceTunnel starts a new instance of a stopped tunnel on client in place
+// of the old one. Must be called with m.mu held.
+func (m *Manager) replaceTunnel(ctx context.Context, name string, old Tunnel, host string, client *ssh.Client, status Status) error {
+	newTunnel, err := m.newTunnel(name, old.Config(), host, client)
+	if err != nil {
+		old.SetStatus(StatusError, err)
+		return err
 	}
 
 	// Copy reconnect count
-	newTunnel.SetStatus(StatusReconnecting, nil)
+	newTunnel.SetStatus(status, nil)
+
+	// The server may still hold the old session's remote forward for a moment
+	setRetryBind(newTunnel)
 
 	if err := newTunnel.Start(ctx); err != nil {
 		newTunnel.SetStatus(StatusError, err)
@@ -436,3 +1231,111 @@ func (m *Manager) ReconnectTunnel(ctx context.Context, name string) error {
 	m.tunnels[name] = newTunnel
 	return nil
 }
+
+// DropConnections closes a running tunnel's open connections without
+// stopping it, returning how many were closed
+func (m *Manager) DropConnections(name string) (int, error) {
+	m.mu.RLock()
+	tunnel, exists := m.tunnels[name]
+	m.mu.RUnlock()
+	if !exists {
+		return 0, errorf(ErrNotRunning, "tunnel '%s' is not running", name)
+	}
+	return tunnel.DropConnections(), nil
+}
+
+// PauseTunnel stops a tunnel forwarding, closing its listener and releasing
+// its SSH connection, but keeps it with its host, override, and stats until
+// it is resumed or stopped. Paused tunnels aren't reconnected.
+func (m *Manager) PauseTunnel(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tunnel, exists := m.tunnels[name]
+	if !exists {
+		return errorf(ErrNotRunning, "tunnel '%s' is not running", name)
+	}
+	if tunnel.Status() == StatusPaused {
+		return nil
+	}
+
+	if err := tunnel.Stop(); err != nil {
+		return err
+	}
+	tunnel.SetStatus(StatusPaused, nil)
+	delete(m.tunnelEndpoints, name)
+	delete(m.nextRetry, name)
+	m.cleanupUnusedClients()
+	return nil
+}
+
+// ResumeTunnel starts a paused tunnel again via the host it was using,
+// keeping its stats. Resuming a tunnel that isn't paused does nothing.
+func (m *Manager) ResumeTunnel(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paused, exists := m.tunnels[name]
+	if !exists {
+		return errorf(ErrNotRunning, "tunnel '%s' is not running", name)
+	}
+	if paused.Status() != StatusPaused {
+		return nil
+	}
+
+	// Pick up any config reloaded while the tunnel was paused
+	cfg, err := m.Config()
+	if err != nil {
+		return err
+	}
+	tunnelCfg, ok := cfg.GetTunnel(name)
+	if !ok {
+		return errorf(ErrTunnelNotFound, "tunnel '%s' not found in config", name)
+	}
+	tunnelCfg, err = m.overrides[name].Apply(tunnelCfg)
+	if err != nil {
+		return err
+	}
+
+	// Something else may have taken the port while the tunnel was paused
+	if tunnelCfg.Type == config.TunnelTypeLocal {
+		if err := CheckTunnelPortsAvailable(tunnelCfg); err != nil {
+			return err
+		}
+	}
+
+	var client *ssh.Client
+	var endpoint string
+	host := m.primaryHost(name)
+	if !tunnelCfg.Lazy {
+		client, endpoint, host, err = m.connectAny(ctx, cfg, m.hostsFor(name))
+		if err != nil {
+			return err
+		}
+	}
+
+	tunnel, err := m.newTunnel(name, tunnelCfg, host, client)
+	if err != nil {
+		return err
+	}
+	if prev, ok := paused.(interface{ base() *baseTunnel }); ok {
+		if next, ok := tunnel.(interface{ base() *baseTunnel }); ok {
+			next.base().stats = prev.base().stats
+		}
+	}
+
+	// The server may still hold the paused forward for a moment
+	setRetryBind(tunnel)
+
+	if err := tunnel.Start(ctx); err != nil {
+		m.cleanupUnusedClients()
+		return err
+	}
+
+	m.tunnels[name] = tunnel
+	m.tunnelHosts[name] = host
+	if endpoint != "" {
+		m.tunnelEndpoints[name] = endpoint
+	}
+	return nil
+}
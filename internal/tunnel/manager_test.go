@@ -0,0 +1,528 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/ssh"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestManagerSharesClientAcrossAliases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	writeTestFile(t, filepath.Join(home, ".ssh", "config"), `
+Host bastion
+  HostName bastion.example.com
+  User admin
+`)
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), `
+hosts:
+  bastion.example.com:
+    user: admin
+  other:
+    hostname: bastion.example.com
+    user: deploy
+`)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	cfg, err := m.Config()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	endpoints := make(map[string]string)
+	for _, alias := range []string{"bastion", "bastion.example.com", "other"} {
+		endpoints[alias] = endpointKey(m.resolveHost(cfg, alias))
+	}
+
+	if endpoints["bastion"] != endpoints["bastion.example.com"] {
+		t.Errorf("expected aliases to share an endpoint, got %q and %q", endpoints["bastion"], endpoints["bastion.example.com"])
+	}
+	if endpoints["bastion"] == endpoints["other"] {
+		t.Errorf("expected a different user to get its own endpoint, got %q", endpoints["other"])
+	}
+
+	// Both aliases' tunnels hold the one client; it survives until both stop
+	endpoint := endpoints["bastion"]
+	m.sshClients[endpoint] = ssh.NewClient(config.Host{}, config.DefaultConfig())
+	m.tunnelHosts["web"] = "bastion"
+	m.tunnelEndpoints["web"] = endpoint
+	m.tunnelHosts["db"] = "bastion.example.com"
+	m.tunnelEndpoints["db"] = endpoint
+	if got := m.GetTunnelEndpoint("db"); got != "admin@bastion.example.com:22" {
+		t.Errorf("expected db to report its resolved endpoint, got %q", got)
+	}
+
+	delete(m.tunnelHosts, "web")
+	delete(m.tunnelEndpoints, "web")
+	m.cleanupUnusedClients()
+	if _, ok := m.sshClients[endpoint]; !ok {
+		t.Fatal("expected client to be kept while another alias uses it")
+	}
+
+	delete(m.tunnelHosts, "db")
+	delete(m.tunnelEndpoints, "db")
+	m.cleanupUnusedClients()
+	if _, ok := m.sshClients[endpoint]; ok {
+		t.Error("expected client to be closed once no tunnels use it")
+	}
+}
+
+func TestStartGroupStagger(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), `
+tunnels:
+  web:
+    type: remote
+    local_port: 3000
+    remote_port: 9000
+  db:
+    type: remote
+    local_port: 5432
+    remote_port: 9001
+groups:
+  dev:
+    tunnels: [web, db]
+    stagger: 50ms
+`)
+
+	// Both tunnels already run via the host, so starting them needs no SSH
+	newManager := func() *Manager {
+		m, err := NewManager()
+		if err != nil {
+			t.Fatalf("NewManager failed: %v", err)
+		}
+		for _, name := range []string{"web", "db"} {
+			cfg := config.Tunnel{Type: config.TunnelTypeRemote}
+			m.tunnels[name] = NewRemoteTunnel(name, cfg, &fakeSSHListener{err: errors.New("unused")})
+			m.tunnelHosts[name] = "bastion"
+		}
+		return m
+	}
+
+	t.Run("waits between tunnels", func(t *testing.T) {
+		m := newManager()
+		start := time.Now()
+		if err := m.StartGroup(context.Background(), "dev", "bastion"); err != nil {
+			t.Fatalf("StartGroup failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("expected StartGroup to wait at least 50ms between tunnels, took %s", elapsed)
+		}
+	})
+
+	t.Run("cancel rolls back", func(t *testing.T) {
+		m := newManager()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		if err := m.StartGroup(ctx, "dev", "bastion"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if running := m.ListRunningTunnels(); len(running) != 1 || running[0] != "db" {
+			t.Errorf("expected only 'db' (not yet reached) to be left running, got %v", running)
+		}
+	})
+}
+
+// slowTunnel is a running tunnel whose Stop waits, like one draining connections
+type slowTunnel struct {
+	*baseTunnel
+	delay   time.Duration
+	stopped atomic.Bool
+}
+
+func (t *slowTunnel) Start(ctx context.Context) error { return nil }
+
+func (t *slowTunnel) Stop() error {
+	time.Sleep(t.delay)
+	t.stopped.Store(true)
+	return nil
+}
+
+func TestStopTunnelsConcurrently(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), `
+tunnels:
+  web: {type: local, local_port: 8080, remote_port: 80}
+  api: {type: local, local_port: 8081, remote_port: 80}
+  db: {type: local, local_port: 5432, remote_port: 5432}
+  cache: {type: local, local_port: 6379, remote_port: 6379}
+groups:
+  dev:
+    tunnels: [web, api@east, api@west, cache]
+`)
+
+	const delay = 50 * time.Millisecond
+	newManager := func(running ...string) (*Manager, map[string]*slowTunnel) {
+		m, err := NewManager()
+		if err != nil {
+			t.Fatalf("NewManager failed: %v", err)
+		}
+		tunnels := make(map[string]*slowTunnel)
+		for _, name := range running {
+			tun := &slowTunnel{baseTunnel: newBaseTunnel(name, config.Tunnel{}), delay: delay}
+			tunnels[name] = tun
+			m.tunnels[name] = tun
+			m.tunnelHosts[name] = "bastion"
+			m.reconnectHistoryFor(name)
+		}
+		return m, tunnels
+	}
+
+	t.Run("stop all", func(t *testing.T) {
+		m, tunnels := newManager("web", "api", "db", "cache")
+
+		start := time.Now()
+		if err := m.StopAll(); err != nil {
+			t.Fatalf("StopAll failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= 2*delay {
+			t.Errorf("expected tunnels to stop concurrently in about %s, took %s", delay, elapsed)
+		}
+		for name, tun := range tunnels {
+			if !tun.stopped.Load() {
+				t.Errorf("expected '%s' to be stopped", name)
+			}
+		}
+		if len(m.tunnels) != 0 || len(m.tunnelHosts) != 0 || len(m.reconnects) != 0 {
+			t.Errorf("expected every tunnel to be forgotten, got %v", m.ListRunningTunnels())
+		}
+	})
+
+	t.Run("stop group", func(t *testing.T) {
+		m, tunnels := newManager("web", "api", "db")
+
+		start := time.Now()
+		err := m.StopGroup("dev")
+		if elapsed := time.Since(start); elapsed >= 2*delay {
+			t.Errorf("expected tunnels to stop concurrently in about %s, took %s", delay, elapsed)
+		}
+
+		// cache isn't running; the rest of the group still stops
+		if !errors.Is(err, ErrNotRunning) {
+			t.Errorf("expected ErrNotRunning for 'cache', got %v", err)
+		}
+		if !tunnels["web"].stopped.Load() || !tunnels["api"].stopped.Load() {
+			t.Error("expected the group's running tunnels to be stopped")
+		}
+		if running := m.ListRunningTunnels(); len(running) != 1 || running[0] != "db" {
+			t.Errorf("expected only 'db' to keep running, got %v", running)
+		}
+	})
+}
+
+func TestRemotePortConflicts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), `
+hosts:
+  bastion:
+    hostname: bastion.example.com
+  jump:
+    hostname: bastion.example.com
+    user: deploy
+  other:
+    hostname: other.example.com
+tunnels:
+  web:
+    type: remote
+    local_port: 3000
+    remote_port: 9000
+  api:
+    type: remote
+    local_port: 3001
+    remote_port: 9000
+  public:
+    type: remote
+    local_port: 3002
+    remote_port: 9000
+    remote_bind: 0.0.0.0
+  admin:
+    type: remote
+    local_port: 3003
+    remote_port: 9000
+    remote_bind: 10.0.0.5
+  ftp:
+    type: remote
+    local_port_range: 3010-3020
+    remote_port_range: 8995-9005
+groups:
+  clash:
+    tunnels: [api, public]
+  split:
+    tunnels: [api, public@other]
+`)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	cfg, err := m.Config()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	webCfg, _ := cfg.GetTunnel("web")
+	m.tunnels["web"] = NewRemoteTunnel("web", webCfg, &fakeSSHListener{err: errors.New("unused")})
+	m.tunnelHosts["web"] = "bastion"
+
+	tests := []struct {
+		name     string
+		tunnel   string
+		host     string
+		conflict bool
+	}{
+		{"same port via an alias of the same server", "api", "jump", true},
+		{"same port on another server", "api", "other", false},
+		{"wildcard bind covers localhost", "public", "bastion", true},
+		{"different bind address", "admin", "bastion", false},
+		{"remote port range covering the port", "ftp", "bastion", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tunnelCfg, _ := cfg.GetTunnel(tt.tunnel)
+			m.mu.Lock()
+			err := m.checkPortConflict(cfg, tunnelCfg, tt.host)
+			m.mu.Unlock()
+			if got := errors.Is(err, ErrPortConflict); got != tt.conflict {
+				t.Errorf("expected conflict %v, got %v", tt.conflict, err)
+			}
+		})
+	}
+
+	// Starting a conflicting tunnel fails before any SSH connection is made
+	if err := m.StartTunnel(context.Background(), "api", "jump"); !errors.Is(err, ErrPortConflict) {
+		t.Errorf("expected StartTunnel to report a remote port conflict, got %v", err)
+	}
+
+	// Groups are checked against each other as well as running tunnels
+	delete(m.tunnels, "web")
+	delete(m.tunnelHosts, "web")
+	clash, _ := cfg.GetGroupMembers("clash")
+	if err := m.checkGroupPortConflicts(clash, "bastion", cfg); !errors.Is(err, ErrPortConflict) {
+		t.Errorf("expected a conflict within the group, got %v", err)
+	}
+	split, _ := cfg.GetGroupMembers("split")
+	if err := m.checkGroupPortConflicts(split, "bastion", cfg); err != nil {
+		t.Errorf("expected no conflict across servers, got %v", err)
+	}
+}
+
+func TestBindsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "localhost", true},
+		{"", "127.0.0.1", true},
+		{"0.0.0.0", "10.0.0.5", true},
+		{"", "10.0.0.5", false},
+		{"10.0.0.5", "10.0.0.6", false},
+		{"Gateway.internal", "gateway.internal", true},
+	}
+
+	for _, tt := range tests {
+		if got := bindsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("bindsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPauseResumeTunnel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	// Lazy tunnels listen without connecting to the host
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), fmt.Sprintf(`
+tunnels:
+  web: {type: local, local_host: 127.0.0.1, local_port: %d, remote_port: 80, lazy: true}
+`, port))
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := m.StartTunnel(ctx, "web", "bastion"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	defer m.StopAll()
+	m.tunnels["web"].(*LocalTunnel).stats.AddSent(100)
+
+	if err := m.PauseTunnel("web"); err != nil {
+		t.Fatalf("PauseTunnel failed: %v", err)
+	}
+	info, ok := m.GetTunnelInfo("web")
+	if !ok || info.Status != StatusPaused {
+		t.Fatalf("expected a paused tunnel, got %+v", info)
+	}
+	if err := CheckPortAvailable("127.0.0.1", port); err != nil {
+		t.Errorf("expected pausing to close the listener: %v", err)
+	}
+	if err := m.ReconnectTunnel(ctx, "web"); !errors.Is(err, ErrPaused) {
+		t.Errorf("expected ErrPaused from ReconnectTunnel, got %v", err)
+	}
+
+	if err := m.ResumeTunnel(ctx, "web"); err != nil {
+		t.Fatalf("ResumeTunnel failed: %v", err)
+	}
+	info, _ = m.GetTunnelInfo("web")
+	if info.Status != StatusIdle {
+		t.Errorf("expected the resumed tunnel to listen again, got %s", info.Status)
+	}
+	if info.Stats.BytesSent != 100 {
+		t.Errorf("expected stats to survive the pause, got %d bytes sent", info.Stats.BytesSent)
+	}
+	if host := m.GetTunnelHost("web"); host != "bastion" {
+		t.Errorf("expected the tunnel to keep its host, got %q", host)
+	}
+
+	if err := m.PauseTunnel("db"); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("expected ErrNotRunning pausing a stopped tunnel, got %v", err)
+	}
+}
+
+func TestStartTunnelRestartsFailedTunnel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	// Lazy tunnels listen without connecting to the host
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), `
+tunnels:
+  web: {type: local, local_host: 127.0.0.1, local_port: 0, remote_port: 80, lazy: true, reconnect: false}
+`)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := m.StartTunnel(ctx, "web", "bastion"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	defer m.StopAll()
+
+	// A tunnel with reconnect off stays in error after it drops
+	failed := m.tunnels["web"]
+	failed.SetStatus(StatusError, errors.New("connection lost"))
+
+	if err := m.StartTunnel(ctx, "web", "bastion"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	if m.tunnels["web"] == failed {
+		t.Fatal("expected bringing a failed tunnel up again to start it afresh")
+	}
+	if info, _ := m.GetTunnelInfo("web"); info.Status != StatusIdle {
+		t.Errorf("expected the restarted tunnel to listen again, got %s", info.Status)
+	}
+}
+
+func TestHostOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		fallbacks [][]string
+		want      []string
+	}{
+		{"no fallbacks", "a", nil, []string{"a"}},
+		{"group then tunnel", "a", [][]string{{"b"}, {"c"}}, []string{"a", "b", "c"}},
+		{"skips repeats", "a", [][]string{{"b", "a"}, {"b", "c"}}, []string{"a", "b", "c"}},
+		{"skips empty", "a", [][]string{{""}}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostOrder(tt.host, tt.fallbacks...); !slices.Equal(got, tt.want) {
+				t.Errorf("hostOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartTunnelTriesFallbackHosts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+
+	// Nothing listens on the port, so every host is unreachable
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), fmt.Sprintf(`
+hosts:
+  primary:
+    hostname: 127.0.0.1
+    port: %d
+  backup:
+    hostname: 127.0.0.1
+    port: %d
+tunnels:
+  web:
+    type: local
+    local_port: 0
+    remote_port: 80
+    fallback_hosts: [backup]
+`, port, port))
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	err = m.StartTunnel(context.Background(), "web", "primary")
+	if !errors.Is(err, ErrHostUnreachable) {
+		t.Fatalf("expected ErrHostUnreachable, got %v", err)
+	}
+	for _, host := range []string{"'primary'", "'backup'"} {
+		if !strings.Contains(err.Error(), host) {
+			t.Errorf("expected the error to mention host %s, got %v", host, err)
+		}
+	}
+	if running := m.ListRunningTunnels(); len(running) != 0 {
+		t.Errorf("expected no tunnels running, got %v", running)
+	}
+}
@@ -0,0 +1,48 @@
+package tunnel
+
+import (
+	"fmt"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// RemoteOverride replaces a tunnel's configured remote target for a single
+// run without touching the config file. Zero fields keep the configured value.
+type RemoteOverride struct {
+	Host string
+	Port int
+}
+
+// IsZero reports whether the override changes nothing
+func (o RemoteOverride) IsZero() bool {
+	return o.Host == "" && o.Port == 0
+}
+
+// Apply checks the override against a tunnel's config and returns the config
+// with the override applied
+func (o RemoteOverride) Apply(t config.Tunnel) (config.Tunnel, error) {
+	if o.Port < 0 || o.Port > 65535 {
+		return t, fmt.Errorf("remote port %d must be between 1 and 65535", o.Port)
+	}
+	if msg := config.ValidateHostField(o.Host); msg != "" {
+		return t, fmt.Errorf("remote host %s", msg)
+	}
+	if !o.IsZero() && t.RemoteSocket != "" {
+		return t, fmt.Errorf("remote target can't be overridden for a tunnel that forwards to remote_socket")
+	}
+	if o.Port != 0 && t.IsRange() {
+		return t, fmt.Errorf("remote port can't be overridden for a tunnel that forwards a port range")
+	}
+	// Remote forwards listen on the server; there is no remote host to dial
+	if o.Host != "" && t.Type == config.TunnelTypeRemote {
+		return t, fmt.Errorf("remote host can only be overridden for local tunnels")
+	}
+
+	if o.Host != "" {
+		t.RemoteHost = o.Host
+	}
+	if o.Port != 0 {
+		t.RemotePort = o.Port
+	}
+	return t, nil
+}
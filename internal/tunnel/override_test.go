@@ -0,0 +1,53 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+func TestRemoteOverrideApply(t *testing.T) {
+	local := config.Tunnel{Type: config.TunnelTypeLocal, LocalPort: 5432, RemoteHost: "db.internal", RemotePort: 5432}
+	remote := config.Tunnel{Type: config.TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000}
+	socket := config.Tunnel{Type: config.TunnelTypeLocal, LocalPort: 2375, RemoteSocket: "/var/run/docker.sock"}
+
+	tests := []struct {
+		name     string
+		cfg      config.Tunnel
+		override RemoteOverride
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{"no override", local, RemoteOverride{}, "db.internal", 5432, false},
+		{"host only", local, RemoteOverride{Host: "db-replica.internal"}, "db-replica.internal", 5432, false},
+		{"port only", local, RemoteOverride{Port: 6432}, "db.internal", 6432, false},
+		{"ipv6 host", local, RemoteOverride{Host: "fd00::5", Port: 6432}, "fd00::5", 6432, false},
+		{"port out of range", local, RemoteOverride{Port: 70000}, "", 0, true},
+		{"host with port", local, RemoteOverride{Host: "db:5432"}, "", 0, true},
+		{"remote tunnel port", remote, RemoteOverride{Port: 9001}, "", 9001, false},
+		{"remote tunnel host", remote, RemoteOverride{Host: "example.com"}, "", 0, true},
+		{"remote socket tunnel", socket, RemoteOverride{Port: 2375}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.override.Apply(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RemoteHost != tt.wantHost || got.RemotePort != tt.wantPort {
+				t.Errorf("got %s:%d, want %s:%d", got.RemoteHost, got.RemotePort, tt.wantHost, tt.wantPort)
+			}
+			if got.LocalPort != tt.cfg.LocalPort {
+				t.Errorf("override changed local port to %d", got.LocalPort)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// CheckPortAvailable probes whether a local address can be bound by briefly
+// listening on it. This catches ports held by processes outside bore, which
+// checkPortConflict can't see.
+func CheckPortAvailable(host string, port int) error {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return errorf(ErrPortConflict, "port %d is already in use by another process (%s)%s", port, addr, heldBy(port))
+		}
+		if errors.Is(err, syscall.EACCES) {
+			return fmt.Errorf("permission denied binding port %d (%s)", port, addr)
+		}
+		return fmt.Errorf("cannot listen on %s: %w", addr, err)
+	}
+
+	return listener.Close()
+}
+
+// CheckTunnelPortsAvailable checks every local port a tunnel binds, which is
+// more than one for a port range
+func CheckTunnelPortsAvailable(t config.Tunnel) error {
+	ports := t.LocalPorts()
+	for port := ports.First; port <= ports.Last; port++ {
+		if err := CheckPortAvailable(t.LocalHost, port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heldBy names the process holding port for an error message, like
+// ", held by pid 1234 (postgres)", or returns "" if it can't be found
+func heldBy(port int) string {
+	if holder := lookupPortHolder(port); holder != "" {
+		return ", held by " + holder
+	}
+	return ""
+}
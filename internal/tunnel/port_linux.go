@@ -0,0 +1,107 @@
+package tunnel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is the st column of a listening socket in /proc/net/tcp
+const tcpListenState = "0A"
+
+// portHolder is the process listening on a port
+type portHolder struct {
+	pid     int // 0 if the process couldn't be found, e.g. it belongs to another user
+	command string
+	uid     int
+}
+
+func (h portHolder) String() string {
+	if h.pid == 0 {
+		return fmt.Sprintf("a process owned by uid %d", h.uid)
+	}
+	return fmt.Sprintf("pid %d (%s)", h.pid, h.command)
+}
+
+// lookupPortHolder describes the process listening on a local TCP port, or
+// returns "" if it can't be found
+func lookupPortHolder(port int) string {
+	holder, ok := findPortHolder("/proc", port)
+	if !ok {
+		return ""
+	}
+	return holder.String()
+}
+
+// findPortHolder finds the process listening on port from the socket tables
+// and file descriptors under proc. Processes of other users can't be
+// inspected, so their sockets are reported by owner only.
+func findPortHolder(proc string, port int) (portHolder, bool) {
+	owners := make(map[string]int) // socket inode -> owning uid
+	for _, table := range []string{"tcp", "tcp6"} {
+		listeningSockets(filepath.Join(proc, "net", table), port, owners)
+	}
+	if len(owners) == 0 {
+		return portHolder{}, false
+	}
+
+	entries, _ := os.ReadDir(proc)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(proc, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+			if uid, ok := owners[strings.TrimSuffix(inode, "]")]; ok {
+				comm, _ := os.ReadFile(filepath.Join(proc, entry.Name(), "comm"))
+				return portHolder{pid: pid, command: strings.TrimSpace(string(comm)), uid: uid}, true
+			}
+		}
+	}
+
+	for _, uid := range owners {
+		return portHolder{uid: uid}, true
+	}
+	return portHolder{}, false
+}
+
+// listeningSockets records the inode and owner of each socket listening on
+// port in a /proc/net/tcp style table
+func listeningSockets(path string, port int, owners map[string]int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(data), "\n")
+	// Skip the header: sl local_address rem_address st tx:rx tr:when retrnsmt uid timeout inode
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != tcpListenState {
+			continue
+		}
+		_, portHex, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if p, err := strconv.ParseUint(portHex, 16, 16); err != nil || int(p) != port {
+			continue
+		}
+		uid, _ := strconv.Atoi(fields[7])
+		owners[fields[9]] = uid
+	}
+}
@@ -0,0 +1,67 @@
+package tunnel
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFindPortHolder(t *testing.T) {
+	proc := t.TempDir()
+	writeTestFile(t, filepath.Join(proc, "net", "tcp"), `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4242 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 4343 1 0000000000000000 100 0 0 10 0
+   2: 00000000:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000    0        0 5555 1 0000000000000000 100 0 0 10 0
+`)
+	writeTestFile(t, filepath.Join(proc, "net", "tcp6"), "  sl  local_address remote_address st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n")
+	writeTestFile(t, filepath.Join(proc, "1234", "comm"), "postgres\n")
+	if err := os.MkdirAll(filepath.Join(proc, "1234", "fd"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[4242]", filepath.Join(proc, "1234", "fd", "3")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		port   int
+		want   string
+		wantOK bool
+	}{
+		{"owning process found", 8080, "pid 1234 (postgres)", true},
+		{"owner not visible", 5432, "a process owned by uid 0", true},
+		{"nothing listening", 9090, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder, ok := findPortHolder(proc, tt.port)
+			if ok != tt.wantOK {
+				t.Fatalf("expected found=%v, got %v", tt.wantOK, ok)
+			}
+			if ok && holder.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, holder.String())
+			}
+		})
+	}
+}
+
+func TestCheckPortAvailableNamesHolder(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	err = CheckPortAvailable("127.0.0.1", port)
+	if err == nil {
+		t.Fatal("expected error for port in use")
+	}
+	if want := "held by pid " + strconv.Itoa(os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("expected the error to name this process (%s), got %v", want, err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package tunnel
+
+// lookupPortHolder can't find the process holding a port on this platform
+func lookupPortHolder(port int) string {
+	return ""
+}
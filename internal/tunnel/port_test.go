@@ -0,0 +1,42 @@
+package tunnel
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCheckPortAvailable(t *testing.T) {
+	// Grab a free port, then release it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	if err := CheckPortAvailable("127.0.0.1", port); err != nil {
+		t.Errorf("expected free port to be available, got %v", err)
+	}
+}
+
+func TestCheckPortAvailableInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	err = CheckPortAvailable("127.0.0.1", port)
+	if err == nil {
+		t.Fatal("expected error for port in use")
+	}
+	if !strings.Contains(err.Error(), "already in use by another process") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, ErrPortConflict) {
+		t.Errorf("expected ErrPortConflict, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// portTunnel is a tunnel forwarding a single port, which a RangeTunnel runs
+// one of per port
+type portTunnel interface {
+	Tunnel
+	base() *baseTunnel
+}
+
+// RangeTunnel forwards a range of ports as one tunnel. It runs a local or
+// remote tunnel per port, which all count towards its stats, and reports the
+// worst of their statuses as its own.
+type RangeTunnel struct {
+	*baseTunnel
+	ports []portTunnel
+
+	// settling is set while the ports start or stop, whose individual
+	// status changes would otherwise flap the range's status
+	settling atomic.Bool
+}
+
+// NewRangeTunnel creates a tunnel forwarding cfg's port range through ports,
+// one tunnel per port as returned by cfg.Expand
+func NewRangeTunnel(name string, cfg config.Tunnel, ports []portTunnel) *RangeTunnel {
+	t := &RangeTunnel{
+		baseTunnel: newBaseTunnel(name, cfg),
+		ports:      ports,
+	}
+	for _, port := range ports {
+		port.SetOnStatusChange(func(string, Status, error) {
+			if !t.settling.Load() {
+				t.updateStatus()
+			}
+		})
+	}
+	return t
+}
+
+// Start starts every port, stopping the ones already started if any fails
+func (t *RangeTunnel) Start(ctx context.Context) error {
+	t.SetStatus(StatusConnecting, nil)
+
+	t.settling.Store(true)
+	for i, port := range t.ports {
+		// Stats are shared, including ones a resumed tunnel carried over
+		port.base().stats = t.stats
+		if err := port.Start(ctx); err != nil {
+			for _, started := range t.ports[:i] {
+				started.Stop()
+			}
+			t.settling.Store(false)
+			t.SetStatus(StatusError, err)
+			return err
+		}
+	}
+	t.settling.Store(false)
+
+	t.updateStatus()
+	return nil
+}
+
+// Stop stops every port
+func (t *RangeTunnel) Stop() error {
+	t.settling.Store(true)
+	var errs []error
+	for _, port := range t.ports {
+		if err := port.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.settling.Store(false)
+
+	t.SetStatus(StatusStopped, nil)
+	return errors.Join(errs...)
+}
+
+// DropConnections closes the open connections on every port
+func (t *RangeTunnel) DropConnections() int {
+	dropped := 0
+	for _, port := range t.ports {
+		dropped += port.DropConnections()
+	}
+	return dropped
+}
+
+// updateStatus sets the range's status from its ports: an error on any port
+// is an error for the whole range, and it is only connected once all are
+func (t *RangeTunnel) updateStatus() {
+	status := StatusConnected
+	for _, port := range t.ports {
+		switch port.Status() {
+		case StatusError:
+			b := port.base()
+			b.mu.RLock()
+			err := b.lastError
+			b.mu.RUnlock()
+			t.SetStatus(StatusError, err)
+			return
+		case StatusConnecting, StatusStopped:
+			status = StatusConnecting
+		}
+	}
+	t.SetStatus(status, nil)
+}
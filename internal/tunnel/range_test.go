@@ -0,0 +1,98 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// newTestRange builds a range tunnel of local ports on free ports, since a
+// contiguous free range can't be picked reliably in tests
+func newTestRange(t *testing.T, ports int) *RangeTunnel {
+	t.Helper()
+	cfg := config.Tunnel{
+		Type:           config.TunnelTypeLocal,
+		LocalHost:      "127.0.0.1",
+		LocalPortRange: "8000-8009",
+		RemoteHost:     "media.internal",
+	}
+	var tunnels []portTunnel
+	for i := 0; i < ports; i++ {
+		portCfg := cfg
+		portCfg.LocalPortRange = ""
+		portCfg.RemotePort = 8000 + i
+		tunnels = append(tunnels, NewLocalTunnel("rtp", portCfg, &fakeSSHClient{}))
+	}
+	return NewRangeTunnel("rtp", cfg, tunnels)
+}
+
+func TestRangeTunnelSharesStats(t *testing.T) {
+	tun := newTestRange(t, 3)
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+
+	if got := tun.Status(); got != StatusConnected {
+		t.Fatalf("expected status connected, got %s", got)
+	}
+
+	for _, port := range tun.ports {
+		conn, err := net.Dial("tcp", port.(*LocalTunnel).listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		conn.Close()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for tun.Info().Stats.Connections < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := tun.Info().Stats.Connections; got != 3 {
+		t.Errorf("expected 3 connections across the range, got %d", got)
+	}
+}
+
+func TestRangeTunnelPortError(t *testing.T) {
+	tun := newTestRange(t, 2)
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer tun.Stop()
+
+	lost := errors.New("listener stopped accepting connections")
+	tun.ports[1].SetStatus(StatusError, lost)
+	if got := tun.Status(); got != StatusError {
+		t.Fatalf("expected an error on one port to fail the range, got %s", got)
+	}
+	if got := tun.Info().Error; got != lost.Error() {
+		t.Errorf("expected the port's error, got %q", got)
+	}
+}
+
+func TestRangeTunnelStartRollsBack(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer taken.Close()
+
+	tun := newTestRange(t, 2)
+	tun.ports[1].base().config.LocalPort = taken.Addr().(*net.TCPAddr).Port
+
+	if err := tun.Start(context.Background()); err == nil {
+		tun.Stop()
+		t.Fatal("expected Start to fail on a port in use")
+	}
+	if got := tun.Status(); got != StatusError {
+		t.Errorf("expected status error, got %s", got)
+	}
+	if got := tun.ports[0].Status(); got != StatusStopped {
+		t.Errorf("expected the started port to be stopped again, got %s", got)
+	}
+}
@@ -0,0 +1,67 @@
+package tunnel
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate is a throughput measurement in bytes per second
+type Rate struct {
+	Send float64
+	Recv float64
+}
+
+type rateSample struct {
+	sent     int64
+	received int64
+	at       time.Time
+}
+
+// RateTracker computes per-tunnel throughput from successive stats samples
+type RateTracker struct {
+	mu      sync.Mutex
+	samples map[string]rateSample
+}
+
+// NewRateTracker creates a new RateTracker
+func NewRateTracker() *RateTracker {
+	return &RateTracker{
+		samples: make(map[string]rateSample),
+	}
+}
+
+// Sample records the tunnel's current counters and returns the rate since the
+// previous sample. It returns false when there is no usable prior sample.
+func (r *RateTracker) Sample(name string, snap StatsSnapshot, now time.Time) (Rate, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, ok := r.samples[name]
+	r.samples[name] = rateSample{
+		sent:     snap.BytesSent,
+		received: snap.BytesReceived,
+		at:       now,
+	}
+
+	if !ok {
+		return Rate{}, false
+	}
+
+	elapsed := now.Sub(prev.at).Seconds()
+	// Counters go backwards when a tunnel is recreated on reconnect
+	if elapsed <= 0 || snap.BytesSent < prev.sent || snap.BytesReceived < prev.received {
+		return Rate{}, false
+	}
+
+	return Rate{
+		Send: float64(snap.BytesSent-prev.sent) / elapsed,
+		Recv: float64(snap.BytesReceived-prev.received) / elapsed,
+	}, true
+}
+
+// Forget drops the sample for a tunnel
+func (r *RateTracker) Forget(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.samples, name)
+}
@@ -0,0 +1,37 @@
+package tunnel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateTracker(t *testing.T) {
+	r := NewRateTracker()
+	now := time.Now()
+
+	// First sample has no prior
+	if _, ok := r.Sample("web", StatsSnapshot{BytesSent: 100, BytesReceived: 200}, now); ok {
+		t.Error("expected no rate on first sample")
+	}
+
+	rate, ok := r.Sample("web", StatsSnapshot{BytesSent: 2100, BytesReceived: 4200}, now.Add(2*time.Second))
+	if !ok {
+		t.Fatal("expected rate on second sample")
+	}
+	if rate.Send != 1000 {
+		t.Errorf("expected send rate 1000, got %v", rate.Send)
+	}
+	if rate.Recv != 2000 {
+		t.Errorf("expected recv rate 2000, got %v", rate.Recv)
+	}
+
+	// Counters reset by a reconnect don't produce a negative rate
+	if _, ok := r.Sample("web", StatsSnapshot{BytesSent: 10}, now.Add(3*time.Second)); ok {
+		t.Error("expected no rate after counters reset")
+	}
+
+	r.Forget("web")
+	if _, ok := r.Sample("web", StatsSnapshot{BytesSent: 10}, now.Add(4*time.Second)); ok {
+		t.Error("expected no rate after forget")
+	}
+}
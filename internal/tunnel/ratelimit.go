@@ -0,0 +1,120 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxLimitedChunk caps how much of one write is reserved at a time, so a
+// single large write can't hold the bucket while other connections wait
+const maxLimitedChunk = 16 * 1024
+
+// RateLimiter is a token bucket shared by every connection it limits. Writers
+// reserve bytes up front and sleep off any shortfall, so concurrent writers
+// are served in the order they asked and split the rate evenly.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second; 0 means unlimited
+	tokens float64 // may go negative while reservations are outstanding
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSec, or no limit if it is 0
+func NewRateLimiter(bytesPerSec int64) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetRate(bytesPerSec)
+	return l
+}
+
+// SetRate changes the limit, taking effect for the next reservation. A rate
+// of 0 or less removes the limit.
+func (l *RateLimiter) SetRate(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(max(bytesPerSec, 0))
+	if rate != l.rate {
+		l.rate = rate
+		l.tokens = rate
+		l.last = time.Time{}
+	}
+}
+
+// burst is how many bytes may be sent at once after an idle period
+func (l *RateLimiter) burst() float64 {
+	return max(l.rate, maxLimitedChunk)
+}
+
+// reserve takes n bytes from the bucket at now and returns how long the
+// caller must wait before sending them
+func (l *RateLimiter) reserve(n int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate == 0 {
+		return 0
+	}
+	if !l.last.IsZero() {
+		l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst())
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until n bytes may be sent or ctx is done
+func (l *RateLimiter) wait(ctx context.Context, n int) error {
+	delay := l.reserve(n, time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitedWriter holds writes to what all of its limiters allow, so a
+// connection is bounded by the tightest of them
+type limitedWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	limiters []*RateLimiter
+}
+
+// limitWriter wraps w with limiters, or returns w if there are none
+func limitWriter(ctx context.Context, w io.Writer, limiters []*RateLimiter) io.Writer {
+	if len(limiters) == 0 {
+		return w
+	}
+	return &limitedWriter{ctx: ctx, w: w, limiters: limiters}
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), maxLimitedChunk)]
+		for _, l := range lw.limiters {
+			if err := l.wait(lw.ctx, len(chunk)); err != nil {
+				return written, err
+			}
+		}
+		n, err := lw.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
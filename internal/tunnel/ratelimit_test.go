@@ -0,0 +1,109 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(100_000)
+
+	// A full bucket sends a second's worth straight away
+	if d := l.reserve(100_000, now); d != 0 {
+		t.Errorf("expected the first second's worth to go immediately, waited %v", d)
+	}
+	// Later reservations queue behind earlier ones
+	if d := l.reserve(50_000, now); d != 500*time.Millisecond {
+		t.Errorf("expected a 500ms wait, got %v", d)
+	}
+	if d := l.reserve(50_000, now); d != time.Second {
+		t.Errorf("expected a 1s wait behind the previous reservation, got %v", d)
+	}
+	// The debt is paid off as time passes
+	if d := l.reserve(10_000, now.Add(time.Second)); d != 100*time.Millisecond {
+		t.Errorf("expected a 100ms wait once the debt was repaid, got %v", d)
+	}
+
+	l.SetRate(0)
+	if d := l.reserve(1_000_000, now); d != 0 {
+		t.Errorf("expected no wait without a limit, got %v", d)
+	}
+}
+
+func TestLimitedWriterSharesRateAcrossTunnels(t *testing.T) {
+	const rate = 400_000
+	global := NewRateLimiter(rate)
+	// Use up the initial burst so only the steady rate is measured
+	global.reserve(rate, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	written := make([]int, 2)
+	for i := range written {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := limitWriter(ctx, io.Discard, []*RateLimiter{global})
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := w.Write(buf)
+				written[i] += n
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := written[0] + written[1]
+	if total > rate/2+2*maxLimitedChunk {
+		t.Errorf("expected at most about %d bytes in 500ms, got %d", rate/2, total)
+	}
+	for i, n := range written {
+		if share := float64(n) / float64(total); share < 0.3 || share > 0.7 {
+			t.Errorf("tunnel %d got %.0f%% of the bandwidth (%v), want a fair share", i, share*100, written)
+		}
+	}
+}
+
+func TestLimitedWriterUsesTightestLimit(t *testing.T) {
+	loose := NewRateLimiter(10_000_000)
+	tight := NewRateLimiter(64 * 1024)
+	w := limitWriter(context.Background(), io.Discard, []*RateLimiter{loose, tight})
+
+	// 64KB of burst, then 32KB at 64KB/s
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 96*1024)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the tighter limit to hold the write for about 500ms, took %v", elapsed)
+	}
+}
+
+func TestLimitedWriterStopsWithContext(t *testing.T) {
+	l := NewRateLimiter(1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := limitWriter(ctx, io.Discard, []*RateLimiter{l})
+	n, err := w.Write(make([]byte, 64*1024))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the write to stop with the context, got %v", err)
+	}
+	if n >= 64*1024 {
+		t.Errorf("expected a partial write, wrote %d bytes", n)
+	}
+
+	if w := limitWriter(ctx, io.Discard, nil); w != io.Discard {
+		t.Error("expected no wrapping without limiters")
+	}
+}
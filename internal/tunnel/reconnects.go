@@ -0,0 +1,51 @@
+package tunnel
+
+import (
+	"sync"
+	"time"
+)
+
+// reconnectHistorySize is how many recent reconnect times are kept per tunnel
+const reconnectHistorySize = 128
+
+// reconnectHistory counts a tunnel's reconnects and remembers when the most
+// recent ones happened. The manager shares one history between the tunnel
+// instances it rebuilds on each reconnect, so the count survives them.
+type reconnectHistory struct {
+	mu    sync.Mutex
+	count int
+	times [reconnectHistorySize]time.Time // ring buffer, next slot at count % size
+}
+
+// record notes a reconnect at now
+func (h *reconnectHistory) record(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.times[h.count%reconnectHistorySize] = now
+	h.count++
+}
+
+// total returns the number of reconnects recorded
+func (h *reconnectHistory) total() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// since returns how many recorded reconnects happened after cutoff, up to
+// reconnectHistorySize, and the time of the latest one
+func (h *reconnectHistory) since(cutoff time.Time) (int, time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return 0, time.Time{}
+	}
+	n := 0
+	for _, at := range h.times {
+		if !at.IsZero() && at.After(cutoff) {
+			n++
+		}
+	}
+	return n, h.times[(h.count-1)%reconnectHistorySize]
+}
@@ -0,0 +1,64 @@
+package tunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+func TestReconnectHistory(t *testing.T) {
+	now := time.Now()
+	var h reconnectHistory
+
+	if n, last := h.since(now.Add(-time.Hour)); n != 0 || !last.IsZero() {
+		t.Fatalf("empty history: got %d, %v", n, last)
+	}
+
+	h.record(now.Add(-2 * time.Hour))
+	h.record(now.Add(-30 * time.Minute))
+	h.record(now.Add(-time.Minute))
+
+	n, last := h.since(now.Add(-time.Hour))
+	if n != 2 {
+		t.Errorf("expected 2 reconnects in the last hour, got %d", n)
+	}
+	if !last.Equal(now.Add(-time.Minute)) {
+		t.Errorf("expected last reconnect %v, got %v", now.Add(-time.Minute), last)
+	}
+	if h.total() != 3 {
+		t.Errorf("expected total 3, got %d", h.total())
+	}
+
+	// Overflowing the ring keeps the total but only the newest times
+	for i := 0; i < reconnectHistorySize; i++ {
+		h.record(now)
+	}
+	if h.total() != reconnectHistorySize+3 {
+		t.Errorf("expected total %d, got %d", reconnectHistorySize+3, h.total())
+	}
+	if n, _ := h.since(now.Add(-time.Hour)); n != reconnectHistorySize {
+		t.Errorf("expected %d recent reconnects, got %d", reconnectHistorySize, n)
+	}
+}
+
+func TestReconnectHistorySharedAcrossInstances(t *testing.T) {
+	shared := &reconnectHistory{}
+
+	// The manager builds a new tunnel for every reconnect
+	for i := 0; i < 3; i++ {
+		b := newBaseTunnel("web", config.Tunnel{Type: config.TunnelTypeLocal})
+		b.reconnects = shared
+		b.SetStatus(StatusReconnecting, nil)
+	}
+
+	b := newBaseTunnel("web", config.Tunnel{Type: config.TunnelTypeLocal})
+	b.reconnects = shared
+	info := b.Info()
+	if info.ReconnectCount != 3 || info.RecentReconnects != 3 {
+		t.Errorf("expected 3 reconnects, got count %d, recent %d", info.ReconnectCount, info.RecentReconnects)
+	}
+	if info.LastReconnect.IsZero() {
+		t.Error("expected LastReconnect to be set")
+	}
+}
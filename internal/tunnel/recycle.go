@@ -0,0 +1,133 @@
+package tunnel
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/ssh"
+)
+
+// Recycled describes an SSH connection replaced by RecycleAgedClients
+type Recycled struct {
+	Host    string        // host alias the connection was made for
+	Age     time.Duration // how long the old connection had been up
+	Tunnels []string      // tunnels moved to the new connection
+	Failed  map[string]error
+}
+
+// agedClient is an SSH connection due to be replaced, along with the fresh
+// client to replace it with
+type agedClient struct {
+	endpoint string
+	host     string
+	old      *ssh.Client
+	fresh    *ssh.Client
+}
+
+// RecycleAgedClients replaces each SSH connection that has been up for at
+// least maxAge with a fresh one, restarting the tunnels on it. Connections
+// whose tunnels have any forwarded connections open are left for a later
+// call, as is one whose host can't be reached right now, so recycling never
+// cuts off traffic or takes down a working connection. Lazy tunnels go idle
+// and reconnect on their next connection. Tunnels that fail to restart are
+// listed in Failed, in the error state.
+func (m *Manager) RecycleAgedClients(ctx context.Context, maxAge time.Duration) []Recycled {
+	if maxAge <= 0 {
+		return nil
+	}
+	cfg, err := m.Config()
+	if err != nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	var aged []agedClient
+	for endpoint, client := range m.sshClients {
+		connectedAt := client.ConnectedAt()
+		if connectedAt.IsZero() || time.Since(connectedAt) < maxAge {
+			continue
+		}
+		names, ok := m.idleTunnelsOn(endpoint)
+		if !ok {
+			continue
+		}
+		host := m.tunnelHosts[names[0]]
+		fresh, _ := m.newSSHClient(cfg, host)
+		aged = append(aged, agedClient{endpoint: endpoint, host: host, old: client, fresh: fresh})
+	}
+	m.mu.Unlock()
+
+	var recycled []Recycled
+	for _, a := range aged {
+		// Connect afresh without the lock, keeping the old connection if
+		// the host can't be reached
+		if err := a.fresh.Connect(ctx); err != nil {
+			continue
+		}
+		r, ok := m.swapClient(ctx, a)
+		if !ok {
+			a.fresh.Close()
+			continue
+		}
+		recycled = append(recycled, r)
+	}
+	return recycled
+}
+
+// idleTunnelsOn returns the sorted names of the tunnels using the SSH
+// connection at endpoint, or false if there are none or any of them has a
+// forwarded connection open. Must be called with m.mu held.
+func (m *Manager) idleTunnelsOn(endpoint string) ([]string, bool) {
+	var names []string
+	for name, tunnel := range m.tunnels {
+		if m.tunnelEndpoints[name] != endpoint {
+			continue
+		}
+		if tunnel.Info().Stats.Active > 0 {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, len(names) > 0
+}
+
+// swapClient moves the tunnels on an aged connection to its fresh one and
+// closes the old one, unless the old connection was replaced or its tunnels
+// changed or picked up traffic while the fresh one was connecting
+func (m *Manager) swapClient(ctx context.Context, a agedClient) (Recycled, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sshClients[a.endpoint] != a.old {
+		return Recycled{}, false
+	}
+	names, ok := m.idleTunnelsOn(a.endpoint)
+	if !ok {
+		return Recycled{}, false
+	}
+	m.sshClients[a.endpoint] = a.fresh
+
+	r := Recycled{Host: a.host, Age: time.Since(a.old.ConnectedAt())}
+	for _, name := range names {
+		tunnel := m.tunnels[name]
+		if local, ok := tunnel.(*LocalTunnel); ok && local.lazy != nil {
+			local.lazy.reset()
+			delete(m.tunnelEndpoints, name)
+			continue
+		}
+		tunnel.Stop()
+		if err := m.replaceTunnel(ctx, name, tunnel, m.tunnelHosts[name], a.fresh, StatusConnecting); err != nil {
+			if r.Failed == nil {
+				r.Failed = make(map[string]error)
+			}
+			r.Failed[name] = err
+			continue
+		}
+		r.Tunnels = append(r.Tunnels, name)
+	}
+	a.old.Close()
+	m.cleanupUnusedClients()
+	return r, true
+}
@@ -0,0 +1,331 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is a local SSH server that lets any client in, echoes back
+// whatever is sent over forwarded connections, and grants remote forwards
+// until told to deny them
+type testSSHServer struct {
+	ln           net.Listener
+	port         int
+	denyForwards atomic.Bool
+}
+
+// newTestSSHServer starts a testSSHServer that home's known_hosts trusts
+func newTestSSHServer(t *testing.T, home string) *testSSHServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	serverCfg := &gossh.ServerConfig{NoClientAuth: true}
+	serverCfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	line := knownhosts.Line([]string{knownhosts.Normalize(ln.Addr().String())}, signer.PublicKey())
+	writeTestFile(t, filepath.Join(home, ".ssh", "known_hosts"), line+"\n")
+
+	s := &testSSHServer{ln: ln, port: ln.Addr().(*net.TCPAddr).Port}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, serverCfg)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) handle(conn net.Conn, serverCfg *gossh.ServerConfig) {
+	sshConn, chans, reqs, err := gossh.NewServerConn(conn, serverCfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+	go func() {
+		for req := range reqs {
+			switch req.Type {
+			case "tcpip-forward":
+				req.Reply(!s.denyForwards.Load(), nil)
+			case "cancel-tcpip-forward":
+				req.Reply(true, nil)
+			default:
+				req.Reply(false, nil)
+			}
+		}
+	}()
+	for newCh := range chans {
+		ch, requests, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		go gossh.DiscardRequests(requests)
+		go func() {
+			defer ch.Close()
+			io.Copy(ch, ch)
+		}()
+	}
+}
+
+// writeClientKey writes a new private key to path for the client to offer
+func writeClientKey(t *testing.T, path string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	block, err := gossh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	writeTestFile(t, path, string(pem.EncodeToMemory(block)))
+}
+
+// newRecycleManager returns a manager whose host "srv" is a testSSHServer,
+// with the given tunnels configured
+func newRecycleManager(t *testing.T, tunnels string) (*Manager, *testSSHServer) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.HomeEnvVar, "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	srv := newTestSSHServer(t, home)
+	writeClientKey(t, filepath.Join(home, ".ssh", "id_ed25519"))
+	writeTestFile(t, filepath.Join(home, ".bore", "config.yaml"), fmt.Sprintf(`
+hosts:
+  srv:
+    hostname: 127.0.0.1
+    port: %d
+tunnels:
+%s`, srv.port, tunnels))
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() {
+		// The echo server only closes a forwarded connection when bore does
+		for _, name := range m.ListRunningTunnels() {
+			m.DropConnections(name)
+		}
+		m.StopAll()
+	})
+	return m, srv
+}
+
+// echoThrough sends a byte through a tunnel's listener and reads it back,
+// returning the still-open connection
+func echoThrough(t *testing.T, tun Tunnel) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", tun.(*LocalTunnel).listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte{1}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 1)); err != nil {
+		t.Fatalf("expected the byte echoed back: %v", err)
+	}
+	return conn
+}
+
+const recycleWeb = "  web: {type: local, local_host: 127.0.0.1, local_port: 0, remote_port: 80}\n"
+
+func TestRecycleAgedClientsReplacesIdleClient(t *testing.T) {
+	m, _ := newRecycleManager(t, recycleWeb)
+	ctx := context.Background()
+	if err := m.StartTunnel(ctx, "web", "srv"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	endpoint := m.GetTunnelEndpoint("web")
+	old := m.sshClients[endpoint]
+	oldTunnel := m.tunnels["web"]
+
+	if recycled := m.RecycleAgedClients(ctx, time.Hour); len(recycled) != 0 {
+		t.Fatalf("expected a young connection to be kept, got %+v", recycled)
+	}
+
+	recycled := m.RecycleAgedClients(ctx, time.Nanosecond)
+	if len(recycled) != 1 {
+		t.Fatalf("expected 1 connection recycled, got %+v", recycled)
+	}
+	if r := recycled[0]; r.Host != "srv" || !slices.Equal(r.Tunnels, []string{"web"}) || r.Failed != nil {
+		t.Errorf("unexpected recycle result %+v", r)
+	}
+	fresh := m.sshClients[endpoint]
+	if fresh == old || !fresh.IsConnected() {
+		t.Error("expected a fresh connection in place of the old one")
+	}
+	if old.IsConnected() {
+		t.Error("expected the old connection to be closed")
+	}
+	if m.tunnels["web"] == oldTunnel {
+		t.Fatal("expected the tunnel to be restarted")
+	}
+	waitForStatus(t, m.tunnels["web"], StatusConnected)
+	echoThrough(t, m.tunnels["web"]).Close()
+}
+
+func TestRecycleAgedClientsSkipsBusyClient(t *testing.T) {
+	m, _ := newRecycleManager(t, recycleWeb)
+	ctx := context.Background()
+	if err := m.StartTunnel(ctx, "web", "srv"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	endpoint := m.GetTunnelEndpoint("web")
+	old := m.sshClients[endpoint]
+	oldTunnel := m.tunnels["web"]
+
+	conn := echoThrough(t, oldTunnel)
+	defer conn.Close()
+
+	if recycled := m.RecycleAgedClients(ctx, time.Nanosecond); len(recycled) != 0 {
+		t.Fatalf("expected a connection with traffic to be kept, got %+v", recycled)
+	}
+	if m.sshClients[endpoint] != old || m.tunnels["web"] != oldTunnel {
+		t.Error("expected the busy connection and its tunnel to be left alone")
+	}
+	if _, err := conn.Write([]byte{1}); err != nil {
+		t.Errorf("expected the open connection to keep working: %v", err)
+	}
+}
+
+func TestRecycleAgedClientsKeepsClientWhenHostUnreachable(t *testing.T) {
+	m, srv := newRecycleManager(t, recycleWeb)
+	ctx := context.Background()
+	if err := m.StartTunnel(ctx, "web", "srv"); err != nil {
+		t.Fatalf("StartTunnel failed: %v", err)
+	}
+	endpoint := m.GetTunnelEndpoint("web")
+	old := m.sshClients[endpoint]
+	oldTunnel := m.tunnels["web"]
+
+	// The existing connection stays up, but new ones are refused
+	srv.ln.Close()
+
+	if recycled := m.RecycleAgedClients(ctx, time.Nanosecond); len(recycled) != 0 {
+		t.Fatalf("expected nothing recycled while the host is unreachable, got %+v", recycled)
+	}
+	if m.sshClients[endpoint] != old || !old.IsConnected() {
+		t.Error("expected the old connection to be kept")
+	}
+	if m.tunnels["web"] != oldTunnel || oldTunnel.Status() != StatusConnected {
+		t.Errorf("expected the tunnel to keep running, got %s", m.tunnels["web"].Status())
+	}
+}
+
+func TestRecycleAgedClientsIdlesLazyTunnels(t *testing.T) {
+	// A second tunnel on port 0 would count as a port conflict
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	m, _ := newRecycleManager(t, recycleWeb+fmt.Sprintf(
+		"  api: {type: local, local_host: 127.0.0.1, local_port: %d, remote_port: 81, lazy: true}\n", port))
+	ctx := context.Background()
+	for _, name := range []string{"web", "api"} {
+		if err := m.StartTunnel(ctx, name, "srv"); err != nil {
+			t.Fatalf("StartTunnel %s failed: %v", name, err)
+		}
+	}
+	lazy := m.tunnels["api"]
+	conn := echoThrough(t, lazy)
+	defer conn.Close()
+	endpoint := m.GetTunnelEndpoint("web")
+	if got := m.GetTunnelEndpoint("api"); got != endpoint {
+		t.Fatalf("expected the lazy tunnel to share the connection, got %q", got)
+	}
+	// The echo server holds the forwarded connection open until bore closes it
+	if _, err := m.DropConnections("api"); err != nil {
+		t.Fatalf("DropConnections failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for lazy.Info().Stats.Active > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the lazy tunnel's connection to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recycled := m.RecycleAgedClients(ctx, time.Nanosecond)
+	if len(recycled) != 1 || !slices.Equal(recycled[0].Tunnels, []string{"web"}) {
+		t.Fatalf("expected only web to be moved, got %+v", recycled)
+	}
+	if m.tunnels["api"] != lazy || lazy.Status() != StatusIdle {
+		t.Errorf("expected the lazy tunnel to go idle in place, got %s", lazy.Status())
+	}
+	if got := m.GetTunnelEndpoint("api"); got != "" {
+		t.Errorf("expected the idle lazy tunnel to hold no connection, got %q", got)
+	}
+
+	// Its next connection uses the fresh one
+	conn = echoThrough(t, lazy)
+	defer conn.Close()
+	if got := m.GetTunnelEndpoint("api"); got != endpoint {
+		t.Errorf("expected the lazy tunnel to reconnect, got %q", got)
+	}
+}
+
+func TestRecycleAgedClientsReportsFailures(t *testing.T) {
+	m, srv := newRecycleManager(t, recycleWeb+
+		"  hook: {type: remote, local_port: 8080, remote_port: 9000}\n")
+	ctx := context.Background()
+	for _, name := range []string{"web", "hook"} {
+		if err := m.StartTunnel(ctx, name, "srv"); err != nil {
+			t.Fatalf("StartTunnel %s failed: %v", name, err)
+		}
+	}
+
+	// The fresh connection's forward for hook is refused
+	srv.denyForwards.Store(true)
+
+	recycled := m.RecycleAgedClients(ctx, time.Nanosecond)
+	if len(recycled) != 1 {
+		t.Fatalf("expected 1 connection recycled, got %+v", recycled)
+	}
+	r := recycled[0]
+	if !slices.Equal(r.Tunnels, []string{"web"}) {
+		t.Errorf("expected web to be moved, got %v", r.Tunnels)
+	}
+	if err := r.Failed["hook"]; !errors.Is(err, ErrForwardDenied) || len(r.Failed) != 1 {
+		t.Errorf("expected hook to fail with ErrForwardDenied, got %v", r.Failed)
+	}
+	if got := m.tunnels["hook"].Status(); got != StatusError {
+		t.Errorf("expected hook to be in error, got %s", got)
+	}
+}
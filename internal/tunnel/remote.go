@@ -2,14 +2,28 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/pjtatlow/bore/internal/config"
 )
 
+const (
+	// remoteListenAttempts bounds how often a reconnect retries a remote port
+	// that is still bound by the previous SSH session
+	remoteListenAttempts = 5
+
+	// remoteListenDelay is the pause between those attempts
+	remoteListenDelay = 500 * time.Millisecond
+)
+
 // RemoteTunnel implements remote port forwarding (-R)
 // It listens on the remote SSH server and forwards connections back locally
 type RemoteTunnel struct {
@@ -19,6 +33,11 @@ type RemoteTunnel struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+
+	// retryBind is set when reconnecting, where the server may not have
+	// released the previous session's forward yet
+	retryBind   bool
+	listenDelay time.Duration
 }
 
 // SSHListener defines the interface for SSH listening operations
@@ -29,8 +48,9 @@ type SSHListener interface {
 // NewRemoteTunnel creates a new remote forwarding tunnel
 func NewRemoteTunnel(name string, cfg config.Tunnel, client SSHListener) *RemoteTunnel {
 	return &RemoteTunnel{
-		baseTunnel: newBaseTunnel(name, cfg),
-		sshClient:  client,
+		baseTunnel:  newBaseTunnel(name, cfg),
+		sshClient:   client,
+		listenDelay: remoteListenDelay,
 	}
 }
 
@@ -40,76 +60,173 @@ func (t *RemoteTunnel) Start(ctx context.Context) error {
 	t.SetStatus(StatusConnecting, nil)
 
 	// Listen on the remote side via SSH
-	remoteAddr := fmt.Sprintf("0.0.0.0:%d", t.config.RemotePort)
+	remoteAddr := net.JoinHostPort(t.config.RemoteBindAddress(), strconv.Itoa(t.config.RemotePort))
 
 	listener, err := t.sshClient.Listen("tcp", remoteAddr)
+	if err != nil && t.retryBind && isBindConflict(err) {
+		// When reconnecting, the server may not have released the previous
+		// session's forward yet. Keep trying in the background rather than
+		// holding up the manager, which reconnects tunnels with its lock held.
+		t.wg.Add(1)
+		go t.retryListen(remoteAddr, err)
+		return nil
+	}
 	if err != nil {
+		err = t.listenError(err)
 		t.SetStatus(StatusError, err)
 		return fmt.Errorf("failed to listen on remote %s: %w", remoteAddr, err)
 	}
+
+	t.serve(listener)
+	return nil
+}
+
+// retryListen retries a remote listen that failed because the port is still
+// bound, a few times before reporting the tunnel as failed
+func (t *RemoteTunnel) retryListen(addr string, err error) {
+	defer t.wg.Done()
+
+	for attempt := 1; attempt < remoteListenAttempts; attempt++ {
+		t.logWarn("Tunnel '%s' remote port %d is still bound, retrying (%d/%d): %v",
+			t.name, t.config.RemotePort, attempt, remoteListenAttempts-1, err)
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(t.listenDelay):
+		}
+
+		var listener net.Listener
+		listener, err = t.sshClient.Listen("tcp", addr)
+		if err == nil {
+			t.serve(listener)
+			return
+		}
+		if !isBindConflict(err) {
+			t.SetStatus(StatusError, fmt.Errorf("failed to listen on remote %s: %w", addr, t.listenError(err)))
+			return
+		}
+	}
+	t.SetStatus(StatusError, wrapf(ErrRemotePortBusy, err, "remote port %d is still bound after %d attempts",
+		t.config.RemotePort, remoteListenAttempts))
+}
+
+// serve starts accepting connections on the remote listener, unless the
+// tunnel was stopped while it was being set up
+func (t *RemoteTunnel) serve(listener net.Listener) {
+	t.mu.Lock()
+	if t.ctx.Err() != nil {
+		t.mu.Unlock()
+		listener.Close()
+		return
+	}
 	t.listener = listener
+	t.mu.Unlock()
 
 	t.SetStatus(StatusConnected, nil)
 
 	t.wg.Add(1)
 	go t.acceptLoop()
+}
 
-	return nil
+// listenError explains a failed remote listen the server refused
+func (t *RemoteTunnel) listenError(err error) error {
+	if !isForwardDenied(err) {
+		return err
+	}
+	return wrapf(ErrForwardDenied, err,
+		"server refused to forward remote port %d (a server policy, not a bore bug: check AllowTcpForwarding and GatewayPorts in its sshd_config, and that the port is free and not privileged)",
+		t.config.RemotePort)
+}
+
+// isBindConflict reports whether a failed remote listen is the port still
+// being bound, as when the server hasn't released the previous session's
+// forward yet. A denial is left alone, since it may be server policy that
+// retrying won't change; other failures, like a dropped connection, are
+// handled by reconnecting.
+func isBindConflict(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE) ||
+		strings.Contains(err.Error(), "address already in use")
+}
+
+// isForwardDenied reports whether err is the SSH server rejecting a
+// tcpip-forward request. x/crypto/ssh only reports this as a plain string.
+func isForwardDenied(err error) bool {
+	return strings.Contains(err.Error(), "tcpip-forward request denied")
 }
 
 // acceptLoop accepts incoming connections from the remote side
 func (t *RemoteTunnel) acceptLoop() {
 	defer t.wg.Done()
 
+	var guard acceptGuard
 	for {
 		remoteConn, err := t.listener.Accept()
 		if err != nil {
+			// A listener closed by Stop isn't an error
 			select {
 			case <-t.ctx.Done():
 				return
 			default:
-				continue
 			}
+
+			if t.handleAcceptError(t.ctx, &guard, err) {
+				return
+			}
+			continue
 		}
+		guard.reset()
 
 		t.stats.IncrementConnections()
+		connID := newConnID()
+		t.logConn("Accepted connection %s from %s", connID, remoteConn.RemoteAddr())
+
 		t.wg.Add(1)
-		go t.handleConnection(remoteConn)
+		go t.handleConnection(remoteConn, connID)
 	}
 }
 
 // handleConnection handles a single forwarded connection
-func (t *RemoteTunnel) handleConnection(remoteConn net.Conn) {
+func (t *RemoteTunnel) handleConnection(remoteConn net.Conn, connID string) {
 	defer t.wg.Done()
+	defer t.stats.OpenConnection()()
 	defer remoteConn.Close()
+	connKey, untrack := t.trackConn(remoteConn)
+	defer untrack()
 
-	localAddr := fmt.Sprintf("%s:%d", t.config.LocalHost, t.config.LocalPort)
+	localAddr := t.localAddr()
 
 	localConn, err := net.Dial("tcp", localAddr)
 	if err != nil {
+		t.logConn("Connection %s failed to dial %s: %v", connID, localAddr, err)
 		return
 	}
 	defer localConn.Close()
+	if !t.trackConnEnd(connKey, localConn) {
+		t.logConn("Connection %s was dropped", connID)
+		return
+	}
+	t.tuneConn(localConn, connID)
 
 	// Bidirectional copy
 	var wg sync.WaitGroup
+	var sent, received int64
 	wg.Add(2)
 
 	// Remote -> Local
 	go func() {
 		defer wg.Done()
-		n, _ := io.Copy(localConn, remoteConn)
-		t.stats.AddReceived(n)
+		received, _ = io.Copy(&countingWriter{w: limitWriter(t.ctx, localConn, t.limiters), add: t.stats.AddReceived}, remoteConn)
 	}()
 
 	// Local -> Remote
 	go func() {
 		defer wg.Done()
-		n, _ := io.Copy(remoteConn, localConn)
-		t.stats.AddSent(n)
+		sent, _ = io.Copy(&countingWriter{w: limitWriter(t.ctx, remoteConn, t.limiters), add: t.stats.AddSent}, localConn)
 	}()
 
 	wg.Wait()
+	t.logConn("Closed connection %s from %s (sent %d bytes, received %d bytes)",
+		connID, remoteConn.RemoteAddr(), sent, received)
 }
 
 // Stop stops the tunnel
@@ -118,8 +235,11 @@ func (t *RemoteTunnel) Stop() error {
 		t.cancel()
 	}
 
-	if t.listener != nil {
-		t.listener.Close()
+	t.mu.RLock()
+	listener := t.listener
+	t.mu.RUnlock()
+	if listener != nil {
+		listener.Close()
 	}
 
 	t.wg.Wait()
@@ -0,0 +1,165 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+type fakeSSHListener struct {
+	err   error
+	fails int // fail this many calls with err, then succeed; 0 fails every call
+	calls int
+	addr  string // address of the last Listen call
+}
+
+func (f *fakeSSHListener) Listen(network, addr string) (net.Listener, error) {
+	f.calls++
+	f.addr = addr
+	if f.fails == 0 || f.calls <= f.fails {
+		return nil, f.err
+	}
+	return net.Listen("tcp", "127.0.0.1:0")
+}
+
+func TestRemoteTunnelForwardDenied(t *testing.T) {
+	tests := []struct {
+		name       string
+		listenErr  error
+		wantDenied bool
+	}{
+		{"denied by server", errors.New("ssh: tcpip-forward request denied by peer"), true},
+		{"connection lost", errors.New("EOF"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Tunnel{Type: config.TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000}
+			tun := NewRemoteTunnel("dev", cfg, &fakeSSHListener{err: tt.listenErr})
+
+			err := tun.Start(context.Background())
+			if err == nil {
+				t.Fatal("expected Start to fail")
+			}
+			if got := errors.Is(err, ErrForwardDenied); got != tt.wantDenied {
+				t.Errorf("errors.Is(err, ErrForwardDenied) = %v, want %v (err: %v)", got, tt.wantDenied, err)
+			}
+			// The server's own error stays in the message for the logs
+			if !strings.Contains(err.Error(), tt.listenErr.Error()) {
+				t.Errorf("expected %q to include the original error", err)
+			}
+			if tt.wantDenied && !strings.Contains(tun.Info().Error, "AllowTcpForwarding") {
+				t.Errorf("expected status error to mention AllowTcpForwarding, got %q", tun.Info().Error)
+			}
+			if tun.Status() != StatusError {
+				t.Errorf("expected status %s, got %s", StatusError, tun.Status())
+			}
+		})
+	}
+}
+
+func TestRemoteTunnelRetriesBindConflict(t *testing.T) {
+	inUse := errors.New("ssh: tcpip-forward request failed: address already in use")
+	denied := errors.New("ssh: tcpip-forward request denied by peer")
+
+	tests := []struct {
+		name       string
+		listener   *fakeSSHListener
+		retryBind  bool
+		wantErr    bool   // from Start itself
+		wantStatus Status // once any retries are done
+		wantCalls  int
+	}{
+		{"bound once then free", &fakeSSHListener{err: inUse, fails: 1}, true, false, StatusConnected, 2},
+		{"still bound after every attempt", &fakeSSHListener{err: inUse}, true, false, StatusError, remoteListenAttempts},
+		{"denial is not retried", &fakeSSHListener{err: denied, fails: 1}, true, true, StatusError, 1},
+		{"first start is not retried", &fakeSSHListener{err: inUse, fails: 1}, false, true, StatusError, 1},
+		{"connection lost is not retried", &fakeSSHListener{err: errors.New("EOF"), fails: 1}, true, true, StatusError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Tunnel{Type: config.TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000}
+			tun := NewRemoteTunnel("dev", cfg, tt.listener)
+			tun.retryBind = tt.retryBind
+			tun.listenDelay = time.Millisecond
+
+			err := tun.Start(context.Background())
+			defer tun.Stop()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Start() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			waitForStatus(t, tun, tt.wantStatus)
+			if tt.listener.calls != tt.wantCalls {
+				t.Errorf("Listen called %d times, want %d", tt.listener.calls, tt.wantCalls)
+			}
+			if tt.retryBind && tt.wantCalls == remoteListenAttempts && !strings.Contains(tun.Info().Error, "still bound") {
+				t.Errorf("expected the error to say the port is still bound, got %q", tun.Info().Error)
+			}
+		})
+	}
+}
+
+func TestRemoteTunnelRetriesBindInBackground(t *testing.T) {
+	inUse := errors.New("ssh: tcpip-forward request failed: address already in use")
+	cfg := config.Tunnel{Type: config.TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000}
+	tun := NewRemoteTunnel("dev", cfg, &fakeSSHListener{err: inUse})
+	tun.retryBind = true
+	tun.listenDelay = time.Hour
+
+	// Start returns straight away, since the manager holds its lock meanwhile
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if got := tun.Status(); got != StatusConnecting {
+		t.Errorf("expected status connecting while retrying, got %s", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tun.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Stop to give up on the retries")
+	}
+	if got := tun.Status(); got != StatusStopped {
+		t.Errorf("expected status stopped, got %s", got)
+	}
+}
+
+func TestRemoteTunnelBindAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		bind     string
+		wantAddr string
+	}{
+		{"loopback by default", "", "localhost:9000"},
+		{"all IPv4 interfaces", "0.0.0.0", "0.0.0.0:9000"},
+		{"IPv6 address", "::1", "[::1]:9000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener := &fakeSSHListener{fails: -1} // never fails
+			cfg := config.Tunnel{Type: config.TunnelTypeRemote, LocalPort: 3000, RemotePort: 9000, RemoteBind: tt.bind}
+			tun := NewRemoteTunnel("dev", cfg, listener)
+			if err := tun.Start(context.Background()); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			defer tun.Stop()
+
+			if listener.addr != tt.wantAddr {
+				t.Errorf("expected the server to listen on %s, got %s", tt.wantAddr, listener.addr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package tunnel
 
 import (
+	"io"
 	"sync/atomic"
 	"time"
 )
@@ -10,6 +11,9 @@ type Stats struct {
 	BytesSent     atomic.Int64
 	BytesReceived atomic.Int64
 	Connections   atomic.Int64
+	Active        atomic.Int64 // connections currently open
+	PeakActive    atomic.Int64 // most connections open at once
+	AcceptErrors  atomic.Int64
 	StartTime     time.Time
 	LastActivity  atomic.Int64 // Unix timestamp
 }
@@ -38,6 +42,24 @@ func (s *Stats) IncrementConnections() {
 	s.Connections.Add(1)
 }
 
+// OpenConnection counts a connection as open until the returned func is
+// called, raising the peak if this is the most open at once
+func (s *Stats) OpenConnection() (closed func()) {
+	active := s.Active.Add(1)
+	for {
+		peak := s.PeakActive.Load()
+		if active <= peak || s.PeakActive.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	return func() { s.Active.Add(-1) }
+}
+
+// IncrementAcceptErrors increments the failed Accept counter
+func (s *Stats) IncrementAcceptErrors() {
+	s.AcceptErrors.Add(1)
+}
+
 // Snapshot returns a snapshot of the current stats
 func (s *Stats) Snapshot() StatsSnapshot {
 	lastActivity := s.LastActivity.Load()
@@ -50,6 +72,9 @@ func (s *Stats) Snapshot() StatsSnapshot {
 		BytesSent:     s.BytesSent.Load(),
 		BytesReceived: s.BytesReceived.Load(),
 		Connections:   s.Connections.Load(),
+		Active:        s.Active.Load(),
+		PeakActive:    s.PeakActive.Load(),
+		AcceptErrors:  s.AcceptErrors.Load(),
 		StartTime:     s.StartTime,
 		LastActivity:  lastActivityTime,
 		Uptime:        time.Since(s.StartTime),
@@ -61,6 +86,9 @@ type StatsSnapshot struct {
 	BytesSent     int64
 	BytesReceived int64
 	Connections   int64
+	Active        int64
+	PeakActive    int64
+	AcceptErrors  int64
 	StartTime     time.Time
 	LastActivity  time.Time
 	Uptime        time.Duration
@@ -70,3 +98,18 @@ type StatsSnapshot struct {
 func (s StatsSnapshot) TotalBytes() int64 {
 	return s.BytesSent + s.BytesReceived
 }
+
+// countingWriter reports bytes as they are written so stats stay current
+// while long-lived connections are still open
+type countingWriter struct {
+	w   io.Writer
+	add func(int64)
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if n > 0 {
+		c.add(int64(n))
+	}
+	return n, err
+}
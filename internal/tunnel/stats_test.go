@@ -1,6 +1,7 @@
 package tunnel
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -36,6 +37,14 @@ func TestStats(t *testing.T) {
 	if snapshot.Connections != 2 {
 		t.Errorf("expected 2 connections, got %d", snapshot.Connections)
 	}
+	closed := stats.OpenConnection()
+	if got := stats.Snapshot().Active; got != 1 {
+		t.Errorf("expected 1 active connection, got %d", got)
+	}
+	closed()
+	if got := stats.Snapshot().Active; got != 0 {
+		t.Errorf("expected 0 active connections after close, got %d", got)
+	}
 	if snapshot.TotalBytes() != 300 {
 		t.Errorf("expected 300 total bytes, got %d", snapshot.TotalBytes())
 	}
@@ -69,3 +78,35 @@ func TestStatsLastActivity(t *testing.T) {
 		t.Error("expected non-zero last activity after send")
 	}
 }
+
+func TestStatsPeakActive(t *testing.T) {
+	stats := NewStats()
+
+	first := stats.OpenConnection()
+	second := stats.OpenConnection()
+	second()
+	third := stats.OpenConnection()
+	if got := stats.Snapshot().PeakActive; got != 2 {
+		t.Errorf("expected a peak of 2 open connections, got %d", got)
+	}
+
+	first()
+	third()
+	snapshot := stats.Snapshot()
+	if snapshot.Active != 0 || snapshot.PeakActive != 2 {
+		t.Errorf("expected the peak to outlast the connections, got %d open and peak %d", snapshot.Active, snapshot.PeakActive)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer stats.OpenConnection()()
+		}()
+	}
+	wg.Wait()
+	if peak := stats.Snapshot().PeakActive; peak < 2 || peak > 50 {
+		t.Errorf("expected a peak between 2 and 50, got %d", peak)
+	}
+}
@@ -2,6 +2,12 @@ package tunnel
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pjtatlow/bore/internal/config"
@@ -16,18 +22,34 @@ const (
 	StatusConnected    Status = "connected"
 	StatusReconnecting Status = "reconnecting"
 	StatusError        Status = "error"
+	StatusIdle         Status = "idle"   // lazy tunnel listening without an SSH connection
+	StatusPaused       Status = "paused" // not forwarding, but keeps its host and stats until resumed
 )
 
+// Healthy reports whether a tunnel is as it should be: connected, a lazy
+// tunnel idle until used, or deliberately paused
+func (s Status) Healthy() bool {
+	return s == StatusConnected || s == StatusIdle || s == StatusPaused
+}
+
+// Halted reports whether a tunnel has stopped forwarding, paused or failed,
+// so bringing it up again should start it afresh
+func (s Status) Halted() bool {
+	return s == StatusPaused || s == StatusError
+}
+
 // Info contains runtime information about a tunnel
 type Info struct {
-	Name           string
-	Config         config.Tunnel
-	Status         Status
-	Error          string
-	Stats          StatsSnapshot
-	ReconnectCount int
-	LastConnected  time.Time
-	LastError      time.Time
+	Name             string
+	Config           config.Tunnel
+	Status           Status
+	Error            string
+	Stats            StatsSnapshot
+	ReconnectCount   int
+	RecentReconnects int // reconnects in the last hour
+	LastReconnect    time.Time
+	LastConnected    time.Time
+	LastError        time.Time
 }
 
 // Tunnel represents an SSH tunnel (local or remote forwarding)
@@ -52,18 +74,47 @@ type Tunnel interface {
 
 	// SetStatus updates the tunnel status
 	SetStatus(status Status, err error)
+
+	// SetOnStatusChange sets a callback to be called when the status changes
+	SetOnStatusChange(fn StatusChangeFunc)
+
+	// DropConnections closes every forwarded connection, keeping the
+	// listener up, and returns how many were closed
+	DropConnections() int
+}
+
+// StatusChangeFunc is called when a tunnel transitions to a new status
+type StatusChangeFunc func(name string, status Status, err error)
+
+// ConnLogger receives connection-level debug events from a tunnel
+type ConnLogger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// WarnLogger receives warnings from a tunnel
+type WarnLogger interface {
+	Warnf(format string, args ...interface{})
 }
 
 // baseTunnel contains common tunnel functionality
 type baseTunnel struct {
+	mu             sync.RWMutex
 	name           string
 	config         config.Tunnel
 	status         Status
 	lastError      error
 	stats          *Stats
-	reconnectCount int
+	reconnects     *reconnectHistory
 	lastConnected  time.Time
 	lastErrorTime  time.Time
+	onStatusChange StatusChangeFunc
+	connLogger     ConnLogger
+	warnLogger     WarnLogger
+	limiters       []*RateLimiter // bandwidth limits every connection draws from
+
+	connsMu  sync.Mutex
+	conns    map[uint64][]io.Closer // both ends of each open connection, by tracking key
+	connsSeq uint64                 // last tracking key handed out
 }
 
 func newBaseTunnel(name string, cfg config.Tunnel) *baseTunnel {
@@ -72,6 +123,8 @@ func newBaseTunnel(name string, cfg config.Tunnel) *baseTunnel {
 		config: cfg,
 		status: StatusStopped,
 		stats:  NewStats(),
+
+		reconnects: &reconnectHistory{},
 	}
 }
 
@@ -83,11 +136,34 @@ func (t *baseTunnel) Config() config.Tunnel {
 	return t.config
 }
 
+// localAddr returns the tunnel's local host:port, bracketing IPv6 literals
+func (t *baseTunnel) localAddr() string {
+	return net.JoinHostPort(t.config.LocalHost, strconv.Itoa(t.config.LocalPort))
+}
+
+// remoteAddr returns the tunnel's remote host:port, bracketing IPv6 literals,
+// or its remote socket path
+func (t *baseTunnel) remoteAddr() string {
+	return t.config.RemoteTarget()
+}
+
+// remoteNetwork returns the network to dial remoteAddr on
+func (t *baseTunnel) remoteNetwork() string {
+	if t.config.RemoteSocket != "" {
+		return "unix"
+	}
+	return "tcp"
+}
+
 func (t *baseTunnel) Status() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.status
 }
 
 func (t *baseTunnel) SetStatus(status Status, err error) {
+	t.mu.Lock()
+	changed := t.status != status
 	t.status = status
 	if err != nil {
 		t.lastError = err
@@ -97,24 +173,131 @@ func (t *baseTunnel) SetStatus(status Status, err error) {
 		t.lastConnected = time.Now()
 	}
 	if status == StatusReconnecting {
-		t.reconnectCount++
+		t.reconnects.record(time.Now())
+	}
+	callback := t.onStatusChange
+	t.mu.Unlock()
+
+	if changed && callback != nil {
+		callback(t.name, status, err)
 	}
 }
 
+func (t *baseTunnel) SetOnStatusChange(fn StatusChangeFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onStatusChange = fn
+}
+
 func (t *baseTunnel) Info() Info {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	recent, lastReconnect := t.reconnects.since(time.Now().Add(-time.Hour))
+
 	errMsg := ""
 	if t.lastError != nil {
 		errMsg = t.lastError.Error()
 	}
 	return Info{
-		Name:           t.name,
-		Config:         t.config,
-		Status:         t.status,
-		Error:          errMsg,
-		Stats:          t.stats.Snapshot(),
-		ReconnectCount: t.reconnectCount,
-		LastConnected:  t.lastConnected,
-		LastError:      t.lastErrorTime,
+		Name:             t.name,
+		Config:           t.config,
+		Status:           t.status,
+		Error:            errMsg,
+		Stats:            t.stats.Snapshot(),
+		ReconnectCount:   t.reconnects.total(),
+		RecentReconnects: recent,
+		LastReconnect:    lastReconnect,
+		LastConnected:    t.lastConnected,
+		LastError:        t.lastErrorTime,
+	}
+}
+
+// base returns the shared tunnel state, so a replacement tunnel can take
+// over its predecessor's stats
+func (t *baseTunnel) base() *baseTunnel {
+	return t
+}
+
+// trackConn records an accepted connection so DropConnections can close it.
+// It returns the key to pass to trackConnEnd, unique within the tunnel even
+// when log connection IDs collide, and a func that forgets the connection once
+// it has finished.
+func (t *baseTunnel) trackConn(conn io.Closer) (key uint64, untrack func()) {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[uint64][]io.Closer)
+	}
+	t.connsSeq++
+	key = t.connsSeq
+	t.conns[key] = []io.Closer{conn}
+	return key, func() {
+		t.connsMu.Lock()
+		defer t.connsMu.Unlock()
+		delete(t.conns, key)
+	}
+}
+
+// trackConnEnd adds the dialed end of a tracked connection, reporting false
+// if the connection was dropped while it was being dialed
+func (t *baseTunnel) trackConnEnd(key uint64, conn io.Closer) bool {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	ends, ok := t.conns[key]
+	if !ok {
+		return false
+	}
+	t.conns[key] = append(ends, conn)
+	return true
+}
+
+func (t *baseTunnel) DropConnections() int {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	for _, ends := range t.conns {
+		for _, conn := range ends {
+			conn.Close()
+		}
 	}
+	dropped := len(t.conns)
+	clear(t.conns)
+	return dropped
 }
 
+// tuneConn applies the tunnel's TCP options to the machine-side leg of a
+// forwarded connection. The SSH side is a channel on a connection shared by
+// every tunnel on the host, so it can't be tuned per tunnel.
+func (t *baseTunnel) tuneConn(conn net.Conn, connID string) {
+	if t.config.NoDelay == nil {
+		return
+	}
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	if err := tcp.SetNoDelay(*t.config.NoDelay); err != nil {
+		t.logConn("Connection %s failed to set TCP_NODELAY: %v", connID, err)
+	}
+}
+
+// logConn logs a connection-level event if connection logging is enabled
+func (t *baseTunnel) logConn(format string, args ...interface{}) {
+	if t.connLogger != nil {
+		t.connLogger.Debugf(format, args...)
+	}
+}
+
+// logWarn logs a tunnel-level warning if a warning logger is set
+func (t *baseTunnel) logWarn(format string, args ...interface{}) {
+	if t.warnLogger != nil {
+		t.warnLogger.Warnf(format, args...)
+	}
+}
+
+// newConnID returns a short random ID for correlating a connection's log events
+func newConnID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package tunnel
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// noDelay reads TCP_NODELAY from a connection's socket
+func noDelay(t *testing.T, conn *net.TCPConn) bool {
+	t.Helper()
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn failed: %v", err)
+	}
+	var value int
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		value, sockErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+	}); err != nil {
+		t.Fatalf("Control failed: %v", err)
+	}
+	if sockErr != nil {
+		t.Fatalf("getsockopt failed: %v", sockErr)
+	}
+	return value != 0
+}
+
+func TestTuneConnNoDelay(t *testing.T) {
+	off, on := false, true
+	tests := []struct {
+		name    string
+		noDelay *bool
+		want    bool
+	}{
+		{"unset keeps the default", nil, true},
+		{"disabled", &off, false},
+		{"enabled", &on, true},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			b := newBaseTunnel("ssh", config.Tunnel{NoDelay: tt.noDelay})
+			b.tuneConn(conn, "test")
+			if got := noDelay(t, conn.(*net.TCPConn)); got != tt.want {
+				t.Errorf("TCP_NODELAY = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package version holds build metadata for the bore binary
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, set at link time with
+//
+//	go build -ldflags "-X github.com/pjtatlow/bore/internal/version.Version=v1.2.3 \
+//	  -X github.com/pjtatlow/bore/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/pjtatlow/bore/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left unset fall back to what the Go toolchain embedded in the binary.
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes a bore build
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// Get returns the build metadata for the running binary
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String formats the build as "v1.2.3 (abc1234, 2024-01-02T03:04:05Z)"
+func (i Info) String() string {
+	return fmt.Sprintf("%s (%s, %s)", i.Version, shortCommit(i.Commit), i.Date)
+}
+
+// Matches reports whether two builds are the same version and commit
+func (i Info) Matches(other Info) bool {
+	return i.Version == other.Version && i.Commit == other.Commit
+}
+
+// shortCommit abbreviates a full commit hash
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
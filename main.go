@@ -9,7 +9,13 @@ import (
 
 func main() {
 	if err := cli.Execute(); err != nil {
+		if cli.Quiet(err) {
+			os.Exit(cli.ExitCode(err))
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if hint := cli.Hint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -0,0 +1,211 @@
+// Package bore runs bore's SSH tunnels inside another Go program, without the
+// daemon or the bore CLI.
+//
+// A Manager starts and stops tunnels defined in a Config, sharing one SSH
+// connection per server between them just as the daemon does. Unlike the
+// daemon it doesn't reconnect dropped tunnels on its own; use OnStatusChange
+// and RestartTunnel to do that.
+//
+// The bore daemon runs on this package too. Its tunnel status and the hosts
+// tunnel up and group enable fall back to come from a Manager and its
+// Config. What this API leaves out on purpose, such as config reloads,
+// remote targets overridden by tunnel up, SSH health checks, and connection
+// recycling, the daemon does on the tunnel manager underneath, so tunnels
+// behave the same in-process as they do under the daemon.
+package bore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/lib"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+// Status is a tunnel's connection state
+type Status string
+
+// Tunnel statuses
+const (
+	StatusStopped      Status = "stopped"
+	StatusConnecting   Status = "connecting"
+	StatusConnected    Status = "connected"
+	StatusReconnecting Status = "reconnecting"
+	StatusError        Status = "error"
+	StatusIdle         Status = "idle"   // a lazy tunnel listening without an SSH connection
+	StatusPaused       Status = "paused" // stopped by PauseTunnel until ResumeTunnel
+)
+
+// Errors returned by Manager methods, for use with errors.Is
+var (
+	ErrTunnelNotFound  = tunnel.ErrTunnelNotFound
+	ErrGroupNotFound   = tunnel.ErrGroupNotFound
+	ErrNotRunning      = tunnel.ErrNotRunning
+	ErrPortConflict    = tunnel.ErrPortConflict
+	ErrHostUnreachable = tunnel.ErrHostUnreachable
+	ErrPaused          = tunnel.ErrPaused
+	ErrHostRequired    = errors.New("host required")
+)
+
+// TunnelStatus describes one running tunnel
+type TunnelStatus struct {
+	Name          string
+	Type          TunnelType
+	Host          string // host the tunnel connects through
+	Endpoint      string // user@hostname:port the host resolved to
+	Status        Status
+	Error         string // why the tunnel is in the error state
+	BytesSent     int64
+	BytesReceived int64
+	Connections   int64 // connections forwarded since the tunnel started
+	ActiveConns   int64 // connections open now
+	PeakConns     int64 // most connections open at once
+}
+
+// Manager runs tunnels from a Config. It is safe for concurrent use.
+type Manager lib.Manager
+
+func (m *Manager) tunnels() *tunnel.Manager {
+	return (*lib.Manager)(m).Tunnels()
+}
+
+func (m *Manager) ctx() context.Context {
+	return (*lib.Manager)(m).Context()
+}
+
+// New creates a Manager for the tunnels in cfg. Hosts not defined in cfg
+// are looked up in ~/.ssh/config, as they are by the daemon.
+func New(cfg *Config) (*Manager, error) {
+	if err := cfg.cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	manager, err := tunnel.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	manager.SetConfigLoader(func() (*config.Config, error) { return cfg.cfg, nil })
+	if _, err := manager.LoadConfig(); err != nil {
+		return nil, err
+	}
+
+	return (*Manager)(lib.New(manager)), nil
+}
+
+// Config returns the config the Manager runs tunnels from
+func (m *Manager) Config() (*Config, error) {
+	cfg, err := m.tunnels().Config()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{cfg: cfg}, nil
+}
+
+// StartTunnel starts the named tunnel via host, or via the tunnel's
+// configured host if host is empty. A tunnel already running via another
+// host is moved to this one.
+func (m *Manager) StartTunnel(name, host string) error {
+	if host == "" {
+		cfg, err := m.Config()
+		if err != nil {
+			return err
+		}
+		if host, err = cfg.TunnelHost(name); err != nil {
+			return err
+		}
+	}
+	return m.tunnels().StartTunnel(m.ctx(), name, host)
+}
+
+// StopTunnel stops the named tunnel
+func (m *Manager) StopTunnel(name string) error {
+	return m.tunnels().StopTunnel(name)
+}
+
+// RestartTunnel reconnects a running tunnel via the host it is already using,
+// e.g. after OnStatusChange reports that it failed
+func (m *Manager) RestartTunnel(name string) error {
+	return m.tunnels().ReconnectTunnel(m.ctx(), name)
+}
+
+// PauseTunnel stops the named tunnel forwarding but keeps its host and
+// stats until ResumeTunnel or StopTunnel
+func (m *Manager) PauseTunnel(name string) error {
+	return m.tunnels().PauseTunnel(name)
+}
+
+// ResumeTunnel starts a paused tunnel again via the host it was using
+func (m *Manager) ResumeTunnel(name string) error {
+	return m.tunnels().ResumeTunnel(m.ctx(), name)
+}
+
+// DropConnections closes the named tunnel's open connections without
+// stopping it, returning how many were closed
+func (m *Manager) DropConnections(name string) (int, error) {
+	return m.tunnels().DropConnections(name)
+}
+
+// StartGroup starts every tunnel in the named group via host, or via the
+// group's configured host if host is empty. If any tunnel fails to start,
+// the ones already started are stopped again.
+func (m *Manager) StartGroup(name, host string) error {
+	cfg, err := m.Config()
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		if host, err = cfg.GroupHost(name); err != nil {
+			return err
+		}
+	} else if _, ok := cfg.cfg.GetGroup(name); !ok {
+		return fmt.Errorf("group '%s' not found in config: %w", name, ErrGroupNotFound)
+	}
+	return m.tunnels().StartGroup(m.ctx(), name, host)
+}
+
+// StopGroup stops every tunnel in the named group
+func (m *Manager) StopGroup(name string) error {
+	return m.tunnels().StopGroup(name)
+}
+
+// Status returns the running tunnels, sorted by name
+func (m *Manager) Status() []TunnelStatus {
+	infos := m.tunnels().GetAllTunnelInfo()
+	statuses := make([]TunnelStatus, 0, len(infos))
+	for _, info := range infos {
+		statuses = append(statuses, TunnelStatus{
+			Name:          info.Name,
+			Type:          TunnelType(info.Config.Type),
+			Host:          m.tunnels().GetTunnelHost(info.Name),
+			Endpoint:      m.tunnels().GetTunnelEndpoint(info.Name),
+			Status:        Status(info.Status),
+			Error:         info.Error,
+			BytesSent:     info.Stats.BytesSent,
+			BytesReceived: info.Stats.BytesReceived,
+			Connections:   info.Stats.Connections,
+			ActiveConns:   info.Stats.Active,
+			PeakConns:     info.Stats.PeakActive,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// OnStatusChange calls fn whenever a tunnel started after this call changes
+// status. fn may be called while the Manager is busy with that tunnel, so it
+// must not block or call Manager methods itself; start a goroutine instead.
+func (m *Manager) OnStatusChange(fn func(name string, status Status, err error)) {
+	m.tunnels().SetOnStatusChange(func(name string, status tunnel.Status, err error) {
+		fn(name, Status(status), err)
+	})
+}
+
+// Close stops every tunnel and closes the SSH connections
+func (m *Manager) Close() error {
+	err := m.tunnels().StopAll()
+	(*lib.Manager)(m).Cancel()
+	return err
+}
@@ -0,0 +1,156 @@
+package bore
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/pjtatlow/bore/internal/config"
+	"github.com/pjtatlow/bore/internal/tunnel"
+)
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+tunnels:
+  web: {type: sideways, local_port: 8080, remote_port: 80}
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if _, err := New(cfg); err == nil {
+		t.Error("expected an invalid config to be rejected")
+	}
+}
+
+func TestStartRequiresHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg, err := ParseConfig([]byte(`
+tunnels:
+  web:
+    type: local
+    local_port: 8080
+    remote_port: 80
+groups:
+  dev:
+    tunnels: [web]
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer m.Close()
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"tunnel without a host", m.StartTunnel("web", ""), ErrHostRequired},
+		{"unknown tunnel", m.StartTunnel("db", ""), ErrTunnelNotFound},
+		{"group without a host", m.StartGroup("dev", ""), ErrHostRequired},
+		{"unknown group", m.StartGroup("prod", "bastion"), ErrGroupNotFound},
+		{"stopping a tunnel that isn't running", m.StopTunnel("web"), ErrNotRunning},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.err)
+		}
+	}
+
+	if status := m.Status(); len(status) != 0 {
+		t.Errorf("expected no running tunnels, got %+v", status)
+	}
+}
+
+func TestConfigNames(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+hosts:
+  bastion: {hostname: bastion.example.com}
+tunnels:
+  web: {type: local, local_port: 8080, remote_port: 80}
+  db: {type: local, local_port: 5432, remote_port: 5432}
+groups:
+  dev: {tunnels: [web, db]}
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if got := cfg.Tunnels(); !slices.Equal(got, []string{"db", "web"}) {
+		t.Errorf("Tunnels() = %v", got)
+	}
+	if got := cfg.Groups(); !slices.Equal(got, []string{"dev"}) {
+		t.Errorf("Groups() = %v", got)
+	}
+	if got := cfg.Hosts(); !slices.Equal(got, []string{"bastion"}) {
+		t.Errorf("Hosts() = %v", got)
+	}
+}
+
+func TestConfigHosts(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+tunnels:
+  web: {type: local, local_port: 8080, remote_port: 80, host: bastion}
+  db: {type: local, local_port: 5432, remote_port: 5432}
+groups:
+  dev: {tunnels: [web, db], host: jump}
+  split: {tunnels: [web@bastion, db@jump]}
+  all: {tunnels: [web, db]}
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		host     func() (string, error)
+		wantHost string
+		wantErr  error
+	}{
+		{"tunnel with a host", func() (string, error) { return cfg.TunnelHost("web") }, "bastion", nil},
+		{"tunnel without a host", func() (string, error) { return cfg.TunnelHost("db") }, "", ErrHostRequired},
+		{"unknown tunnel", func() (string, error) { return cfg.TunnelHost("cache") }, "", ErrTunnelNotFound},
+		{"group with a host", func() (string, error) { return cfg.GroupHost("dev") }, "jump", nil},
+		{"group whose tunnels have hosts", func() (string, error) { return cfg.GroupHost("split") }, "", nil},
+		{"group needing a host", func() (string, error) { return cfg.GroupHost("all") }, "", ErrHostRequired},
+		{"unknown group", func() (string, error) { return cfg.GroupHost("prod") }, "", ErrGroupNotFound},
+	}
+	for _, tt := range tests {
+		host, err := tt.host()
+		if host != tt.wantHost || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tt.name, host, err, tt.wantHost, tt.wantErr)
+		}
+	}
+}
+
+// The exported values must stay in step with the ones they are converted from
+func TestTypesMatchInternal(t *testing.T) {
+	statuses := map[tunnel.Status]Status{
+		tunnel.StatusStopped:      StatusStopped,
+		tunnel.StatusConnecting:   StatusConnecting,
+		tunnel.StatusConnected:    StatusConnected,
+		tunnel.StatusReconnecting: StatusReconnecting,
+		tunnel.StatusError:        StatusError,
+		tunnel.StatusIdle:         StatusIdle,
+		tunnel.StatusPaused:       StatusPaused,
+	}
+	for internal, exported := range statuses {
+		if Status(internal) != exported {
+			t.Errorf("status %q is exported as %q", internal, exported)
+		}
+	}
+
+	types := map[config.TunnelType]TunnelType{
+		config.TunnelTypeLocal:  TunnelTypeLocal,
+		config.TunnelTypeRemote: TunnelTypeRemote,
+	}
+	for internal, exported := range types {
+		if TunnelType(internal) != exported {
+			t.Errorf("tunnel type %q is exported as %q", internal, exported)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package bore
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pjtatlow/bore/internal/config"
+)
+
+// Config is a bore configuration, in the same shape as ~/.bore/config.yaml.
+// Tunnels, hosts, and groups are defined in that format, which is what this
+// package keeps stable, so a Config is built by ParseConfig or LoadConfig
+// rather than field by field.
+type Config struct {
+	cfg *config.Config
+}
+
+// TunnelType is the direction a tunnel forwards in
+type TunnelType string
+
+// Tunnel types
+const (
+	TunnelTypeLocal  TunnelType = "local"  // listens here and dials from the SSH server
+	TunnelTypeRemote TunnelType = "remote" // listens on the SSH server and dials from here
+)
+
+// DefaultConfig returns an empty config with bore's default settings
+func DefaultConfig() *Config {
+	return &Config{cfg: config.DefaultConfig()}
+}
+
+// ParseConfig parses a config from YAML, applying defaults
+func ParseConfig(data []byte) (*Config, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{cfg: cfg}, nil
+}
+
+// LoadConfig reads a config file. A missing file gives the default config.
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{cfg: cfg}, nil
+}
+
+// DefaultConfigPath returns the config file the bore CLI uses: config.yaml in
+// $BORE_HOME, or ~/.bore if that is unset
+func DefaultConfigPath() (string, error) {
+	return config.ConfigPath()
+}
+
+// Tunnels returns the names of the tunnels defined in the config, sorted
+func (c *Config) Tunnels() []string {
+	return sortedKeys(c.cfg.Tunnels)
+}
+
+// Groups returns the names of the groups defined in the config, sorted
+func (c *Config) Groups() []string {
+	return sortedKeys(c.cfg.Groups)
+}
+
+// Hosts returns the names of the hosts defined in the config, sorted. Hosts
+// only in ~/.ssh/config can be used too but aren't listed.
+func (c *Config) Hosts() []string {
+	return sortedKeys(c.cfg.Hosts)
+}
+
+// TunnelHost returns the host the named tunnel starts via when given none
+func (c *Config) TunnelHost(name string) (string, error) {
+	t, ok := c.cfg.GetTunnel(name)
+	if !ok {
+		return "", fmt.Errorf("tunnel '%s' not found in config: %w", name, ErrTunnelNotFound)
+	}
+	if t.Host == "" {
+		return "", fmt.Errorf("tunnel '%s' has no configured host: %w", name, ErrHostRequired)
+	}
+	return t.Host, nil
+}
+
+// GroupHost returns the host the named group starts via when given none. It
+// is empty, without an error, if every tunnel in the group is listed with a
+// host of its own, as "tunnel@host".
+func (c *Config) GroupHost(name string) (string, error) {
+	group, ok := c.cfg.GetGroup(name)
+	if !ok {
+		return "", fmt.Errorf("group '%s' not found in config: %w", name, ErrGroupNotFound)
+	}
+	if group.Host == "" && group.NeedsHost() {
+		return "", fmt.Errorf("group '%s' has no configured host: %w", name, ErrHostRequired)
+	}
+	return group.Host, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package bore_test
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/pjtatlow/bore/pkg/bore"
+)
+
+func Example() {
+	cfg, err := bore.ParseConfig([]byte(`
+hosts:
+  bastion:
+    hostname: bastion.example.com
+    user: deploy
+tunnels:
+  db:
+    type: local
+    host: bastion
+    local_port: 5432
+    remote_port: 5432
+`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m, err := bore.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.StartTunnel("db", ""); err != nil {
+		log.Fatal(err)
+	}
+	for _, t := range m.Status() {
+		fmt.Printf("%s via %s: %s\n", t.Name, t.Endpoint, t.Status)
+	}
+
+	// Keep the tunnel up until interrupted
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+}
+
+func ExampleManager_OnStatusChange() {
+	cfg, err := bore.LoadConfig("tunnels.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	m, err := bore.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
+
+	// Restart tunnels that fail, as the daemon would
+	m.OnStatusChange(func(name string, status bore.Status, err error) {
+		if status == bore.StatusError {
+			go func() {
+				if err := m.RestartTunnel(name); err != nil {
+					log.Printf("failed to restart %s: %v", name, err)
+				}
+			}()
+		}
+	})
+
+	if err := m.StartGroup("dev", ""); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleManager_StartTunnel_notFound() {
+	m, err := bore.New(bore.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
+
+	err = m.StartTunnel("cache", "bastion")
+	fmt.Println(errors.Is(err, bore.ErrTunnelNotFound))
+	// Output: true
+}